@@ -2,10 +2,30 @@
 package main
 
 import (
+	"log"
+	"os"
+
 	"github.com/cyberdr0id/referral/internal/api"
 )
 
+// commands presents one-off commands which are run instead of the application.
+var commands = map[string]func(args []string) error{
+	api.ImportCommand:      api.Import,
+	api.MakePrivateCommand: api.MakePrivate,
+	api.ReconcileCommand:   api.Reconcile,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+
+			return
+		}
+	}
+
 	if logger, err := api.Start(); err != nil {
 		logger.ErrorLogger.Fatal(err)
 	}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/cyberdr0id/referral/internal/storage"
+)
+
+// MakePrivateCommand presents a name of command which makes CVs uploaded as public-read objects private.
+const MakePrivateCommand = "make-private"
+
+var errMakePrivateUsage = errors.New("usage: make-private [-dry-run] [-prefix prefix]")
+
+// MakePrivate sets private ACL on objects of S3 bucket and prints their keys to stdout.
+func MakePrivate(args []string) error {
+	flags := flag.NewFlagSet(MakePrivateCommand, flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print keys of objects without updating them")
+	prefix := flags.String("prefix", "", "update only objects whose keys start with the prefix")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errMakePrivateUsage
+	}
+
+	store, err := storage.NewS3Store()
+	if err != nil {
+		return fmt.Errorf("cannot create new instance of object storage: %w", err)
+	}
+
+	count, err := store.MakePrivate(*prefix, *dryRun, func(key string) {
+		fmt.Println(key)
+	})
+	if err != nil {
+		return fmt.Errorf("cannot make objects private: %w", err)
+	}
+
+	if *dryRun {
+		fmt.Printf("%d objects would be made private\n", count)
+	} else {
+		fmt.Printf("%d objects were made private\n", count)
+	}
+
+	return nil
+}
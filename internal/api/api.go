@@ -1,20 +1,39 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/cyberdr0id/referral/internal/event"
 	"github.com/cyberdr0id/referral/internal/handler"
+	"github.com/cyberdr0id/referral/internal/notification"
 	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/scan"
 	"github.com/cyberdr0id/referral/internal/service"
 	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/cyberdr0id/referral/internal/webhook"
 	"github.com/cyberdr0id/referral/pkg/jwt"
 	mylog "github.com/cyberdr0id/referral/pkg/log"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type appConfig struct {
-	Port string `envconfig:"APP_PORT"`
+	Port               string                   `envconfig:"APP_PORT"`
+	LeaderboardEnabled bool                     `envconfig:"APP_LEADERBOARD_ENABLED"`
+	StreamCVs          bool                     `envconfig:"APP_STREAM_CVS"`
+	MaxCVSize          int64                    `envconfig:"APP_MAX_CV_SIZE" default:"10485760"`
+	SLA                map[string]time.Duration `envconfig:"APP_SLA"`
+	SLACheckInterval   time.Duration            `envconfig:"APP_SLA_CHECK_INTERVAL" default:"1h"`
+	SLARemindEvery     time.Duration            `envconfig:"APP_SLA_REMIND_EVERY" default:"24h"`
+	WebhookInterval    time.Duration            `envconfig:"APP_WEBHOOK_INTERVAL" default:"10s"`
+	WebhookTimeout     time.Duration            `envconfig:"APP_WEBHOOK_TIMEOUT" default:"10s"`
+	OutboxInterval     time.Duration            `envconfig:"APP_OUTBOX_INTERVAL" default:"5s"`
+	ScanInterval       time.Duration            `envconfig:"APP_SCAN_INTERVAL" default:"10s"`
+	ExtractInterval    time.Duration            `envconfig:"APP_EXTRACT_INTERVAL" default:"30s"`
+	ReconcileInterval  time.Duration            `envconfig:"APP_RECONCILE_INTERVAL" default:"24h"`
+	ReconcileDelete    bool                     `envconfig:"APP_RECONCILE_DELETE"`
 }
 
 // Start starts API with initialization of necessary components.
@@ -38,22 +57,125 @@ func Start() (*mylog.Logger, error) {
 		return logger, fmt.Errorf("error with creating JWT token manager: %w", err)
 	}
 
-	gcs, err := storage.NewStorage()
+	files, err := storage.NewFileStore()
 	if err != nil {
 		fmt.Println(fmt.Errorf("cannot create new instance of object storage: %s", err))
 		return logger, fmt.Errorf("cannot create new instance of object storage: %s", err)
 	}
 
-	authService := service.NewAuthService(repo, tm)
-	referralService := service.NewReferralService(repo, gcs)
-
-	server := handler.NewServer(authService, referralService, logger)
-
 	cfg, err := loadConfig()
 	if err != nil {
 		return logger, fmt.Errorf("error with loading app config: %w", err)
 	}
 	log.Println(cfg)
+
+	authService := service.NewAuthService(repo, tm)
+	referralService := service.NewReferralService(repo, files)
+	referralService.SLA = service.SLA{Limits: cfg.SLA, RemindEvery: cfg.SLARemindEvery}
+	referralService.Webhooks = webhook.NewClient(cfg.WebhookTimeout)
+	referralService.Logger = logger.ErrorLogger
+
+	referralService.Keys, err = storage.NewKeyLayout()
+	if err != nil {
+		return logger, fmt.Errorf("cannot load storage key layout: %w", err)
+	}
+	if cfg.ReconcileDelete && referralService.Keys.Root() == "" {
+		return logger, fmt.Errorf("cannot delete orphaned files by reconciliation: %w", service.ErrNoKeyPrefix)
+	}
+
+	referralService.Notifier, err = notification.NewNotifier(service.NewRecipientFinder(repo), logger.InfoLogger)
+	if err != nil {
+		return logger, fmt.Errorf("cannot create notifier: %w", err)
+	}
+
+	referralService.Publisher, err = event.NewPublisher(logger.InfoLogger)
+	if err != nil {
+		return logger, fmt.Errorf("cannot create events publisher: %w", err)
+	}
+
+	referralService.Scanner, err = scan.NewScanner()
+	if err != nil {
+		return logger, fmt.Errorf("cannot create malware scanner: %w", err)
+	}
+
+	server := handler.NewServer(authService, referralService, logger)
+	server.LeaderboardEnabled = cfg.LeaderboardEnabled
+	server.StreamCVs = cfg.StreamCVs
+	server.MaxCVSize = cfg.MaxCVSize
+
+	if local, ok := files.(*storage.LocalStore); ok {
+		server.MountFiles(local)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go runPeriodically(ctx, cfg.SLACheckInterval, func(now time.Time) {
+		n, err := referralService.SendSLAReminders(now)
+		if err != nil {
+			logger.ErrorLogger.Println(fmt.Errorf("cannot send SLA reminders: %w", err))
+		}
+		if n != 0 {
+			logger.InfoLogger.Printf("sent reminders about %d overdue requests", n)
+		}
+	})
+
+	go runPeriodically(ctx, cfg.OutboxInterval, func(now time.Time) {
+		if _, err := referralService.RelayEvents(now); err != nil {
+			logger.ErrorLogger.Println(fmt.Errorf("cannot relay events: %w", err))
+		}
+	})
+
+	go runPeriodically(ctx, cfg.WebhookInterval, func(now time.Time) {
+		if _, err := referralService.DeliverWebhooks(now); err != nil {
+			logger.ErrorLogger.Println(fmt.Errorf("cannot deliver webhooks: %w", err))
+		}
+	})
+
+	go runPeriodically(ctx, cfg.ScanInterval, func(now time.Time) {
+		n, err := referralService.ScanCVs(now)
+		if err != nil {
+			logger.ErrorLogger.Println(fmt.Errorf("cannot scan CVs: %w", err))
+		}
+		if n != 0 {
+			logger.InfoLogger.Printf("scanned %d CVs", n)
+		}
+
+		n, err = referralService.ScanFiles(now)
+		if err != nil {
+			logger.ErrorLogger.Println(fmt.Errorf("cannot scan attached files: %w", err))
+		}
+		if n != 0 {
+			logger.InfoLogger.Printf("scanned %d attached files", n)
+		}
+	})
+
+	go runPeriodically(ctx, cfg.ExtractInterval, func(time.Time) {
+		n, err := referralService.ExtractCVs()
+		if err != nil {
+			logger.ErrorLogger.Println(fmt.Errorf("cannot extract text of CVs: %w", err))
+		}
+		if n != 0 {
+			logger.InfoLogger.Printf("extracted text of %d CVs", n)
+		}
+	})
+
+	go runPeriodically(ctx, cfg.ReconcileInterval, func(now time.Time) {
+		report, err := referralService.ReconcileFiles(now, cfg.ReconcileDelete)
+		if err != nil {
+			logger.ErrorLogger.Println(fmt.Errorf("cannot reconcile files: %w", err))
+		}
+		for _, key := range report.OrphanedObjects {
+			logger.InfoLogger.Printf("file %s isn't referenced by any request", key)
+		}
+		for _, f := range report.MissingFiles {
+			logger.ErrorLogger.Printf("file %s of request %s is missing in object storage", f.Key, f.RequestID)
+		}
+		if report.ExpiredUploads != 0 || report.DeletedObjects != 0 {
+			logger.InfoLogger.Printf("deleted files of %d expired uploads and %d orphaned files", report.ExpiredUploads, report.DeletedObjects)
+		}
+	})
+
 	if err := server.Run(cfg.Port, server); err != nil {
 		fmt.Println(fmt.Errorf("error while starting server: %s", err))
 		return logger, fmt.Errorf("error while starting server: %s", err)
@@ -69,5 +191,29 @@ func loadConfig() (*appConfig, error) {
 		return nil, fmt.Errorf("unable to read application config: %w", err)
 	}
 
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
 	return &c, nil
 }
+
+// validate checks that intervals of periodic jobs aren't negative, zero interval disables the job.
+func (c *appConfig) validate() error {
+	intervals := map[string]time.Duration{
+		"APP_SLA_CHECK_INTERVAL": c.SLACheckInterval,
+		"APP_WEBHOOK_INTERVAL":   c.WebhookInterval,
+		"APP_OUTBOX_INTERVAL":    c.OutboxInterval,
+		"APP_SCAN_INTERVAL":      c.ScanInterval,
+		"APP_EXTRACT_INTERVAL":   c.ExtractInterval,
+		"APP_RECONCILE_INTERVAL": c.ReconcileInterval,
+	}
+
+	for name, interval := range intervals {
+		if interval < 0 {
+			return fmt.Errorf("%s must not be negative, got %s", name, interval)
+		}
+	}
+
+	return nil
+}
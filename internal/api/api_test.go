@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppConfig_Validate(t *testing.T) {
+	assert.NoError(t, (&appConfig{SLACheckInterval: time.Hour}).validate())
+	assert.Error(t, (&appConfig{ScanInterval: -time.Second}).validate())
+}
+
+func TestRunPeriodically_ZeroInterval(t *testing.T) {
+	done := make(chan struct{})
+
+	go func() {
+		runPeriodically(context.Background(), 0, func(time.Time) {})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job with zero interval is running")
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+	"github.com/cyberdr0id/referral/internal/storage"
+)
+
+// ImportCommand presents a name of command which imports historical requests.
+const ImportCommand = "import"
+
+var errImportUsage = errors.New("usage: import [-dry-run] [-cv-dir dir] file.csv")
+
+// Import imports historical requests from CSV file and prints import report to stdout.
+func Import(args []string) error {
+	flags := flag.NewFlagSet(ImportCommand, flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "validate file and print report without importing requests")
+	cvDir := flags.String("cv-dir", "", "directory which relative cv_path values are resolved against (default is directory of the file)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errImportUsage
+	}
+
+	filename := flags.Arg(0)
+	if *cvDir == "" {
+		*cvDir = filepath.Dir(filename)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("cannot open import file: %w", err)
+	}
+	defer file.Close()
+
+	db, err := repository.NewConnection()
+	if err != nil {
+		return fmt.Errorf("error while trying to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	files, err := storage.NewFileStore()
+	if err != nil {
+		return fmt.Errorf("cannot create new instance of object storage: %w", err)
+	}
+
+	referralService := service.NewReferralService(repository.NewRepository(db), files)
+
+	referralService.Keys, err = storage.NewKeyLayout()
+	if err != nil {
+		return fmt.Errorf("cannot load storage key layout: %w", err)
+	}
+
+	report, err := referralService.ImportRequests(file, openLocalCV(*cvDir), *dryRun)
+	if err != nil {
+		return fmt.Errorf("cannot import requests: %w", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+// openLocalCV returns CVOpener which opens CV files from local file system.
+func openLocalCV(dir string) service.CVOpener {
+	return func(cvPath string) (io.ReadSeekCloser, error) {
+		if !filepath.IsAbs(cvPath) {
+			cvPath = filepath.Join(dir, cvPath)
+		}
+
+		return os.Open(cvPath)
+	}
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+	"github.com/cyberdr0id/referral/internal/storage"
+)
+
+// ReconcileCommand presents a name of command which reconciles object storage with database.
+const ReconcileCommand = "reconcile-files"
+
+var errReconcileUsage = errors.New("usage: reconcile-files [-delete]")
+
+// Reconcile reconciles object storage with database and prints report to stdout. Orphaned files
+// are only reported unless -delete is set, deletion requires storage key layout to have a prefix.
+func Reconcile(args []string) error {
+	flags := flag.NewFlagSet(ReconcileCommand, flag.ContinueOnError)
+	deleteOrphans := flags.Bool("delete", false, "delete files which aren't referenced by any request")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errReconcileUsage
+	}
+
+	db, err := repository.NewConnection()
+	if err != nil {
+		return fmt.Errorf("error while trying to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	files, err := storage.NewFileStore()
+	if err != nil {
+		return fmt.Errorf("cannot create new instance of object storage: %w", err)
+	}
+
+	referralService := service.NewReferralService(repository.NewRepository(db), files)
+
+	referralService.Keys, err = storage.NewKeyLayout()
+	if err != nil {
+		return fmt.Errorf("cannot load storage key layout: %w", err)
+	}
+
+	report, err := referralService.ReconcileFiles(time.Now(), *deleteOrphans)
+	if err != nil {
+		return fmt.Errorf("cannot reconcile files: %w", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
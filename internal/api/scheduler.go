@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"time"
+)
+
+// runPeriodically calls fn every interval until ctx is done, the first call is made after the first interval.
+// Zero interval disables the job.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func(now time.Time)) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			fn(now)
+		}
+	}
+}
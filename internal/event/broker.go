@@ -0,0 +1,74 @@
+package event
+
+import (
+	"sync"
+)
+
+const subscriptionBuffer = 64
+
+// Broker publishes events to subscribers within the process. It's in-process only: with several instances
+// of the application every event is published by the instance which relayed it from the outbox, so
+// subscribers connected to other instances get it only by replay after reconnection.
+type Broker struct {
+	mu            sync.Mutex
+	subscriptions map[*Subscription]struct{}
+}
+
+// Subscription presents a stream of events which match filter of the subscriber.
+type Subscription struct {
+	C <-chan Event
+
+	c      chan Event
+	filter func(Event) bool
+	broker *Broker
+}
+
+// NewBroker creates a new instance of Broker.
+func NewBroker() *Broker {
+	return &Broker{subscriptions: map[*Subscription]struct{}{}}
+}
+
+// Subscribe creates subscription to events which match the filter, nil filter matches all events.
+func (b *Broker) Subscribe(filter func(Event) bool) *Subscription {
+	c := make(chan Event, subscriptionBuffer)
+	s := &Subscription{C: c, c: c, filter: filter, broker: b}
+
+	b.mu.Lock()
+	b.subscriptions[s] = struct{}{}
+	b.mu.Unlock()
+
+	return s
+}
+
+// Publish sends event to all subscribers without waiting for them. Subscribers which don't keep up
+// are closed, so they don't miss events silently and can resume from the last received one.
+func (b *Broker) Publish(e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscriptions {
+		if s.filter != nil && !s.filter(e) {
+			continue
+		}
+
+		select {
+		case s.c <- e:
+		default:
+			delete(b.subscriptions, s)
+			close(s.c)
+		}
+	}
+
+	return nil
+}
+
+// Close cancels subscription, C is closed.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if _, ok := s.broker.subscriptions[s]; ok {
+		delete(s.broker.subscriptions, s)
+		close(s.c)
+	}
+}
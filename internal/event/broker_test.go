@@ -0,0 +1,44 @@
+package event
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker_Publish(t *testing.T) {
+	broker := NewBroker()
+
+	all := broker.Subscribe(nil)
+	created := broker.Subscribe(func(e Event) bool { return e.Name == ReferralCreated })
+	closed := broker.Subscribe(nil)
+	closed.Close()
+
+	_ = broker.Publish(Event{ID: "1", Name: ReferralCreated})
+	_ = broker.Publish(Event{ID: "2", Name: ReferralStatusChanged})
+
+	assert.Equal(t, Event{ID: "1", Name: ReferralCreated}, <-all.C)
+	assert.Equal(t, Event{ID: "2", Name: ReferralStatusChanged}, <-all.C)
+	assert.Equal(t, Event{ID: "1", Name: ReferralCreated}, <-created.C)
+	assert.Empty(t, created.C)
+
+	_, ok := <-closed.C
+	assert.False(t, ok)
+}
+
+func TestBroker_Publish_SlowSubscriber(t *testing.T) {
+	broker := NewBroker()
+	slow := broker.Subscribe(nil)
+
+	for i := 0; i <= subscriptionBuffer; i++ {
+		_ = broker.Publish(Event{Name: ReferralCreated})
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+
+	assert.Equal(t, subscriptionBuffer, received)
+	slow.Close()
+}
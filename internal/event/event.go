@@ -0,0 +1,202 @@
+// Package event describes domain events which are recorded in the transactional outbox
+// and publishes them to consumers.
+package event
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+const (
+	// ReferralCreated presents submission of a new referral.
+	ReferralCreated = "referral.created"
+
+	// ReferralStatusChanged presents change of referral status.
+	ReferralStatusChanged = "referral.status_changed"
+
+	// ReferralAssigned presents assignment of referral to a recruiter.
+	ReferralAssigned = "referral.assigned"
+
+	// BonusStatusChanged presents change of referral bonus status.
+	BonusStatusChanged = "bonus.status_changed"
+)
+
+const (
+	// DriverNone presents absence of external publisher, events are consumed only by the service itself.
+	DriverNone = "none"
+
+	// DriverLog presents publisher which writes events to log.
+	DriverLog = "log"
+
+	// DriverHTTP presents publisher which posts events to an HTTP endpoint.
+	DriverHTTP = "http"
+
+	// HeaderID presents header with id of the event, consumers use it to skip duplicates.
+	HeaderID = "X-Event-ID"
+
+	firstRetryDelay = 30 * time.Second
+	maxRetryDelay   = 6 * time.Hour
+	maxResponseSize = 1 << 10
+)
+
+type publisherConfig struct {
+	Driver  string        `envconfig:"EVENTS_PUBLISHER" default:"none"`
+	URL     string        `envconfig:"EVENTS_HTTP_URL"`
+	Timeout time.Duration `envconfig:"EVENTS_HTTP_TIMEOUT" default:"10s"`
+}
+
+// Event presents a domain event, payload is a JSON snapshot of the changed entity.
+type Event struct {
+	ID      string          `json:"id"`
+	Name    string          `json:"event"`
+	Payload json.RawMessage `json:"data"`
+	Created time.Time       `json:"occurredAt"`
+}
+
+// Publisher publishes events. Events are delivered at least once, so publishers must tolerate duplicates.
+type Publisher interface {
+	Publish(e Event) error
+}
+
+// PublisherFunc is an adapter to use ordinary functions as publishers.
+type PublisherFunc func(e Event) error
+
+// Publish calls f(e).
+func (f PublisherFunc) Publish(e Event) error {
+	return f(e)
+}
+
+// Publishers publishes every event to all publishers, event fails if any of them fails.
+type Publishers []Publisher
+
+// Publish publishes event to all publishers even if some of them fail.
+func (p Publishers) Publish(e Event) error {
+	var firstErr error
+
+	for _, publisher := range p {
+		if err := publisher.Publish(e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// NewPublisher creates external publisher configured by EVENTS_* environment variables,
+// nil is returned with none driver.
+func NewPublisher(logger *log.Logger) (Publisher, error) {
+	var cfg publisherConfig
+
+	if err := envconfig.Process("events", &cfg); err != nil {
+		return nil, fmt.Errorf("error with config loading: %w", err)
+	}
+
+	switch cfg.Driver {
+	case DriverNone:
+		return nil, nil
+	case DriverLog:
+		return LogPublisher{Logger: logger}, nil
+	case DriverHTTP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("EVENTS_HTTP_URL is required by http publisher")
+		}
+
+		return &HTTPPublisher{URL: cfg.URL, Client: &http.Client{Timeout: cfg.Timeout}}, nil
+	default:
+		return nil, fmt.Errorf("unknown events publisher %q", cfg.Driver)
+	}
+}
+
+// RetryDelay returns delay before the next attempt after the given number of failed attempts,
+// it doubles with every attempt.
+func RetryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+
+	return delay
+}
+
+// LogPublisher writes events to log.
+type LogPublisher struct {
+	Logger *log.Logger
+}
+
+// Publish writes event to log.
+func (p LogPublisher) Publish(e Event) error {
+	p.Logger.Printf("event %s %s: %s", e.ID, e.Name, e.Payload)
+
+	return nil
+}
+
+// MemoryPublisher keeps published events in memory, it's used in tests.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Publish saves event.
+func (p *MemoryPublisher) Publish(e Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, e)
+
+	return nil
+}
+
+// Events returns all published events.
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Event(nil), p.events...)
+}
+
+// HTTPPublisher posts events as JSON to an endpoint, responses other than 2xx are errors.
+type HTTPPublisher struct {
+	URL    string
+	Client *http.Client
+}
+
+// Publish posts event to the endpoint.
+func (p *HTTPPublisher) Publish(e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("cannot encode event: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("cannot create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, e.ID)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cannot send event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		return fmt.Errorf("endpoint responded with %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPPublisher_Publish(t *testing.T) {
+	e := Event{
+		ID:      "7",
+		Name:    ReferralCreated,
+		Payload: json.RawMessage(`{"id":"1"}`),
+		Created: time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	testTable := []struct {
+		testName        string
+		responseCode    int
+		isErrorExpected bool
+	}{
+		{testName: "Success", responseCode: http.StatusAccepted},
+		{testName: "Failure: endpoint error", responseCode: http.StatusInternalServerError, isErrorExpected: true},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			endpoint := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, `{"id":"7","event":"referral.created","data":{"id":"1"},"occurredAt":"2022-06-10T00:00:00Z"}`, string(body))
+				assert.Equal(t, "7", r.Header.Get(HeaderID))
+
+				rw.WriteHeader(tc.responseCode)
+			}))
+			defer endpoint.Close()
+
+			err := (&HTTPPublisher{URL: endpoint.URL, Client: endpoint.Client()}).Publish(e)
+			if tc.isErrorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPublishers_Publish(t *testing.T) {
+	first, last := &MemoryPublisher{}, &MemoryPublisher{}
+	failing := PublisherFunc(func(e Event) error { return errors.New("unavailable") })
+
+	err := Publishers{first, failing, last}.Publish(Event{ID: "1"})
+	assert.Error(t, err)
+	assert.Equal(t, []Event{{ID: "1"}}, first.Events())
+	assert.Equal(t, []Event{{ID: "1"}}, last.Events())
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, RetryDelay(1))
+	assert.Equal(t, time.Minute, RetryDelay(2))
+	assert.Equal(t, 4*time.Minute, RetryDelay(4))
+	assert.Equal(t, 6*time.Hour, RetryDelay(20))
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+// AssignRequest type presents data for request assignment, empty assignee makes request unassigned.
+type AssignRequest struct {
+	ID         string `json:"id"`
+	AssigneeID string `json:"assigneeId"`
+}
+
+// RecruiterPositionsRequest type presents positions a recruiter reviews.
+type RecruiterPositionsRequest struct {
+	UserID    string   `json:"userId"`
+	Positions []string `json:"positions"`
+}
+
+// GetQueue admin handler that outputs requests assigned to current admin, open requests are shown by default.
+func (s *Server) GetQueue(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	filter, err := ValidateGetRequestsRequest(r.URL.Query(), anyUserID)
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	filter.AssigneeID = userID
+	filter.Unassigned = false
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{repository.StatusSubmitted}
+	}
+
+	s.sendRequestsPage(rw, r, filter)
+}
+
+// AssignRequest admin handler that assigns request to a recruiter or reassigns it.
+func (s *Server) AssignRequest(rw http.ResponseWriter, r *http.Request) {
+	var request AssignRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.ValidateAssignRequest(); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err := s.Referral.AssignRequest(request.ID, request.AssigneeID)
+	if errors.Is(err, service.ErrNoResult) || errors.Is(err, service.ErrNoUser) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrNoRecruiter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, UpdateResponse{Message: fmt.Sprintf("request with %s ID has been assigned", request.ID)}, http.StatusOK)
+}
+
+// GetRecruiters admin handler that outputs recruiters with their positions and workload.
+func (s *Server) GetRecruiters(rw http.ResponseWriter, r *http.Request) {
+	recruiters, err := s.Referral.GetRecruiters()
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, recruiters, http.StatusOK)
+}
+
+// SetRecruiterPositions admin handler that sets positions a recruiter gets requests for automatically.
+func (s *Server) SetRecruiterPositions(rw http.ResponseWriter, r *http.Request) {
+	var request RecruiterPositionsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := ValidateNumber(request.UserID); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err := s.Referral.SetRecruiterPositions(request.UserID, request.Positions)
+	if errors.Is(err, service.ErrNoUser) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrNoRecruiter) || errors.Is(err, service.ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, request, http.StatusOK)
+}
+
+// GetAssignmentRules admin handler that outputs assignment rules.
+func (s *Server) GetAssignmentRules(rw http.ResponseWriter, r *http.Request) {
+	rules, err := s.Referral.GetAssignmentRules()
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, rules, http.StatusOK)
+}
+
+// SaveAssignmentRule admin handler that sets assignment strategy of a position.
+func (s *Server) SaveAssignmentRule(rw http.ResponseWriter, r *http.Request) {
+	var request repository.AssignmentRule
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := service.ValidateAssignmentRule(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Referral.SaveAssignmentRule(request); err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, request, http.StatusOK)
+}
+
+// ValidateAssignRequest validates data before request assignment.
+func (r *AssignRequest) ValidateAssignRequest() error {
+	if err := ValidateNumber(r.ID); err != nil {
+		return err
+	}
+
+	if r.AssigneeID != "" {
+		if err := ValidateNumber(r.AssigneeID); err != nil {
+			return fmt.Errorf("%w: assignee id has bad format", ErrInvalidParameter)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+var (
+	bonusStatuses = map[string]bool{
+		repository.BonusPending:   true,
+		repository.BonusApproved:  true,
+		repository.BonusPaid:      true,
+		repository.BonusCancelled: true,
+	}
+
+	bonusIDExp = regexp.MustCompile(`^[1-9]\d*$`)
+
+	bonusExportHeader = []string{
+		"id", "request_id", "candidate_name", "candidate_surname", "position", "user_id", "user_name",
+		"amount", "currency", "status", "eligible_at", "approved_at", "paid_at", "created",
+	}
+)
+
+// UpdateBonusRequest type presents data for bonus status update.
+type UpdateBonusRequest struct {
+	ID        string `json:"id"`
+	NewStatus string `json:"status"`
+}
+
+// GetBonusRules admin handler that outputs all bonus rules.
+func (s *Server) GetBonusRules(rw http.ResponseWriter, r *http.Request) {
+	rules, err := s.Referral.GetBonusRules()
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, rules, http.StatusOK)
+}
+
+// SaveBonusRule admin handler that creates or replaces bonus rule of a position.
+func (s *Server) SaveBonusRule(rw http.ResponseWriter, r *http.Request) {
+	var request repository.BonusRule
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := service.ValidateBonusRule(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Referral.SaveBonusRule(request); err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, request, http.StatusOK)
+}
+
+// DeleteBonusRule admin handler that deletes bonus rule of a position.
+func (s *Server) DeleteBonusRule(rw http.ResponseWriter, r *http.Request) {
+	err := s.Referral.DeleteBonusRule(strings.TrimSpace(r.URL.Query().Get(positionParameter)))
+	if errors.Is(err, service.ErrNoBonusRule) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// GetBonuses admin handler that outputs payout ledger.
+func (s *Server) GetBonuses(rw http.ResponseWriter, r *http.Request) {
+	filter, err := ValidateBonusFilter(r.URL.Query())
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	bonuses, err := s.Referral.GetBonuses(filter)
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, bonuses, http.StatusOK)
+}
+
+// UpdateBonus admin handler that approves, marks as paid or cancels bonus.
+func (s *Server) UpdateBonus(rw http.ResponseWriter, r *http.Request) {
+	var request UpdateBonusRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := request.ValidateUpdateBonusRequest(); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err := s.Referral.UpdateBonus(request.ID, strings.ToLower(request.NewStatus), time.Now())
+	if errors.Is(err, service.ErrNoResult) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidBonusTransition) || errors.Is(err, service.ErrBonusNotEligible) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, UpdateResponse{Message: fmt.Sprintf("bonus status with %s ID has been updated", request.ID)}, http.StatusOK)
+}
+
+// ExportBonuses admin handler that streams payout ledger as CSV or XLSX table.
+func (s *Server) ExportBonuses(rw http.ResponseWriter, r *http.Request) {
+	filter, err := ValidateBonusFilter(r.URL.Query())
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	w, err := newExportWriter(rw, r, "bonuses")
+	if errors.Is(err, ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		return
+	}
+
+	s.writeExport(w, bonusExportHeader, func(write func(record []string) error) error {
+		return s.Referral.ExportBonuses(filter, func(bonus repository.Bonus) error {
+			return write([]string{
+				bonus.ID,
+				bonus.RequestID,
+				bonus.CandidateName,
+				bonus.CandidateSurname,
+				bonus.Position,
+				bonus.UserID,
+				bonus.UserName,
+				bonus.Amount,
+				bonus.Currency,
+				bonus.Status,
+				formatTime(&bonus.EligibleAt),
+				formatTime(bonus.ApprovedAt),
+				formatTime(bonus.PaidAt),
+				formatTime(&bonus.Created),
+			})
+		})
+	})
+}
+
+// ValidateBonusFilter validates user_id and status query parameters of payout ledger.
+func ValidateBonusFilter(query url.Values) (repository.BonusFilter, error) {
+	filter := repository.BonusFilter{
+		UserID: query.Get(userIDParameter),
+	}
+
+	if filter.UserID != "" && !bonusIDExp.MatchString(filter.UserID) {
+		return repository.BonusFilter{}, fmt.Errorf("%w: user id has bad format", ErrInvalidParameter)
+	}
+
+	for _, status := range queryValues(query, statusParameter) {
+		status = strings.ToLower(status)
+		if !bonusStatuses[status] {
+			return repository.BonusFilter{}, fmt.Errorf("%w: bonus status", ErrInvalidParameter)
+		}
+
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	return filter, nil
+}
+
+// ValidateUpdateBonusRequest validates data before bonus update.
+func (r *UpdateBonusRequest) ValidateUpdateBonusRequest() error {
+	if !bonusIDExp.MatchString(r.ID) {
+		return fmt.Errorf("%w: id has bad format", ErrInvalidParameter)
+	}
+
+	status := strings.ToLower(r.NewStatus)
+	if !bonusStatuses[status] || status == repository.BonusPending {
+		return fmt.Errorf("%w: bonus status", ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// formatTime formats optional time in RFC 3339, nil is formatted as empty string.
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+const maxEmployeeNameLength = 64
+
+// UpdateEmployeeRequest type presents employment data of user.
+type UpdateEmployeeRequest struct {
+	UserID    string `json:"userId"`
+	FirstName string `json:"firstName"`
+	LastName  string `json:"lastName"`
+	Hired     string `json:"hired"`
+}
+
+// GetEligibilityRules admin handler that outputs settings of eligibility rules.
+func (s *Server) GetEligibilityRules(rw http.ResponseWriter, r *http.Request) {
+	rules, err := s.Referral.GetEligibilityRules()
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, rules, http.StatusOK)
+}
+
+// SaveEligibilityRule admin handler that enables, disables or reconfigures eligibility rule.
+func (s *Server) SaveEligibilityRule(rw http.ResponseWriter, r *http.Request) {
+	var request repository.EligibilityRule
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := service.ValidateEligibilityRule(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Referral.SaveEligibilityRule(request); err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, request, http.StatusOK)
+}
+
+// UpdateEmployee admin handler that sets employment data of user.
+func (s *Server) UpdateEmployee(rw http.ResponseWriter, r *http.Request) {
+	var request UpdateEmployeeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	employee, err := request.ValidateUpdateEmployeeRequest()
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = s.Referral.UpdateEmployee(employee)
+	if errors.Is(err, service.ErrNoUser) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, employee, http.StatusOK)
+}
+
+// ValidateUpdateEmployeeRequest validates employment data, empty hire date clears it.
+func (r *UpdateEmployeeRequest) ValidateUpdateEmployeeRequest() (repository.Employee, error) {
+	if err := ValidateNumber(r.UserID); err != nil {
+		return repository.Employee{}, err
+	}
+
+	employee := repository.Employee{
+		UserID:    r.UserID,
+		FirstName: strings.TrimSpace(r.FirstName),
+		LastName:  strings.TrimSpace(r.LastName),
+	}
+
+	if len(employee.FirstName) > maxEmployeeNameLength || len(employee.LastName) > maxEmployeeNameLength {
+		return repository.Employee{}, fmt.Errorf("%w: name must be at most %d symbols", ErrInvalidParameter, maxEmployeeNameLength)
+	}
+
+	if r.Hired != "" {
+		hired, err := time.Parse(dateLayout, r.Hired)
+		if err != nil {
+			return repository.Employee{}, fmt.Errorf("%w: hired has bad format", ErrInvalidParameter)
+		}
+
+		employee.Hired = &hired
+	}
+
+	return employee, nil
+}
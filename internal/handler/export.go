@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/pkg/xlsx"
+)
+
+const (
+	formatParameter = "format"
+	acceptHeaderKey = "Accept"
+
+	csvFormat      = "csv"
+	xlsxFormat     = "xlsx"
+	csvContentType = "text/csv"
+
+	// exportFlushRows presents number of rows after which written data is sent to client.
+	exportFlushRows = 100
+)
+
+var exportHeader = []string{
+	"id", "candidate_name", "candidate_surname", "position", "status",
+	"author_id", "author_name", "created", "updated", "decided",
+}
+
+// exportWriter presents a writer of exported table.
+type exportWriter interface {
+	Write(record []string) error
+	Flush() error
+	Close() error
+}
+
+// csvWriter adapts csv.Writer to exportWriter.
+type csvWriter struct {
+	*csv.Writer
+}
+
+// Write writes record escaping values which can be interpreted by spreadsheet applications as formulas.
+func (w csvWriter) Write(record []string) error {
+	escaped := make([]string, len(record))
+	for i, value := range record {
+		if value != "" && strings.ContainsAny(value[:1], "=+-@") {
+			value = "'" + value
+		}
+
+		escaped[i] = value
+	}
+
+	return w.Writer.Write(escaped)
+}
+
+func (w csvWriter) Flush() error {
+	w.Writer.Flush()
+	return w.Writer.Error()
+}
+
+func (w csvWriter) Close() error {
+	return w.Flush()
+}
+
+// ExportRequests admin handler that streams all requests which match the filter as CSV or XLSX table.
+func (s *Server) ExportRequests(rw http.ResponseWriter, r *http.Request) {
+	filter, err := ValidateGetRequestsRequest(r.URL.Query(), r.URL.Query().Get(userIDParameter))
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	w, err := newExportWriter(rw, r, "references")
+	if errors.Is(err, ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		return
+	}
+
+	s.writeExport(w, exportHeader, func(write func(record []string) error) error {
+		return s.Referral.ExportRequests(filter, func(request repository.UserRequests) error {
+			return write([]string{
+				request.ID,
+				request.Name,
+				request.Surname,
+				request.Position,
+				request.Status,
+				request.Author.ID,
+				request.Author.Name,
+				request.Created,
+				request.Updated,
+				request.Decided,
+			})
+		})
+	})
+}
+
+// newExportWriter negotiates format of exported table, sets headers of the attachment named by the name
+// and the current date and returns writer of the table to the response. Written rows are sent to client
+// periodically, write deadline is extended per write, so large exports aren't cut off.
+func newExportWriter(rw http.ResponseWriter, r *http.Request, name string) (exportWriter, error) {
+	format, err := exportFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := csvContentType
+	if format == xlsxFormat {
+		contentType = xlsx.ContentType
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format(dateLayout), format)
+	rw.Header().Set("Content-Type", contentType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	out := deadlineWriter{ResponseWriter: rw, r: r}
+
+	if format == xlsxFormat {
+		w, err := xlsx.NewWriter(out)
+		if err != nil {
+			return nil, err
+		}
+
+		return &flushingWriter{exportWriter: w}, nil
+	}
+
+	return &flushingWriter{exportWriter: csvWriter{csv.NewWriter(out)}}, nil
+}
+
+// writeExport writes the header and rows passed by export to the table and closes it. Response is
+// already partially sent, so errors can only be logged.
+func (s *Server) writeExport(w exportWriter, header []string, export func(write func(record []string) error) error) {
+	err := w.Write(header)
+	if err == nil {
+		err = export(w.Write)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+	}
+}
+
+// flushingWriter sends written rows to client every exportFlushRows rows.
+type flushingWriter struct {
+	exportWriter
+	rows int
+}
+
+func (w *flushingWriter) Write(record []string) error {
+	if err := w.exportWriter.Write(record); err != nil {
+		return fmt.Errorf("cannot write row: %w", err)
+	}
+
+	if w.rows++; w.rows%exportFlushRows == 0 {
+		return w.Flush()
+	}
+
+	return nil
+}
+
+// exportFormat gets export format either from query parameter or from Accept header, CSV is used by default.
+func exportFormat(r *http.Request) (string, error) {
+	if format := strings.ToLower(r.URL.Query().Get(formatParameter)); format != "" {
+		if format != csvFormat && format != xlsxFormat {
+			return "", fmt.Errorf("%w: format", ErrInvalidParameter)
+		}
+
+		return format, nil
+	}
+
+	if strings.Contains(r.Header.Get(acceptHeaderKey), xlsx.ContentType) {
+		return xlsxFormat, nil
+	}
+
+	return csvFormat, nil
+}
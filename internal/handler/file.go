@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+const (
+	fileIDParameter = "file_id"
+	kindParameter   = "kind"
+)
+
+// GetFiles outputs files attached to request of the user including previous CV versions.
+func (s *Server) GetFiles(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	s.getFiles(rw, r, userID)
+}
+
+// GetAnyFiles admin handler that outputs files attached to any request.
+func (s *Server) GetAnyFiles(rw http.ResponseWriter, r *http.Request) {
+	s.getFiles(rw, r, anyUserID)
+}
+
+func (s *Server) getFiles(rw http.ResponseWriter, r *http.Request, authorID string) {
+	id := r.URL.Query().Get(idParameter)
+	if err := ValidateNumber(id); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	files, err := s.Referral.GetFiles(id, authorID)
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, files, http.StatusOK)
+}
+
+// AttachFile attaches file to request of the user. Kind of the file is passed in kind form field,
+// a new CV version becomes the current CV of request.
+func (s *Server) AttachFile(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	s.attachFile(rw, r, userID, userID)
+}
+
+// AttachAnyFile admin handler that attaches file to any request.
+func (s *Server) AttachAnyFile(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	s.attachFile(rw, r, anyUserID, userID)
+}
+
+func (s *Server) attachFile(rw http.ResponseWriter, r *http.Request, authorID, userID string) {
+	id := r.URL.Query().Get(idParameter)
+	if err := ValidateNumber(id); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	file, fileHeader, ok := s.formFile(rw, r)
+	if !ok {
+		return
+	}
+	defer file.Close()
+
+	kind := r.FormValue(kindParameter)
+	if !repository.IsFileKind(kind) {
+		sendResponse(rw, ErrorResponse{Message: fmt.Errorf("%w: file kind", ErrInvalidParameter).Error()}, http.StatusBadRequest)
+		return
+	}
+
+	attached, err := s.Referral.AttachFile(service.AttachFileRequest{
+		RequestID: id,
+		Kind:      kind,
+		Filename:  fileHeader.Filename,
+		File:      file,
+		AuthorID:  authorID,
+		UserID:    userID,
+	})
+	if errors.Is(err, service.ErrNoResult) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrUnsupportedCV) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusUnsupportedMediaType)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, attached, http.StatusCreated)
+}
+
+// DeleteFile deletes file attached to request of the user, the current CV can't be deleted.
+func (s *Server) DeleteFile(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	s.deleteFile(rw, r, userID)
+}
+
+// DeleteAnyFile admin handler that deletes file attached to any request.
+func (s *Server) DeleteAnyFile(rw http.ResponseWriter, r *http.Request) {
+	s.deleteFile(rw, r, anyUserID)
+}
+
+func (s *Server) deleteFile(rw http.ResponseWriter, r *http.Request, authorID string) {
+	id := r.URL.Query().Get(fileIDParameter)
+	if err := ValidateNumber(id); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err := s.Referral.DeleteFile(id, authorID)
+	if errors.Is(err, service.ErrNoFile) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrCurrentCV) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// DownloadFile downloads file attached to request of the user.
+func (s *Server) DownloadFile(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	s.downloadFile(rw, r, userID, userID)
+}
+
+// DownloadAnyFile admin handler that downloads file attached to any request.
+func (s *Server) DownloadAnyFile(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	s.downloadFile(rw, r, anyUserID, userID)
+}
+
+// downloadFile responds with a link to the file, or streams the file itself if StreamCVs is set.
+// Download is recorded to the audit trail the same way as downloads of CVs.
+func (s *Server) downloadFile(rw http.ResponseWriter, r *http.Request, authorID, userID string) {
+	id := r.URL.Query().Get(fileIDParameter)
+	if err := ValidateNumber(id); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	f, err := s.Referral.GetFile(id, authorID)
+	if errors.Is(err, service.ErrNoFile) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	download := repository.CVDownload{
+		RequestID:  f.RequestID,
+		FileID:     f.ID,
+		UserID:     userID,
+		Mode:       repository.DownloadLink,
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	if !s.StreamCVs {
+		url, err := s.Referral.FileLink(f)
+		if errors.Is(err, service.ErrUnscannedFile) {
+			sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorLogger.Println(err)
+			sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+			return
+		}
+
+		if err := s.Referral.RecordCVDownload(download); err != nil {
+			s.Logger.ErrorLogger.Println(err)
+			sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+			return
+		}
+
+		sendResponse(rw, DownloadResponse{Link: url}, http.StatusOK)
+		return
+	}
+
+	file, err := s.Referral.OpenFile(f)
+	if errors.Is(err, service.ErrNoFile) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrUnscannedFile) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	download.Mode = repository.DownloadStream
+	download.Range = r.Header.Get("Range")
+
+	if err := s.Referral.RecordCVDownload(download); err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	serveFile(rw, r, file)
+}
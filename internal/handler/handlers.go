@@ -4,37 +4,64 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/repository"
 	"github.com/cyberdr0id/referral/internal/service"
 )
 
 const (
-	filenameParam         = "fileName"
-	candidateNameParam    = "candidateName"
-	candidateSurnameParam = "candidateSurname"
-	statusParameter       = "status"
-	idParameter           = "id"
-	pageNumberParameter   = "page"
-	pageSizeParameter     = "size"
-	userIDParameter       = "user_id"
+	filenameParam            = "fileName"
+	candidateNameParam       = "candidateName"
+	candidateSurnameParam    = "candidateSurname"
+	candidatePositionParam   = "candidatePosition"
+	candidateDepartmentParam = "candidateDepartment"
+	statusParameter          = "status"
+	positionParameter        = "position"
+	sortParameter            = "sort"
+	orderParameter           = "order"
+	createdFromParameter     = "created_from"
+	createdToParameter       = "created_to"
+	updatedFromParameter     = "updated_from"
+	updatedToParameter       = "updated_to"
+	idParameter              = "id"
+	pageNumberParameter      = "page"
+	pageSizeParameter        = "size"
+	userIDParameter          = "user_id"
+	assigneeParameter        = "assignee"
+	keywordParameter         = "keyword"
+	unassignedValue          = "none"
 
 	anyUserID         = ""
 	defaultPageNumber = 1
 	defaultPageSize   = 10
+	maxBulkUpdateSize = service.MaxBulkUpdateSize
+	maxKeywords       = 10
+	maxKeywordLength  = 64
+
+	dateLayout = "2006-01-02"
 )
 
 // ErrorResponse presents a custom error type for error response.
 type ErrorResponse struct {
 	Message string `json:"error"`
+	Code    string `json:"code,omitempty"`
 }
 
 // ErrInvalidParameter presents an error when user enters invalid parameter.
-var ErrInvalidParameter = errors.New("invalid parameter")
+var ErrInvalidParameter = service.ErrInvalidParameter
+
+// assigneeExp presents format of assignee id.
+var assigneeExp = regexp.MustCompile("^([1-9])\\d*$")
 
 // sendResponse sends response with specified object in body.
 func sendResponse(w http.ResponseWriter, resp interface{}, code int) {
@@ -135,25 +162,34 @@ type CandidateSendingResponse struct {
 	CandidateID string `json:"id"`
 }
 
-// SendCandidate sends candidate info and his cv.
+// SendCandidate sends candidate info and his cv. CV must be a document of allowed type which
+// isn't larger than MaxCVSize, type is detected by content of the file rather than its name.
 func (s *Server) SendCandidate(rw http.ResponseWriter, r *http.Request) {
-	file, fileHeader, err := r.FormFile(filenameParam)
-	if err != nil {
-		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+	file, fileHeader, ok := s.formFile(rw, r)
+	if !ok {
 		return
 	}
 	defer file.Close()
 
-	f, err := fileHeader.Open()
+	cvType, err := service.DetectCVType(file, fileHeader.Filename)
+	if errors.Is(err, service.ErrUnsupportedCV) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusUnsupportedMediaType)
+		return
+	}
 	if err != nil {
 		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
 	}
-	filetype := strings.Split(fileHeader.Filename, ".")
+
 	request := service.SubmitCandidateRequest{
-		File:             f,
+		File:             file,
 		CandidateName:    r.FormValue(candidateNameParam),
 		CandidateSurname: r.FormValue(candidateSurnameParam),
-		Filetype:         filetype[len(filetype)-1],
+		Position:         strings.TrimSpace(r.FormValue(candidatePositionParam)),
+		Department:       strings.TrimSpace(r.FormValue(candidateDepartmentParam)),
+		Filetype:         cvType.Extension,
+		ContentType:      cvType.MIME,
+		Filename:         fileHeader.Filename,
 	}
 
 	if err := ValidateCandidateSendingRequest(request); err != nil {
@@ -162,6 +198,11 @@ func (s *Server) SendCandidate(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	id, err := s.Referral.AddCandidate(r.Context(), request)
+	var eligibilityErr *service.EligibilityError
+	if errors.As(err, &eligibilityErr) {
+		sendResponse(rw, ErrorResponse{Message: err.Error(), Code: eligibilityErr.Code}, http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
 		s.Logger.ErrorLogger.Println(err)
 		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
@@ -171,12 +212,44 @@ func (s *Server) SendCandidate(rw http.ResponseWriter, r *http.Request) {
 	sendResponse(rw, CandidateSendingResponse{CandidateID: id}, http.StatusOK)
 }
 
+// formFile reads file of multipart form which mustn't be larger than MaxCVSize. Error response
+// is sent if the file can't be read.
+func (s *Server) formFile(rw http.ResponseWriter, r *http.Request) (multipart.File, *multipart.FileHeader, bool) {
+	errTooLarge := fmt.Errorf("%w: file must be at most %d bytes", ErrInvalidParameter, s.MaxCVSize)
+
+	if r.ContentLength > s.MaxCVSize+maxFormOverhead {
+		sendResponse(rw, ErrorResponse{Message: errTooLarge.Error()}, http.StatusRequestEntityTooLarge)
+		return nil, nil, false
+	}
+	r.Body = http.MaxBytesReader(rw, r.Body, s.MaxCVSize+maxFormOverhead)
+
+	file, fileHeader, err := r.FormFile(filenameParam)
+	if isBodyTooLarge(err) {
+		sendResponse(rw, ErrorResponse{Message: errTooLarge.Error()}, http.StatusRequestEntityTooLarge)
+		return nil, nil, false
+	}
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	if fileHeader.Size > s.MaxCVSize {
+		file.Close()
+		sendResponse(rw, ErrorResponse{Message: errTooLarge.Error()}, http.StatusRequestEntityTooLarge)
+		return nil, nil, false
+	}
+
+	return file, fileHeader, true
+}
+
+// isBodyTooLarge checks if reading of request body failed because of http.MaxBytesReader limit,
+// the error has no exported type in Go 1.18.
+func isBodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "request body too large")
+}
+
 // GetRequests outputs all user requests.
 func (s *Server) GetRequests(rw http.ResponseWriter, r *http.Request) {
-	status := strings.ToLower(r.URL.Query().Get(statusParameter))
-	pageNumber := r.URL.Query().Get(pageNumberParameter)
-	pageSize := r.URL.Query().Get(pageSizeParameter)
-
 	userID, ok := context.GetUserID(r.Context())
 	if !ok {
 		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
@@ -184,43 +257,26 @@ func (s *Server) GetRequests(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pageNumberInt, pageSizeInt, err := ValidateGetRequestsRequest(status, pageNumber, pageSize, userID)
+	filter, err := ValidateGetRequestsRequest(r.URL.Query(), userID)
 	if err != nil {
 		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
-	userRequests, err := s.Referral.GetRequests(userID, status, pageNumberInt, pageSizeInt)
-	if err != nil {
-		s.Logger.ErrorLogger.Println(err)
-		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
-		return
-	}
-
-	sendResponse(rw, userRequests, http.StatusOK)
+	s.sendRequestsPage(rw, r, filter)
 }
 
 // GetAllRequests admin handler that returns list of all requests.
 func (s *Server) GetAllRequests(rw http.ResponseWriter, r *http.Request) {
-	status := r.URL.Query().Get(statusParameter)
-	pageNumber := r.URL.Query().Get(pageNumberParameter)
-	pageSize := r.URL.Query().Get(pageSizeParameter)
 	userID := r.URL.Query().Get(userIDParameter)
 
-	pageNumberInt, pageSizeInt, err := ValidateGetRequestsRequest(status, pageNumber, pageSize, userID)
+	filter, err := ValidateGetRequestsRequest(r.URL.Query(), userID)
 	if err != nil {
 		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
-	userRequests, err := s.Referral.GetRequests(userID, status, pageNumberInt, pageSizeInt)
-	if err != nil {
-		s.Logger.ErrorLogger.Println(err)
-		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
-		return
-	}
-
-	sendResponse(rw, userRequests, http.StatusOK)
+	s.sendRequestsPage(rw, r, filter)
 }
 
 // DownloadResponse presents a type which contains link to file for download candidate cv.
@@ -244,30 +300,61 @@ func (s *Server) DownloadCV(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	url, err := s.Referral.DownloadFile(r.Context(), id, userID)
-	if errors.Is(err, service.ErrNoFile) {
+	s.downloadCV(rw, r, id, userID, userID)
+}
+
+// DownloadAnyCV provides access for admin to download any CV of candidates.
+func (s *Server) DownloadAnyCV(rw http.ResponseWriter, r *http.Request) {
+	fileID := r.URL.Query().Get(idParameter)
+
+	if err := ValidateNumber(fileID); err != nil {
 		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
-	if err != nil {
-		s.Logger.ErrorLogger.Println(err)
-		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
 		return
 	}
 
-	sendResponse(rw, DownloadResponse{Link: url}, http.StatusOK)
+	s.downloadCV(rw, r, fileID, anyUserID, userID)
 }
 
-// DownloadAnyCV provides access for admin to download any CV of candidates.
-func (s *Server) DownloadAnyCV(rw http.ResponseWriter, r *http.Request) {
-	fileID := r.URL.Query().Get(idParameter)
+// downloadCV responds with a link to CV of the candidate, or streams CV itself if StreamCVs is set.
+// Download by the user is recorded to the audit trail before CV is sent, CV isn't sent if it fails.
+func (s *Server) downloadCV(rw http.ResponseWriter, r *http.Request, id, authorID, userID string) {
+	download := repository.CVDownload{
+		RequestID:  id,
+		UserID:     userID,
+		Mode:       repository.DownloadLink,
+		RemoteAddr: r.RemoteAddr,
+	}
+
+	if !s.StreamCVs {
+		url, err := s.Referral.DownloadFile(r.Context(), id, authorID)
+		if errors.Is(err, service.ErrNoFile) {
+			sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			s.Logger.ErrorLogger.Println(err)
+			sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+			return
+		}
 
-	if err := ValidateNumber(fileID); err != nil {
-		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		if err := s.Referral.RecordCVDownload(download); err != nil {
+			s.Logger.ErrorLogger.Println(err)
+			sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+			return
+		}
+
+		sendResponse(rw, DownloadResponse{Link: url}, http.StatusOK)
 		return
 	}
 
-	url, err := s.Referral.DownloadFile(r.Context(), fileID, anyUserID)
+	file, err := s.Referral.OpenCV(id, authorID)
 	if errors.Is(err, service.ErrNoFile) {
 		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
 		return
@@ -277,8 +364,29 @@ func (s *Server) DownloadAnyCV(rw http.ResponseWriter, r *http.Request) {
 		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
 		return
 	}
+	defer file.Close()
+
+	download.Mode = repository.DownloadStream
+	download.Range = r.Header.Get("Range")
+
+	if err := s.Referral.RecordCVDownload(download); err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	serveFile(rw, r, file)
+}
 
-	sendResponse(rw, DownloadResponse{Link: url}, http.StatusOK)
+// serveFile sends file as an attachment, ranges are supported. Write deadline is extended while
+// the file is sent, so large files reach slow clients.
+func serveFile(rw http.ResponseWriter, r *http.Request, file service.CVFile) {
+	rw.Header().Set("Content-Type", file.ContentType)
+	rw.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Name}))
+	rw.Header().Set("Cache-Control", "private, no-store")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(deadlineWriter{ResponseWriter: rw, r: r}, r, file.Name, time.Time{}, file)
 }
 
 // UpdateRequest type presents data for request update.
@@ -321,6 +429,126 @@ func (s *Server) UpdateRequest(rw http.ResponseWriter, r *http.Request) {
 	sendResponse(rw, UpdateResponse{Message: fmt.Sprintf("request status with %s ID has been updated", request.ID)}, http.StatusOK)
 }
 
+// BulkUpdateRequest type presents data for status update of several requests.
+// Requests are selected either by list of ids or by filter.
+type BulkUpdateRequest struct {
+	IDs       []string          `json:"ids"`
+	Filter    *BulkUpdateFilter `json:"filter"`
+	NewStatus string            `json:"status"`
+	Atomic    bool              `json:"atomic"`
+}
+
+// BulkUpdateFilter presents a filter for selecting requests for bulk update,
+// fields have the same meaning as query parameters of requests list.
+type BulkUpdateFilter struct {
+	UserID      string   `json:"user_id"`
+	Statuses    []string `json:"status"`
+	Positions   []string `json:"position"`
+	CreatedFrom string   `json:"created_from"`
+	CreatedTo   string   `json:"created_to"`
+	UpdatedFrom string   `json:"updated_from"`
+	UpdatedTo   string   `json:"updated_to"`
+}
+
+// BulkUpdateResponse presents type with info about bulk update.
+type BulkUpdateResponse struct {
+	Updated int                    `json:"updated"`
+	Results []service.UpdateResult `json:"results"`
+}
+
+// BulkUpdateRequests updates status of several requests.
+func (s *Server) BulkUpdateRequests(rw http.ResponseWriter, r *http.Request) {
+	var request BulkUpdateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	filter, err := request.ValidateBulkUpdateRequest()
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	results, err := s.Referral.UpdateRequests(service.BulkUpdateRequest{
+		IDs:    request.IDs,
+		Filter: filter,
+		Status: strings.ToLower(request.NewStatus),
+		Atomic: request.Atomic,
+	})
+	if errors.Is(err, service.ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrNoResult) {
+		sendResponse(rw, BulkUpdateResponse{Results: results}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	var updated int
+	for _, result := range results {
+		if result.Error == "" {
+			updated++
+		}
+	}
+
+	sendResponse(rw, BulkUpdateResponse{Updated: updated, Results: results}, http.StatusOK)
+}
+
+// ValidateBulkUpdateRequest validates data before bulk update and returns requests filter if it's specified.
+func (r *BulkUpdateRequest) ValidateBulkUpdateRequest() (*repository.RequestFilter, error) {
+	status := strings.ToLower(r.NewStatus)
+	if status == "" || !requestsStatus[status] {
+		return nil, fmt.Errorf("%w: request status", ErrInvalidParameter)
+	}
+
+	if (len(r.IDs) == 0) == (r.Filter == nil) {
+		return nil, fmt.Errorf("%w: either ids or filter must be specified", ErrInvalidParameter)
+	}
+
+	if len(r.IDs) > maxBulkUpdateSize {
+		return nil, fmt.Errorf("%w: at most %d ids can be updated at once", ErrInvalidParameter, maxBulkUpdateSize)
+	}
+
+	for _, id := range r.IDs {
+		if err := ValidateNumber(id); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Filter == nil {
+		return nil, nil
+	}
+
+	query := url.Values{
+		statusParameter:      r.Filter.Statuses,
+		positionParameter:    r.Filter.Positions,
+		createdFromParameter: {r.Filter.CreatedFrom},
+		createdToParameter:   {r.Filter.CreatedTo},
+		updatedFromParameter: {r.Filter.UpdatedFrom},
+		updatedToParameter:   {r.Filter.UpdatedTo},
+	}
+
+	filter, err := ValidateGetRequestsRequest(query, r.Filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter.AuthorID == "" && len(filter.Statuses) == 0 && len(filter.Positions) == 0 &&
+		filter.CreatedFrom.IsZero() && filter.CreatedTo.IsZero() && filter.UpdatedFrom.IsZero() && filter.UpdatedTo.IsZero() {
+		return nil, fmt.Errorf("%w: filter must contain at least one condition", ErrInvalidParameter)
+	}
+
+	return &filter, nil
+}
+
 // ValidateUpdateRequest validates data before request update.
 func (r *UpdateRequest) ValidateUpdateRequest() error {
 	if !requestsStatus[strings.ToLower(r.NewStatus)] {
@@ -375,22 +603,7 @@ func (r *LogInRequest) ValidateLogInRequest() error {
 
 // ValidateCandidateSendingRequest validates data before candidate sending.
 func ValidateCandidateSendingRequest(r service.SubmitCandidateRequest) error {
-	if len(r.CandidateName) == 0 || len(r.CandidateSurname) == 0 {
-		return fmt.Errorf("%w: wrong length", ErrInvalidParameter)
-	}
-
-	nameSurnameExp := "^(^[A-Za-zА-Яа-я]{2,16})?$"
-	isValid, _ := regexp.MatchString(nameSurnameExp, r.CandidateName)
-	if !isValid {
-		return fmt.Errorf("%w: name has invalid format", ErrInvalidParameter)
-	}
-
-	isValid, _ = regexp.MatchString(nameSurnameExp, r.CandidateSurname)
-	if !isValid {
-		return fmt.Errorf("%w: surname has invalid format", ErrInvalidParameter)
-	}
-
-	return nil
+	return service.ValidateCandidate(r.CandidateName, r.CandidateSurname, r.Position, r.Department)
 }
 
 var requestsStatus = map[string]bool{
@@ -400,62 +613,194 @@ var requestsStatus = map[string]bool{
 	"":          true,
 }
 
-// ValidateGetRequestsRequest validates parameters of request of getting requests.
-func ValidateGetRequestsRequest(status, pageNumber, pageSize, id string) (int, int, error) {
-	var pn int
-	var ps int
+// ValidateGetRequestsRequest validates parameters of request of getting requests
+// and builds requests filter from them.
+func ValidateGetRequestsRequest(query url.Values, id string) (repository.RequestFilter, error) {
+	filter := repository.RequestFilter{
+		AuthorID:   id,
+		Statuses:   queryValues(query, statusParameter),
+		Positions:  queryValues(query, positionParameter),
+		Keywords:   queryValues(query, keywordParameter),
+		SortBy:     strings.ToLower(query.Get(sortParameter)),
+		SortOrder:  strings.ToLower(query.Get(orderParameter)),
+		PageNumber: defaultPageNumber,
+		PageSize:   defaultPageSize,
+	}
+
+	for i, status := range filter.Statuses {
+		filter.Statuses[i] = strings.ToLower(status)
+		if !requestsStatus[filter.Statuses[i]] {
+			return repository.RequestFilter{}, fmt.Errorf("%w: request status", ErrInvalidParameter)
+		}
+	}
+
+	if len(filter.Keywords) > maxKeywords {
+		return repository.RequestFilter{}, fmt.Errorf("%w: too many keywords", ErrInvalidParameter)
+	}
+	for i, keyword := range filter.Keywords {
+		if utf8.RuneCountInString(keyword) > maxKeywordLength {
+			return repository.RequestFilter{}, fmt.Errorf("%w: keyword is too long", ErrInvalidParameter)
+		}
+		filter.Keywords[i] = strings.ToLower(keyword)
+	}
+
+	if filter.SortBy != "" && !repository.IsSortField(filter.SortBy) {
+		return repository.RequestFilter{}, fmt.Errorf("%w: sort field", ErrInvalidParameter)
+	}
 
-	if !requestsStatus[strings.ToLower(status)] {
-		return 0, 0, fmt.Errorf("%w: request status", ErrInvalidParameter)
+	if filter.SortOrder != "" && filter.SortOrder != repository.SortOrderAsc && filter.SortOrder != repository.SortOrderDesc {
+		return repository.RequestFilter{}, fmt.Errorf("%w: sort order", ErrInvalidParameter)
 	}
 
 	idExp := "^([1-9])\\d*$"
 
-	if pageSize != "" {
+	if pageSize := query.Get(pageSizeParameter); pageSize != "" {
 		ok, err := regexp.MatchString(idExp, pageSize)
 		if !ok {
-			return 0, 0, fmt.Errorf("%w: page size has bad format", ErrInvalidParameter)
+			return repository.RequestFilter{}, fmt.Errorf("%w: page size has bad format", ErrInvalidParameter)
 		}
 		if err != nil {
-			return 0, 0, fmt.Errorf("cannot validate page size: %w", err)
+			return repository.RequestFilter{}, fmt.Errorf("cannot validate page size: %w", err)
 		}
 
-		ps, err = strconv.Atoi(pageSize)
+		filter.PageSize, err = strconv.Atoi(pageSize)
 		if err != nil {
-			return 0, 0, fmt.Errorf("cannot conver page size to int: %w", err)
+			return repository.RequestFilter{}, fmt.Errorf("cannot conver page size to int: %w", err)
 		}
-	} else {
-		ps = defaultPageSize
 	}
 
-	if pageNumber != "" {
+	if pageNumber := query.Get(pageNumberParameter); pageNumber != "" {
 		ok, err := regexp.MatchString(idExp, pageNumber)
 		if !ok {
-			return 0, 0, fmt.Errorf("%w: page number has bad format", ErrInvalidParameter)
+			return repository.RequestFilter{}, fmt.Errorf("%w: page number has bad format", ErrInvalidParameter)
 		}
 		if err != nil {
-			return 0, 0, fmt.Errorf("cannot validate page number: %w", err)
+			return repository.RequestFilter{}, fmt.Errorf("cannot validate page number: %w", err)
 		}
 
-		pn, err = strconv.Atoi(pageNumber)
+		filter.PageNumber, err = strconv.Atoi(pageNumber)
 		if err != nil {
-			return 0, 0, fmt.Errorf("cannot convert page number to integer: %w", err)
+			return repository.RequestFilter{}, fmt.Errorf("cannot convert page number to integer: %w", err)
 		}
-	} else {
-		pn = defaultPageNumber
 	}
 
 	if id != "" {
 		ok, err := regexp.MatchString(idExp, id)
 		if !ok {
-			return 0, 0, fmt.Errorf("%w: user id has bad format", ErrInvalidParameter)
+			return repository.RequestFilter{}, fmt.Errorf("%w: user id has bad format", ErrInvalidParameter)
+		}
+		if err != nil {
+			return repository.RequestFilter{}, fmt.Errorf("cannot validate id parameter: %w", err)
+		}
+	}
+
+	if query.Has(cursorParameter) {
+		filter.Keyset = true
+
+		if cursor := query.Get(cursorParameter); cursor != "" {
+			after, err := repository.DecodeCursor(cursor)
+			if err != nil {
+				return repository.RequestFilter{}, fmt.Errorf("%w: cursor", ErrInvalidParameter)
+			}
+
+			filter.After = &after
+		}
+
+		if total := query.Get(totalParameter); total != "" {
+			var err error
+
+			filter.CountTotal, err = strconv.ParseBool(total)
+			if err != nil {
+				return repository.RequestFilter{}, fmt.Errorf("%w: total", ErrInvalidParameter)
+			}
+		}
+	}
+
+	if assignee := query.Get(assigneeParameter); assignee == unassignedValue {
+		filter.Unassigned = true
+	} else if assignee != "" {
+		if !assigneeExp.MatchString(assignee) {
+			return repository.RequestFilter{}, fmt.Errorf("%w: assignee has bad format", ErrInvalidParameter)
+		}
+
+		filter.AssigneeID = assignee
+	}
+
+	var err error
+
+	if filter.CreatedFrom, filter.CreatedTo, err = parseDateRange(query, createdFromParameter, createdToParameter); err != nil {
+		return repository.RequestFilter{}, err
+	}
+
+	if filter.UpdatedFrom, filter.UpdatedTo, err = parseDateRange(query, updatedFromParameter, updatedToParameter); err != nil {
+		return repository.RequestFilter{}, err
+	}
+
+	return filter, nil
+}
+
+// queryValues returns all values of query parameter, it's possible to pass several values
+// both by repeating parameter and by separating values with comma.
+func queryValues(query url.Values, key string) []string {
+	var values []string
+
+	for _, param := range query[key] {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
+
+// parseDateRange parses lower and upper bounds of date range. Bounds can be passed
+// either as a date or in RFC 3339 format, a date in upper bound includes the whole day.
+func parseDateRange(query url.Values, fromKey, toKey string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+
+	if value := query.Get(fromKey); value != "" {
+		from, _, err = parseDate(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s has bad format", ErrInvalidParameter, fromKey)
 		}
+	}
+
+	if value := query.Get(toKey); value != "" {
+		var date bool
+
+		to, date, err = parseDate(value)
 		if err != nil {
-			return 0, 0, fmt.Errorf("cannot validate id parameter: %w", err)
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %s has bad format", ErrInvalidParameter, toKey)
+		}
+
+		if date {
+			to = to.AddDate(0, 0, 1)
 		}
 	}
 
-	return pn, ps, nil
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s must be before %s", ErrInvalidParameter, fromKey, toKey)
+	}
+
+	return from, to, nil
+}
+
+// parseDate parses date either in RFC 3339 format or as a date, the flag tells if value is a date.
+func parseDate(value string) (time.Time, bool, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, false, nil
+	}
+
+	t, err = time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return t, true, nil
 }
 
 // ValidateNumber checks if parameter is number.
@@ -2,15 +2,26 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/repository"
 	"github.com/cyberdr0id/referral/internal/service"
 	mock_service "github.com/cyberdr0id/referral/internal/service/mock"
+	"github.com/cyberdr0id/referral/pkg/jwt"
 	mylog "github.com/cyberdr0id/referral/pkg/log"
+	"github.com/cyberdr0id/referral/pkg/xlsx"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -397,3 +408,1161 @@ func TestServer_LogIn(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateGetRequestsRequest(t *testing.T) {
+	testTable := []struct {
+		testName        string
+		query           string
+		userID          string
+		expectedFilter  repository.RequestFilter
+		isErrorExpected bool
+	}{
+		{
+			testName: "Success: default parameters",
+			query:    "",
+			userID:   defaultID,
+			expectedFilter: repository.RequestFilter{
+				AuthorID:   defaultID,
+				PageNumber: defaultPageNumber,
+				PageSize:   defaultPageSize,
+			},
+		},
+		{
+			testName: "Success: filters and sort",
+			query:    "status=Accepted,rejected&status=submitted&position=developer&sort=surname&order=ASC&page=2&size=5&created_from=2022-01-01&created_to=2022-01-31",
+			expectedFilter: repository.RequestFilter{
+				Statuses:    []string{"accepted", "rejected", "submitted"},
+				Positions:   []string{"developer"},
+				CreatedFrom: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+				CreatedTo:   time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+				SortBy:      "surname",
+				SortOrder:   repository.SortOrderAsc,
+				PageNumber:  2,
+				PageSize:    5,
+			},
+		},
+		{
+			testName: "Success: assignee",
+			query:    "assignee=" + defaultID,
+			expectedFilter: repository.RequestFilter{
+				AssigneeID: defaultID,
+				PageNumber: defaultPageNumber,
+				PageSize:   defaultPageSize,
+			},
+		},
+		{
+			testName: "Success: unassigned",
+			query:    "assignee=none",
+			expectedFilter: repository.RequestFilter{
+				Unassigned: true,
+				PageNumber: defaultPageNumber,
+				PageSize:   defaultPageSize,
+			},
+		},
+		{
+			testName: "Success: keywords",
+			query:    "keyword=Golang,docker&keyword=C%2B%2B",
+			expectedFilter: repository.RequestFilter{
+				Keywords:   []string{"golang", "docker", "c++"},
+				PageNumber: defaultPageNumber,
+				PageSize:   defaultPageSize,
+			},
+		},
+		{
+			testName:        "Failure: invalid assignee",
+			query:           "assignee=me",
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: too long keyword",
+			query:           "keyword=" + strings.Repeat("a", maxKeywordLength+1),
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: invalid status",
+			query:           "status=accepted,hired",
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: invalid sort field",
+			query:           "sort=password",
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: invalid sort order",
+			query:           "order=up",
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: invalid date",
+			query:           "updated_from=yesterday",
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: empty date range",
+			query:           "created_from=2022-02-01&created_to=2022-01-01",
+			isErrorExpected: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			query, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			filter, err := ValidateGetRequestsRequest(query, tc.userID)
+			if tc.isErrorExpected {
+				assert.ErrorIs(t, err, ErrInvalidParameter)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedFilter, filter)
+		})
+	}
+}
+
+func TestServer_GetAllRequests(t *testing.T) {
+	total := 5
+	next := "/admin/references?cursor=" + repository.EncodeCursor(repository.RequestCursor{Value: "2022-01-02T00:00:00Z", ID: "2"})
+
+	requests := []repository.UserRequests{
+		{ID: "3", Name: defaultName, Status: "submitted", Created: "2022-01-03T00:00:00Z"},
+		{ID: "2", Name: defaultName, Status: "submitted", Created: "2022-01-02T00:00:00Z"},
+	}
+
+	testTable := []struct {
+		testName         string
+		query            string
+		filter           repository.RequestFilter
+		total            int
+		expectedResponse RequestsPageResponse
+		expectedLink     string
+	}{
+		{
+			testName: "Success: offset pagination, status 200",
+			query:    "page=2&size=2",
+			filter:   repository.RequestFilter{PageNumber: 2, PageSize: 2},
+			total:    5,
+			expectedResponse: RequestsPageResponse{
+				Items: requests,
+				Total: &total,
+				Page:  2,
+				Size:  2,
+				Next:  "/admin/references?page=3&size=2",
+				Prev:  "/admin/references?page=1&size=2",
+			},
+			expectedLink: `</admin/references?page=1&size=2>; rel="first", ` +
+				`</admin/references?page=1&size=2>; rel="prev", ` +
+				`</admin/references?page=3&size=2>; rel="next", ` +
+				`</admin/references?page=3&size=2>; rel="last"`,
+		},
+		{
+			testName: "Success: keyset pagination, status 200",
+			query:    "cursor=&size=2",
+			filter:   repository.RequestFilter{PageNumber: defaultPageNumber, PageSize: 2, Keyset: true},
+			expectedResponse: RequestsPageResponse{
+				Items: requests,
+				Size:  2,
+				Next:  next + "&size=2",
+			},
+			expectedLink: `</admin/references?cursor=&size=2>; rel="first", ` +
+				`<` + next + `&size=2>; rel="next"`,
+		},
+		{
+			testName: "Success: keyset pagination with total, status 200",
+			query:    "cursor=&size=2&total=true",
+			filter:   repository.RequestFilter{PageNumber: defaultPageNumber, PageSize: 2, Keyset: true, CountTotal: true},
+			total:    5,
+			expectedResponse: RequestsPageResponse{
+				Items: requests,
+				Total: &total,
+				Size:  2,
+				Next:  next + "&size=2&total=true",
+			},
+			expectedLink: `</admin/references?cursor=&size=2&total=true>; rel="first", ` +
+				`<` + next + `&size=2&total=true>; rel="next"`,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(&jwt.Claims{IsAdmin: true}, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			referral.EXPECT().GetRequests(tc.filter).Return(requests, tc.total, nil)
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/references?"+tc.query, nil)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+
+			s.Router.ServeHTTP(w, req)
+
+			var response RequestsPageResponse
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.expectedResponse, response)
+			assert.Equal(t, tc.expectedLink, w.Header().Get(linkHeaderKey))
+		})
+	}
+}
+
+func TestServer_GetQueue(t *testing.T) {
+	requests := []repository.UserRequests{
+		{ID: "3", Name: defaultName, Status: "submitted", Created: "2022-01-03T00:00:00Z"},
+	}
+
+	testTable := []struct {
+		testName string
+		query    string
+		filter   repository.RequestFilter
+	}{
+		{
+			testName: "Success: open requests by default, status 200",
+			filter: repository.RequestFilter{
+				AssigneeID: defaultID,
+				Statuses:   []string{repository.StatusSubmitted},
+				PageNumber: defaultPageNumber,
+				PageSize:   defaultPageSize,
+			},
+		},
+		{
+			testName: "Success: requests with status, status 200",
+			query:    "status=accepted&assignee=none",
+			filter: repository.RequestFilter{
+				AssigneeID: defaultID,
+				Statuses:   []string{repository.StatusAccepted},
+				PageNumber: defaultPageNumber,
+				PageSize:   defaultPageSize,
+			},
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{IsAdmin: true}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			referral.EXPECT().GetRequests(tc.filter).Return(requests, len(requests), nil)
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/queue?"+tc.query, nil)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+
+			s.Router.ServeHTTP(w, req)
+
+			var response RequestsPageResponse
+			_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, requests, response.Items)
+		})
+	}
+}
+
+func TestServer_StreamEvents(t *testing.T) {
+	replayed := event.Event{ID: "5", Name: event.ReferralCreated, Payload: json.RawMessage(`{"id":"3"}`)}
+	live := event.Event{ID: "6", Name: event.ReferralStatusChanged, Payload: json.RawMessage(`{"id":"3","status":"accepted"}`)}
+
+	testTable := []struct {
+		testName           string
+		lastEventID        string
+		mockBehavior       func(r *mock_service.MockReferral)
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:    "Success: replay and live events, status 200",
+			lastEventID: "4",
+			mockBehavior: func(r *mock_service.MockReferral) {
+				r.EXPECT().SubscribeEvents(defaultID).DoAndReturn(func(authorID string) *event.Subscription {
+					broker := event.NewBroker()
+					subscription := broker.Subscribe(nil)
+
+					_ = broker.Publish(replayed)
+					_ = broker.Publish(live)
+					subscription.Close()
+
+					return subscription
+				})
+				r.EXPECT().ReplayEvents("4", defaultID).Return([]event.Event{replayed}, false, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: "retry: 3000\n\n" +
+				"id: 5\nevent: referral.created\ndata: {\"id\":\"3\"}\n\n" +
+				"id: 6\nevent: referral.status_changed\ndata: {\"id\":\"3\",\"status\":\"accepted\"}\n\n",
+		},
+		{
+			testName:    "Success: truncated replay, status 200",
+			lastEventID: "4",
+			mockBehavior: func(r *mock_service.MockReferral) {
+				r.EXPECT().SubscribeEvents(defaultID).DoAndReturn(func(authorID string) *event.Subscription {
+					broker := event.NewBroker()
+					subscription := broker.Subscribe(nil)
+
+					_ = broker.Publish(replayed)
+					_ = broker.Publish(live)
+					subscription.Close()
+
+					return subscription
+				})
+				r.EXPECT().ReplayEvents("4", defaultID).Return([]event.Event{replayed}, true, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedBody: "retry: 3000\n\n" +
+				"id: 5\nevent: resync\ndata: {}\n\n" +
+				"id: 6\nevent: referral.status_changed\ndata: {\"id\":\"3\",\"status\":\"accepted\"}\n\n",
+		},
+		{
+			testName:           "Failure: bad last event id, status 400",
+			lastEventID:        "last",
+			mockBehavior:       func(r *mock_service.MockReferral) {},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedBody:       `{"error":"invalid parameter: last event id has bad format"}` + "\n",
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			tc.mockBehavior(referral)
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/references/events", nil)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+			req.Header.Set(lastEventIDHeader, tc.lastEventID)
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestBulkUpdateRequest_ValidateBulkUpdateRequest(t *testing.T) {
+	testTable := []struct {
+		testName        string
+		request         BulkUpdateRequest
+		expectedFilter  *repository.RequestFilter
+		isErrorExpected bool
+	}{
+		{
+			testName: "Success: ids",
+			request:  BulkUpdateRequest{IDs: []string{"1", "2"}, NewStatus: "Accepted"},
+		},
+		{
+			testName: "Success: filter",
+			request: BulkUpdateRequest{
+				Filter:    &BulkUpdateFilter{Statuses: []string{"submitted"}, CreatedTo: "2022-01-01"},
+				NewStatus: "rejected",
+			},
+			expectedFilter: &repository.RequestFilter{
+				Statuses:   []string{"submitted"},
+				CreatedTo:  time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+				PageNumber: defaultPageNumber,
+				PageSize:   defaultPageSize,
+			},
+		},
+		{
+			testName:        "Failure: empty status",
+			request:         BulkUpdateRequest{IDs: []string{"1"}},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: both ids and filter",
+			request:         BulkUpdateRequest{IDs: []string{"1"}, Filter: &BulkUpdateFilter{UserID: defaultID}, NewStatus: "accepted"},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: empty filter",
+			request:         BulkUpdateRequest{Filter: &BulkUpdateFilter{}, NewStatus: "accepted"},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: invalid id",
+			request:         BulkUpdateRequest{IDs: []string{"1", "abc"}, NewStatus: "accepted"},
+			isErrorExpected: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			filter, err := tc.request.ValidateBulkUpdateRequest()
+			if tc.isErrorExpected {
+				assert.ErrorIs(t, err, ErrInvalidParameter)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedFilter, filter)
+		})
+	}
+}
+
+func TestServer_ExportRequests(t *testing.T) {
+	request := repository.UserRequests{
+		ID:       defaultID,
+		Name:     "Billie",
+		Surname:  "Jean",
+		Position: "=developer",
+		Status:   "accepted",
+		Created:  "2022-01-01T00:00:00Z",
+		Updated:  "2022-01-03T00:00:00Z",
+		Decided:  "2022-01-02T00:00:00Z",
+	}
+	request.Author.ID = defaultID
+	request.Author.Name = defaultName
+
+	testTable := []struct {
+		testName            string
+		query               string
+		accept              string
+		expectedStatusCode  int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			testName:            "Success: csv by default, status 200",
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: csvContentType,
+			expectedBody: "id,candidate_name,candidate_surname,position,status,author_id,author_name,created,updated,decided\n" +
+				"1,Billie,Jean,'=developer,accepted,1,testName,2022-01-01T00:00:00Z,2022-01-03T00:00:00Z,2022-01-02T00:00:00Z\n",
+		},
+		{
+			testName:            "Success: xlsx by Accept header, status 200",
+			accept:              xlsx.ContentType,
+			expectedStatusCode:  http.StatusOK,
+			expectedContentType: xlsx.ContentType,
+		},
+		{
+			testName:           "Failure: unknown format, status 400",
+			query:              "format=pdf",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(&jwt.Claims{IsAdmin: true}, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			referral.EXPECT().ExportRequests(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ repository.RequestFilter, fn func(repository.UserRequests) error) error {
+					return fn(request)
+				}).
+				MaxTimes(1)
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/references/export?"+tc.query, nil)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+			req.Header.Set(acceptHeaderKey, tc.accept)
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+			if tc.expectedContentType != "" {
+				assert.Equal(t, tc.expectedContentType, w.Header().Get("Content-Type"))
+			}
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestServer_SendCandidate(t *testing.T) {
+	testTable := []struct {
+		testName           string
+		filename           string
+		content            string
+		maxCVSize          int64
+		expectedFiletype   string
+		expectedStatusCode int
+	}{
+		{
+			testName:           "Success: status 200",
+			filename:           "cv.pdf",
+			content:            "%PDF-1.7\n",
+			expectedFiletype:   "pdf",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName:           "Failure: executable with document extension, status 415",
+			filename:           "cv.pdf",
+			content:            "MZ\x90\x00\x03\x00",
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			testName:           "Failure: content doesn't match extension, status 415",
+			filename:           "cv.docx",
+			content:            "%PDF-1.7\n",
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			testName:           "Failure: file is too large, status 413",
+			filename:           "cv.txt",
+			content:            strings.Repeat("a", 32),
+			maxCVSize:          16,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			if tc.expectedFiletype != "" {
+				referral.EXPECT().AddCandidate(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, request service.SubmitCandidateRequest) (string, error) {
+						assert.Equal(t, tc.expectedFiletype, request.Filetype)
+						assert.Equal(t, "application/pdf", request.ContentType)
+
+						return defaultID, nil
+					})
+			}
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+			if tc.maxCVSize != 0 {
+				s.MaxCVSize = tc.maxCVSize
+			}
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			form.WriteField(candidateNameParam, "Billie")
+			form.WriteField(candidateSurnameParam, "Jean")
+			file, _ := form.CreateFormFile(filenameParam, tc.filename)
+			file.Write([]byte(tc.content))
+			form.Close()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/references", &body)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestServer_AttachFile(t *testing.T) {
+	testTable := []struct {
+		testName           string
+		kind               string
+		attachErr          error
+		expectedStatusCode int
+	}{
+		{
+			testName:           "Success: status 201",
+			kind:               repository.FileKindCV,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			testName:           "Failure: unknown kind, status 400",
+			kind:               "photo",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			testName:           "Failure: request of another user, status 404",
+			kind:               repository.FileKindCoverLetter,
+			attachErr:          service.ErrNoResult,
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			if repository.IsFileKind(tc.kind) {
+				referral.EXPECT().AttachFile(gomock.Any()).
+					DoAndReturn(func(request service.AttachFileRequest) (repository.File, error) {
+						assert.Equal(t, defaultID, request.RequestID)
+						assert.Equal(t, tc.kind, request.Kind)
+						assert.Equal(t, "cv.pdf", request.Filename)
+						assert.Equal(t, defaultID, request.AuthorID)
+						assert.Equal(t, defaultID, request.UserID)
+
+						return repository.File{ID: defaultID}, tc.attachErr
+					})
+			}
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			form.WriteField(kindParameter, tc.kind)
+			file, _ := form.CreateFormFile(filenameParam, "cv.pdf")
+			file.Write([]byte("%PDF-1.7\n"))
+			form.Close()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/references/files?id="+defaultID, &body)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+			req.Header.Set("Content-Type", form.FormDataContentType())
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestServer_DeleteFile(t *testing.T) {
+	testTable := []struct {
+		testName           string
+		deleteErr          error
+		expectedStatusCode int
+	}{
+		{
+			testName:           "Success: status 204",
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			testName:           "Failure: current CV, status 409",
+			deleteErr:          service.ErrCurrentCV,
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			testName:           "Failure: no file, status 404",
+			deleteErr:          service.ErrNoFile,
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			referral.EXPECT().DeleteFile(defaultID, defaultID).Return(tc.deleteErr)
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("DELETE", "/references/files?file_id="+defaultID, nil)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+// uploadSHA256 presents SHA-256 checksum of uploaded CV in hex.
+const uploadSHA256 = "0716f9264c9fe19f5d7455276107f3ddcc1d3497f63d60689a73558ae8a1bf5e"
+
+func TestServer_CreateUpload(t *testing.T) {
+	testTable := []struct {
+		testName           string
+		size               int64
+		sha256             string
+		createErr          error
+		expectedStatusCode int
+	}{
+		{
+			testName:           "Success: status 201",
+			size:               100,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			testName:           "Failure: empty file, status 400",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			testName:           "Failure: too large file, status 413",
+			size:               DefaultMaxCVSize + 1,
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			testName:           "Failure: unsupported type, status 415",
+			size:               100,
+			createErr:          service.ErrUnsupportedCV,
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			testName:           "Failure: invalid checksum, status 400",
+			size:               100,
+			sha256:             "checksum",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			checksum := uploadSHA256
+			if tc.sha256 != "" {
+				checksum = tc.sha256
+			}
+
+			referral := mock_service.NewMockReferral(ctrl)
+			if tc.size > 0 && tc.size <= DefaultMaxCVSize && checksum == uploadSHA256 {
+				referral.EXPECT().CreateUpload(defaultID, "cv.pdf", tc.size, uploadSHA256).Return(service.UploadSlot{ID: defaultID}, tc.createErr)
+			}
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			body, _ := json.Marshal(CreateUploadRequest{Filename: "cv.pdf", Size: tc.size, SHA256: checksum})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/references/uploads", bytes.NewReader(body))
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestServer_SendUploadedCandidate(t *testing.T) {
+	testTable := []struct {
+		testName           string
+		request            SendUploadedCandidateRequest
+		addErr             error
+		expectedStatusCode int
+	}{
+		{
+			testName: "Success: status 200",
+			request: SendUploadedCandidateRequest{
+				UploadID:   defaultID,
+				Name:       "Billie",
+				Surname:    "Jean",
+				Position:   "developer",
+				Department: "engineering",
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			testName: "Failure: no upload id, status 400",
+			request: SendUploadedCandidateRequest{
+				Name:       "Billie",
+				Surname:    "Jean",
+				Position:   "developer",
+				Department: "engineering",
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			testName: "Failure: file isn't uploaded, status 409",
+			request: SendUploadedCandidateRequest{
+				UploadID:   defaultID,
+				Name:       "Billie",
+				Surname:    "Jean",
+				Position:   "developer",
+				Department: "engineering",
+			},
+			addErr:             service.ErrUploadIncomplete,
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			testName: "Failure: file doesn't match, status 422",
+			request: SendUploadedCandidateRequest{
+				UploadID:   defaultID,
+				Name:       "Billie",
+				Surname:    "Jean",
+				Position:   "developer",
+				Department: "engineering",
+			},
+			addErr:             service.ErrUploadMismatch,
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			testName: "Failure: used upload, status 404",
+			request: SendUploadedCandidateRequest{
+				UploadID:   defaultID,
+				Name:       "Billie",
+				Surname:    "Jean",
+				Position:   "developer",
+				Department: "engineering",
+			},
+			addErr:             service.ErrNoUpload,
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			if tc.request.UploadID != "" {
+				referral.EXPECT().AddUploadedCandidate(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ interface{}, request service.SubmitCandidateRequest) (string, error) {
+						assert.Equal(t, tc.request.UploadID, request.UploadID)
+						assert.Equal(t, tc.request.Name, request.CandidateName)
+
+						return defaultID, tc.addErr
+					})
+			}
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+
+			body, _ := json.Marshal(tc.request)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/references/uploaded", bytes.NewReader(body))
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+// nopCloser presents in-memory file which is opened for download.
+type nopCloser struct {
+	*strings.Reader
+}
+
+func (nopCloser) Close() error { return nil }
+
+func TestServer_DownloadCV(t *testing.T) {
+	openCV := func(string, string) (service.CVFile, error) {
+		return service.CVFile{
+			ReadSeekCloser: nopCloser{strings.NewReader("content")},
+			Name:           "Billie_Jean_CV.pdf",
+			ContentType:    "application/pdf",
+		}, nil
+	}
+
+	testTable := []struct {
+		testName           string
+		stream             bool
+		rangeHeader        string
+		openCV             func(string, string) (service.CVFile, error)
+		recordErr          error
+		expectedDownload   *repository.CVDownload
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Success: link, status 200",
+			expectedDownload:   &repository.CVDownload{RequestID: defaultID, UserID: defaultID, Mode: repository.DownloadLink},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       `{"link":"https://storage/1.pdf"}`,
+		},
+		{
+			testName:           "Success: stream, status 200",
+			stream:             true,
+			openCV:             openCV,
+			expectedDownload:   &repository.CVDownload{RequestID: defaultID, UserID: defaultID, Mode: repository.DownloadStream},
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "content",
+		},
+		{
+			testName:           "Success: range of stream, status 206",
+			stream:             true,
+			rangeHeader:        "bytes=3-",
+			openCV:             openCV,
+			expectedDownload:   &repository.CVDownload{RequestID: defaultID, UserID: defaultID, Mode: repository.DownloadStream, Range: "bytes=3-"},
+			expectedStatusCode: http.StatusPartialContent,
+			expectedBody:       "tent",
+		},
+		{
+			testName: "Failure: no file, status 400",
+			stream:   true,
+			openCV: func(string, string) (service.CVFile, error) {
+				return service.CVFile{}, service.ErrNoFile
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			testName:           "Failure: download isn't recorded, status 500",
+			stream:             true,
+			openCV:             openCV,
+			recordErr:          errInternalServerError,
+			expectedDownload:   &repository.CVDownload{RequestID: defaultID, UserID: defaultID, Mode: repository.DownloadStream},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			claims := &jwt.Claims{}
+			claims.Subject = defaultID
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(claims, nil).AnyTimes()
+
+			referral := mock_service.NewMockReferral(ctrl)
+			referral.EXPECT().DownloadFile(gomock.Any(), defaultID, defaultID).Return("https://storage/1.pdf", nil).AnyTimes()
+			if tc.openCV != nil {
+				referral.EXPECT().OpenCV(defaultID, defaultID).DoAndReturn(tc.openCV)
+			}
+			if tc.expectedDownload != nil {
+				referral.EXPECT().RecordCVDownload(gomock.Any()).DoAndReturn(func(download repository.CVDownload) error {
+					download.RemoteAddr = ""
+					assert.Equal(t, *tc.expectedDownload, download)
+
+					return tc.recordErr
+				})
+			}
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+			s.StreamCVs = tc.stream
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/cvs?id="+defaultID, nil)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+			if tc.rangeHeader != "" {
+				req.Header.Set("Range", tc.rangeHeader)
+			}
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, strings.TrimSpace(w.Body.String()))
+			}
+			if tc.stream && w.Code < http.StatusBadRequest {
+				assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+				assert.Equal(t, `attachment; filename=Billie_Jean_CV.pdf`, w.Header().Get("Content-Disposition"))
+			}
+		})
+	}
+}
+
+func TestServer_GetLeaderboard(t *testing.T) {
+	entries := []repository.LeaderboardEntry{{Rank: 1, Name: defaultName, Accepted: 3}}
+
+	testTable := []struct {
+		testName           string
+		enabled            bool
+		query              string
+		expectedStatusCode int
+		mock               func(s *mock_service.MockReferral)
+	}{
+		{
+			testName:           "Success: status 200",
+			enabled:            true,
+			query:              "from=2022-01-01&to=2022-01-31&limit=5",
+			expectedStatusCode: http.StatusOK,
+			mock: func(s *mock_service.MockReferral) {
+				s.EXPECT().GetLeaderboard(
+					time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+					time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC),
+					5,
+				).Return(entries, nil)
+			},
+		},
+		{
+			testName:           "Failure: leaderboard is disabled, status 404",
+			enabled:            false,
+			expectedStatusCode: http.StatusNotFound,
+			mock:               func(s *mock_service.MockReferral) {},
+		},
+		{
+			testName:           "Failure: invalid limit, status 400",
+			enabled:            true,
+			query:              "limit=1000",
+			expectedStatusCode: http.StatusBadRequest,
+			mock:               func(s *mock_service.MockReferral) {},
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			auth := mock_service.NewMockAuth(ctrl)
+			auth.EXPECT().ParseToken(token).Return(&jwt.Claims{}, nil)
+
+			referral := mock_service.NewMockReferral(ctrl)
+			tc.mock(referral)
+
+			logger, err := mylog.NewLogger()
+			if err != nil {
+				t.Fatalf("error with logger creating: %s", err.Error())
+			}
+
+			s := NewServer(auth, referral, logger)
+			s.LeaderboardEnabled = tc.enabled
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/references/leaderboard?"+tc.query, nil)
+			req.Header.Set(authHeaderKey, bearerScheme+" "+token)
+
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+			if tc.expectedStatusCode == http.StatusOK {
+				var response []repository.LeaderboardEntry
+				_ = json.Unmarshal(w.Body.Bytes(), &response)
+
+				assert.Equal(t, entries, response)
+			}
+		})
+	}
+}
+
+// deadlineConn records deadlines set on the connection.
+type deadlineConn struct {
+	net.Conn
+	read, write []time.Time
+}
+
+func (c *deadlineConn) SetReadDeadline(t time.Time) error {
+	c.read = append(c.read, t)
+	return nil
+}
+
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error {
+	c.write = append(c.write, t)
+	return nil
+}
+
+func TestWithDeadlines(t *testing.T) {
+	conn := &deadlineConn{}
+
+	handler := withDeadlines(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		rw.Write(body)
+		rw.Write(body)
+	}))
+
+	req := httptest.NewRequest(http.MethodPut, "/files/cv.pdf", strings.NewReader("content"))
+	req = req.WithContext(context.WithValue(req.Context(), connKey{}, net.Conn(conn)))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	assert.Equal(t, "contentcontent", w.Body.String())
+	assert.NotEmpty(t, conn.read)
+	assert.Len(t, conn.write, 2)
+	assert.WithinDuration(t, time.Now().Add(writeTimeout), conn.write[1], time.Second)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+const (
+	importFileParam    = "file"
+	importCVsParam     = "cvs"
+	dryRunParameter    = "dry_run"
+	maxImportMemory    = 32 << 20
+	importedCVNotFound = "CV file is not attached"
+)
+
+// ImportRequests admin handler that imports historical requests from CSV file.
+// CVs referenced from cv_path column are attached to the same multipart form and matched by file name.
+func (s *Server) ImportRequests(rw http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get(dryRunParameter); value != "" {
+		var err error
+
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			sendResponse(rw, ErrorResponse{Message: fmt.Errorf("%w: %s", ErrInvalidParameter, dryRunParameter).Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := r.ParseMultipartForm(maxImportMemory); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, _, err := r.FormFile(importFileParam)
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	cvs := map[string]*multipart.FileHeader{}
+	for _, fileHeader := range r.MultipartForm.File[importCVsParam] {
+		cvs[path.Base(fileHeader.Filename)] = fileHeader
+	}
+
+	openCV := func(cvPath string) (io.ReadSeekCloser, error) {
+		fileHeader, ok := cvs[path.Base(cvPath)]
+		if !ok {
+			return nil, errors.New(importedCVNotFound)
+		}
+
+		return fileHeader.Open()
+	}
+
+	report, err := s.Referral.ImportRequests(file, openCV, dryRun)
+	if errors.Is(err, service.ErrInvalidImportFile) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, report, http.StatusOK)
+}
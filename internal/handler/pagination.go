@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+)
+
+const (
+	cursorParameter = "cursor"
+	totalParameter  = "total"
+	linkHeaderKey   = "Link"
+)
+
+// RequestsPageResponse presents a page of requests with pagination metadata.
+type RequestsPageResponse struct {
+	Items []repository.UserRequests `json:"items"`
+	Total *int                      `json:"total,omitempty"`
+	Page  int                       `json:"page,omitempty"`
+	Size  int                       `json:"size"`
+	Next  string                    `json:"next,omitempty"`
+	Prev  string                    `json:"prev,omitempty"`
+}
+
+// sendRequestsPage gets requests by filter and sends them wrapped into a page
+// with navigation links in body and in Link header (RFC 8288).
+func (s *Server) sendRequestsPage(rw http.ResponseWriter, r *http.Request, filter repository.RequestFilter) {
+	requests, total, err := s.Referral.GetRequests(filter)
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	page := RequestsPageResponse{
+		Items: requests,
+		Size:  filter.PageSize,
+	}
+
+	if !filter.Keyset || filter.CountTotal {
+		page.Total = &total
+	}
+
+	links := map[string]string{}
+
+	if filter.Keyset {
+		links["first"] = pageURL(r.URL, cursorParameter, "")
+		if len(requests) == filter.PageSize {
+			cursor := repository.EncodeCursor(filter.CursorOf(requests[len(requests)-1]))
+			links["next"] = pageURL(r.URL, cursorParameter, cursor)
+		}
+	} else {
+		page.Page = filter.PageNumber
+
+		lastPage := (total + filter.PageSize - 1) / filter.PageSize
+		if lastPage == 0 {
+			lastPage = 1
+		}
+
+		links["first"] = pageURL(r.URL, pageNumberParameter, "1")
+		links["last"] = pageURL(r.URL, pageNumberParameter, strconv.Itoa(lastPage))
+		if filter.PageNumber > 1 {
+			links["prev"] = pageURL(r.URL, pageNumberParameter, strconv.Itoa(filter.PageNumber-1))
+		}
+		if filter.PageNumber < lastPage {
+			links["next"] = pageURL(r.URL, pageNumberParameter, strconv.Itoa(filter.PageNumber+1))
+		}
+	}
+
+	page.Next = links["next"]
+	page.Prev = links["prev"]
+
+	rw.Header().Set(linkHeaderKey, linkHeader(links))
+
+	sendResponse(rw, page, http.StatusOK)
+}
+
+// pageURL returns URL of current request with replaced paging parameter.
+func pageURL(u *url.URL, key, value string) string {
+	query := u.Query()
+	query.Set(key, value)
+
+	return u.Path + "?" + query.Encode()
+}
+
+// linkHeader formats links as a value of Link header.
+func linkHeader(links map[string]string) string {
+	var values []string
+
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if link, ok := links[rel]; ok {
+			values = append(values, fmt.Sprintf("<%s>; rel=%q", link, rel))
+		}
+	}
+
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+// GetPreferences outputs settings of current user.
+func (s *Server) GetPreferences(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	preferences, err := s.Referral.GetPreferences(userID)
+	if errors.Is(err, service.ErrNoUser) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, preferences, http.StatusOK)
+}
+
+// UpdatePreferences updates settings of current user.
+func (s *Server) UpdatePreferences(rw http.ResponseWriter, r *http.Request) {
+	var request repository.Preferences
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := service.ValidatePreferences(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	err := s.Referral.UpdatePreferences(userID, request)
+	if errors.Is(err, service.ErrNoUser) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, request, http.StatusOK)
+}
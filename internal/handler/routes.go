@@ -1,6 +1,12 @@
 // Package handler responsible for rounting.
 package handler
 
+import (
+	"net/http"
+
+	"github.com/cyberdr0id/referral/internal/storage"
+)
+
 // InitRoutes initialize all endpoints.
 func (s *Server) InitRoutes() {
 	s.Router.Use(s.LoggingMiddlewre)
@@ -13,12 +19,59 @@ func (s *Server) InitRoutes() {
 
 	userRouter.HandleFunc("/references", s.SendCandidate).Methods("POST")
 	userRouter.HandleFunc("/references", s.GetRequests).Methods("GET")
+	userRouter.HandleFunc("/references/uploads", s.CreateUpload).Methods("POST")
+	userRouter.HandleFunc("/references/uploaded", s.SendUploadedCandidate).Methods("POST")
+	userRouter.HandleFunc("/references/stats", s.GetUserStats).Methods("GET")
+	userRouter.HandleFunc("/references/leaderboard", s.GetLeaderboard).Methods("GET")
+	userRouter.HandleFunc("/references/events", s.StreamEvents).Methods("GET")
 	userRouter.HandleFunc("/cvs", s.DownloadCV).Methods("GET")
+	userRouter.HandleFunc("/references/files", s.GetFiles).Methods("GET")
+	userRouter.HandleFunc("/references/files", s.AttachFile).Methods("POST")
+	userRouter.HandleFunc("/references/files", s.DeleteFile).Methods("DELETE")
+	userRouter.HandleFunc("/references/files/download", s.DownloadFile).Methods("GET")
+	userRouter.HandleFunc("/users/me/preferences", s.GetPreferences).Methods("GET")
+	userRouter.HandleFunc("/users/me/preferences", s.UpdatePreferences).Methods("PUT")
 
 	adminRouter := userRouter.NewRoute().Subrouter()
 	adminRouter.Use(s.AdminMiddleware)
 
 	adminRouter.HandleFunc("/admin/references", s.UpdateRequest).Methods("PUT")
+	adminRouter.HandleFunc("/admin/references/bulk", s.BulkUpdateRequests).Methods("PUT")
+	adminRouter.HandleFunc("/admin/references/assignee", s.AssignRequest).Methods("PUT")
+	adminRouter.HandleFunc("/admin/queue", s.GetQueue).Methods("GET")
 	adminRouter.HandleFunc("/admin/references", s.GetAllRequests).Methods("GET")
+	adminRouter.HandleFunc("/admin/references/export", s.ExportRequests).Methods("GET")
+	adminRouter.HandleFunc("/admin/references/import", s.ImportRequests).Methods("POST")
+	adminRouter.HandleFunc("/admin/references/events", s.StreamAllEvents).Methods("GET")
 	adminRouter.HandleFunc("/admin/cvs", s.DownloadAnyCV).Methods("GET")
+	adminRouter.HandleFunc("/admin/references/files", s.GetAnyFiles).Methods("GET")
+	adminRouter.HandleFunc("/admin/references/files", s.AttachAnyFile).Methods("POST")
+	adminRouter.HandleFunc("/admin/references/files", s.DeleteAnyFile).Methods("DELETE")
+	adminRouter.HandleFunc("/admin/references/files/download", s.DownloadAnyFile).Methods("GET")
+	adminRouter.HandleFunc("/admin/stats", s.GetStats).Methods("GET")
+	adminRouter.HandleFunc("/admin/reports/overdue", s.GetOverdueRequests).Methods("GET")
+	adminRouter.HandleFunc("/admin/bonus-rules", s.GetBonusRules).Methods("GET")
+	adminRouter.HandleFunc("/admin/bonus-rules", s.SaveBonusRule).Methods("PUT")
+	adminRouter.HandleFunc("/admin/bonus-rules", s.DeleteBonusRule).Methods("DELETE")
+	adminRouter.HandleFunc("/admin/bonuses", s.GetBonuses).Methods("GET")
+	adminRouter.HandleFunc("/admin/bonuses", s.UpdateBonus).Methods("PUT")
+	adminRouter.HandleFunc("/admin/bonuses/export", s.ExportBonuses).Methods("GET")
+	adminRouter.HandleFunc("/admin/eligibility-rules", s.GetEligibilityRules).Methods("GET")
+	adminRouter.HandleFunc("/admin/eligibility-rules", s.SaveEligibilityRule).Methods("PUT")
+	adminRouter.HandleFunc("/admin/employees", s.UpdateEmployee).Methods("PUT")
+	adminRouter.HandleFunc("/admin/recruiters", s.GetRecruiters).Methods("GET")
+	adminRouter.HandleFunc("/admin/recruiters", s.SetRecruiterPositions).Methods("PUT")
+	adminRouter.HandleFunc("/admin/assignment-rules", s.GetAssignmentRules).Methods("GET")
+	adminRouter.HandleFunc("/admin/assignment-rules", s.SaveAssignmentRule).Methods("PUT")
+	adminRouter.HandleFunc("/admin/webhooks", s.GetWebhooks).Methods("GET")
+	adminRouter.HandleFunc("/admin/webhooks", s.AddWebhook).Methods("POST")
+	adminRouter.HandleFunc("/admin/webhooks", s.UpdateWebhook).Methods("PUT")
+	adminRouter.HandleFunc("/admin/webhooks", s.DeleteWebhook).Methods("DELETE")
+	adminRouter.HandleFunc("/admin/webhooks/deliveries", s.GetDeliveries).Methods("GET")
+	adminRouter.HandleFunc("/admin/webhooks/deliveries/redeliver", s.Redeliver).Methods("POST")
+}
+
+// MountFiles serves and accepts uploads of files of local storage by signed URLs, signature is checked by the handler.
+func (s *Server) MountFiles(files http.Handler) {
+	s.Router.PathPrefix(storage.LocalPathPrefix).Handler(withDeadlines(files)).Methods("GET", "HEAD", "PUT")
 }
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"context"
+	"io"
+	"net"
 	"net/http"
 	"time"
 
@@ -10,6 +12,19 @@ import (
 	"github.com/gorilla/mux"
 )
 
+const (
+	// DefaultMaxCVSize presents default maximum size of uploaded CV, it's 10 MiB.
+	DefaultMaxCVSize = 10 << 20
+
+	// maxFormOverhead presents size of form fields and multipart boundaries besides CV.
+	maxFormOverhead = 1 << 20
+
+	readTimeout  = 10 * time.Second
+	writeTimeout = 10 * time.Second
+)
+
+type connKey struct{}
+
 // Server presents a type of main application server.
 type Server struct {
 	HTTPServer *http.Server
@@ -17,28 +32,99 @@ type Server struct {
 	Auth       service.Auth
 	Referral   service.Referral
 	Logger     *log.Logger
+
+	// LeaderboardEnabled enables leaderboard of referrers, it's disabled by default.
+	LeaderboardEnabled bool
+
+	// MaxCVSize presents maximum size of uploaded CV in bytes.
+	MaxCVSize int64
+
+	// StreamCVs enables streaming of CVs through the server instead of responding with links to object storage.
+	StreamCVs bool
 }
 
-// Run starts server.
+// Run starts server. Connection is kept in request context, so long-lived responses extend their write deadline.
 func (s *Server) Run(port string, handler http.Handler) error {
 	s.HTTPServer = &http.Server{
 		Addr:           ":" + port,
 		Handler:        handler,
 		MaxHeaderBytes: 1 << 20,
-		ReadTimeout:    10 * time.Second,
-		WriteTimeout:   10 * time.Second,
+		ReadTimeout:    readTimeout,
+		WriteTimeout:   writeTimeout,
+		ConnContext: func(ctx context.Context, c net.Conn) context.Context {
+			return context.WithValue(ctx, connKey{}, c)
+		},
 	}
 
 	return s.HTTPServer.ListenAndServe()
 }
 
+// extendWriteDeadline lets response be written within the timeout from now despite write timeout of the server.
+func extendWriteDeadline(r *http.Request, timeout time.Duration) error {
+	conn, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return nil
+	}
+
+	return conn.SetWriteDeadline(time.Now().Add(timeout))
+}
+
+// extendReadDeadline lets request body be read within the timeout from now despite read timeout of the server.
+func extendReadDeadline(r *http.Request, timeout time.Duration) error {
+	conn, ok := r.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return nil
+	}
+
+	return conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// deadlineWriter extends write deadline before every write, so a large response is sent
+// to a slow client as long as it keeps receiving.
+type deadlineWriter struct {
+	http.ResponseWriter
+	r *http.Request
+}
+
+func (w deadlineWriter) Write(p []byte) (int, error) {
+	if err := extendWriteDeadline(w.r, writeTimeout); err != nil {
+		return 0, err
+	}
+
+	return w.ResponseWriter.Write(p)
+}
+
+// deadlineReader extends read deadline before every read of request body, so a large body
+// is received from a slow client as long as it keeps sending.
+type deadlineReader struct {
+	io.ReadCloser
+	r *http.Request
+}
+
+func (b deadlineReader) Read(p []byte) (int, error) {
+	if err := extendReadDeadline(b.r, readTimeout); err != nil {
+		return 0, err
+	}
+
+	return b.ReadCloser.Read(p)
+}
+
+// withDeadlines extends read and write deadlines for every read of request body and write of response.
+func withDeadlines(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r.Body = deadlineReader{ReadCloser: r.Body, r: r}
+		h.ServeHTTP(deadlineWriter{ResponseWriter: rw, r: r}, r)
+	})
+}
+
 // NewServer creates a new instance of type Server.
 func NewServer(auth service.Auth, referral service.Referral, log *log.Logger) *Server {
 	s := &Server{
-		Router:   mux.NewRouter(),
-		Auth:     auth,
-		Referral: referral,
-		Logger:   log,
+		Router:    mux.NewRouter(),
+		Auth:      auth,
+		Referral:  referral,
+		Logger:    log,
+		MaxCVSize: DefaultMaxCVSize,
 	}
 
 	s.InitRoutes()
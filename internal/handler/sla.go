@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+)
+
+// GetOverdueRequests admin handler that outputs report of requests which exceed SLA of their status.
+func (s *Server) GetOverdueRequests(rw http.ResponseWriter, r *http.Request) {
+	requests, err := s.Referral.GetOverdueRequests(time.Now())
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, requests, http.StatusOK)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/context"
+)
+
+const (
+	fromParameter = "from"
+	toParameter   = "to"
+
+	week              = 7 * 24 * time.Hour
+	defaultStatsWeeks = 12
+	maxStatsPeriod    = 2 * 53 * week
+)
+
+// GetStats admin handler that returns statistics of requests created in a period.
+// Period is set by from and to parameters, by default last 12 weeks are used.
+func (s *Server) GetStats(rw http.ResponseWriter, r *http.Request) {
+	from, to, err := ValidateStatsRequest(r, time.Now())
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.Referral.GetStats(from, to)
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, stats, http.StatusOK)
+}
+
+// ValidateStatsRequest validates period of statistics request, missing bounds are set relative to now.
+func ValidateStatsRequest(r *http.Request, now time.Time) (time.Time, time.Time, error) {
+	from, to, err := parseDateRange(r.URL.Query(), fromParameter, toParameter)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if to.IsZero() {
+		to = now.UTC()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultStatsWeeks * week)
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s must be before %s", ErrInvalidParameter, fromParameter, toParameter)
+	}
+
+	if to.Sub(from) > maxStatsPeriod {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: period must be at most %d weeks", ErrInvalidParameter, maxStatsPeriod/week)
+	}
+
+	return from.UTC(), to.UTC(), nil
+}
+
+const (
+	limitParameter          = "limit"
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+	leaderboardDisabled     = "leaderboard is disabled"
+)
+
+// GetUserStats outputs statistics of user requests.
+func (s *Server) GetUserStats(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := s.Referral.GetUserStats(userID)
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, stats, http.StatusOK)
+}
+
+// GetLeaderboard outputs referrers ranked by number of accepted requests in a period.
+func (s *Server) GetLeaderboard(rw http.ResponseWriter, r *http.Request) {
+	if !s.LeaderboardEnabled {
+		sendResponse(rw, ErrorResponse{Message: leaderboardDisabled}, http.StatusNotFound)
+		return
+	}
+
+	from, to, err := ValidateStatsRequest(r, time.Now())
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultLeaderboardLimit
+	if value := r.URL.Query().Get(limitParameter); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLeaderboardLimit {
+			sendResponse(rw, ErrorResponse{Message: fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidParameter, maxLeaderboardLimit).Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	entries, err := s.Referral.GetLeaderboard(from, to, limit)
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, entries, http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/event"
+)
+
+const (
+	lastEventIDHeader    = "Last-Event-ID"
+	lastEventIDParameter = "last_event_id"
+
+	heartbeatInterval = 15 * time.Second
+	reconnectDelay    = 3 * time.Second
+
+	// resyncEvent tells client that events since its last one can't be replayed, so it should reload
+	// requests and resume from the id of the event.
+	resyncEvent = "resync"
+)
+
+// StreamEvents user handler that streams creation and status changes of user requests as Server-Sent Events.
+// Comment events aren't streamed, requests have no comments.
+func (s *Server) StreamEvents(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	s.streamEvents(rw, r, userID)
+}
+
+// StreamAllEvents admin handler that streams creation and status changes of all requests as Server-Sent Events.
+func (s *Server) StreamAllEvents(rw http.ResponseWriter, r *http.Request) {
+	s.streamEvents(rw, r, anyUserID)
+}
+
+// streamEvents streams events about requests of the author until client disconnects. Events recorded
+// after the one from Last-Event-ID header (or last_event_id parameter) are sent first, so clients
+// resume without gaps after reconnection. If too many events are missed, resync event is sent instead.
+// If client doesn't keep up, stream is closed to be resumed. Every write has to complete within
+// the server write timeout, which is extended after each of them.
+func (s *Server) streamEvents(rw http.ResponseWriter, r *http.Request, authorID string) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("streaming is not supported"))
+		sendResponse(rw, ErrorResponse{Message: "streaming is not supported"}, http.StatusInternalServerError)
+		return
+	}
+
+	lastEventID := r.Header.Get(lastEventIDHeader)
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get(lastEventIDParameter)
+	}
+	if lastEventID != "" {
+		if err := ValidateNumber(lastEventID); err != nil {
+			sendResponse(rw, ErrorResponse{Message: fmt.Errorf("%w: last event id has bad format", ErrInvalidParameter).Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Subscription is made before replay, so events published meanwhile aren't lost.
+	subscription := s.Referral.SubscribeEvents(authorID)
+	defer subscription.Close()
+
+	var replayed []event.Event
+	var truncated bool
+	if lastEventID != "" {
+		var err error
+
+		replayed, truncated, err = s.Referral.ReplayEvents(lastEventID, authorID)
+		if err != nil {
+			s.Logger.ErrorLogger.Println(err)
+			sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := extendWriteDeadline(r, writeTimeout); err != nil {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot extend write deadline: %w", err))
+		sendResponse(rw, ErrorResponse{Message: "streaming is not supported"}, http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(rw, "retry: %d\n\n", reconnectDelay.Milliseconds())
+
+	sent := make(map[string]bool, len(replayed))
+	for _, e := range replayed {
+		if !truncated {
+			writeEvent(rw, e)
+		}
+		sent[e.ID] = true
+	}
+	if truncated {
+		writeEvent(rw, event.Event{ID: replayed[len(replayed)-1].ID, Name: resyncEvent, Payload: []byte("{}")})
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-subscription.C:
+			if !ok {
+				return
+			}
+
+			if sent[e.ID] {
+				continue
+			}
+
+			if extendWriteDeadline(r, writeTimeout) != nil {
+				return
+			}
+			writeEvent(rw, e)
+			flusher.Flush()
+		case <-heartbeat.C:
+			if extendWriteDeadline(r, writeTimeout) != nil {
+				return
+			}
+			fmt.Fprint(rw, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes event in text/event-stream format, payload is a single line of JSON.
+func writeEvent(rw http.ResponseWriter, e event.Event) {
+	fmt.Fprintf(rw, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Name, e.Payload)
+}
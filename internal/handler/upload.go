@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+// CreateUploadRequest presents a request of upload slot for CV of the size and SHA-256 checksum in hex.
+type CreateUploadRequest struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// sha256Exp presents format of SHA-256 checksum in hex.
+var sha256Exp = regexp.MustCompile("^[0-9a-fA-F]{64}$")
+
+// SendUploadedCandidateRequest presents candidate info with ID of upload slot which CV is uploaded by.
+type SendUploadedCandidateRequest struct {
+	UploadID   string `json:"uploadId"`
+	Name       string `json:"candidateName"`
+	Surname    string `json:"candidateSurname"`
+	Position   string `json:"candidatePosition"`
+	Department string `json:"candidateDepartment"`
+}
+
+// CreateUpload creates upload slot with presigned request which uploads CV directly to object storage,
+// so large files don't pass through the application.
+func (s *Server) CreateUpload(rw http.ResponseWriter, r *http.Request) {
+	userID, ok := context.GetUserID(r.Context())
+	if !ok {
+		s.Logger.ErrorLogger.Println(fmt.Errorf("cannot get user id from context"))
+		sendResponse(rw, fmt.Errorf("cannot get user id from context"), http.StatusInternalServerError)
+		return
+	}
+
+	var request CreateUploadRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if request.Size <= 0 {
+		sendResponse(rw, ErrorResponse{Message: fmt.Errorf("%w: size must be positive", ErrInvalidParameter).Error()}, http.StatusBadRequest)
+		return
+	}
+	if request.Size > s.MaxCVSize {
+		err := fmt.Errorf("%w: file must be at most %d bytes", ErrInvalidParameter, s.MaxCVSize)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if !sha256Exp.MatchString(request.SHA256) {
+		sendResponse(rw, ErrorResponse{Message: fmt.Errorf("%w: sha256 must be SHA-256 checksum in hex", ErrInvalidParameter).Error()}, http.StatusBadRequest)
+		return
+	}
+
+	slot, err := s.Referral.CreateUpload(userID, request.Filename, request.Size, request.SHA256)
+	if errors.Is(err, service.ErrUnsupportedCV) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusUnsupportedMediaType)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, slot, http.StatusCreated)
+}
+
+// SendUploadedCandidate sends candidate info with CV uploaded by upload slot. The uploaded file
+// is verified to match size and type of the slot.
+func (s *Server) SendUploadedCandidate(rw http.ResponseWriter, r *http.Request) {
+	var body SendUploadedCandidateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	request := service.SubmitCandidateRequest{
+		CandidateName:    body.Name,
+		CandidateSurname: body.Surname,
+		Position:         strings.TrimSpace(body.Position),
+		Department:       strings.TrimSpace(body.Department),
+		UploadID:         body.UploadID,
+	}
+
+	if err := ValidateCandidateSendingRequest(request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if request.UploadID == "" {
+		sendResponse(rw, ErrorResponse{Message: fmt.Errorf("%w: upload id is required", ErrInvalidParameter).Error()}, http.StatusBadRequest)
+		return
+	}
+
+	id, err := s.Referral.AddUploadedCandidate(r.Context(), request)
+	if errors.Is(err, service.ErrNoUpload) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrUploadIncomplete) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusConflict)
+		return
+	}
+	if errors.Is(err, service.ErrUploadMismatch) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusUnprocessableEntity)
+		return
+	}
+	var eligibilityErr *service.EligibilityError
+	if errors.As(err, &eligibilityErr) {
+		sendResponse(rw, ErrorResponse{Message: err.Error(), Code: eligibilityErr.Code}, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, CandidateSendingResponse{CandidateID: id}, http.StatusOK)
+}
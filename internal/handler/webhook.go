@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/service"
+)
+
+const webhookParameter = "webhook"
+
+// RedeliverRequest type presents id of a delivery to send again.
+type RedeliverRequest struct {
+	ID string `json:"id"`
+}
+
+// GetWebhooks admin handler that outputs webhook subscriptions.
+func (s *Server) GetWebhooks(rw http.ResponseWriter, r *http.Request) {
+	webhooks, err := s.Referral.GetWebhooks()
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, webhooks, http.StatusOK)
+}
+
+// AddWebhook admin handler that subscribes an endpoint to events, the response contains webhook secret.
+func (s *Server) AddWebhook(rw http.ResponseWriter, r *http.Request) {
+	var request repository.Webhook
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	webhook, err := s.Referral.AddWebhook(request)
+	if errors.Is(err, service.ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, webhook, http.StatusCreated)
+}
+
+// UpdateWebhook admin handler that updates webhook subscription, secret is kept if it isn't set.
+func (s *Server) UpdateWebhook(rw http.ResponseWriter, r *http.Request) {
+	var request repository.Webhook
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := ValidateNumber(request.ID); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err := s.Referral.UpdateWebhook(request)
+	if errors.Is(err, service.ErrNoWebhook) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidParameter) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, UpdateResponse{Message: fmt.Sprintf("webhook with %s ID has been updated", request.ID)}, http.StatusOK)
+}
+
+// DeleteWebhook admin handler that deletes webhook subscription with its delivery log.
+func (s *Server) DeleteWebhook(rw http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get(idParameter)
+	if err := ValidateNumber(id); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err := s.Referral.DeleteWebhook(id)
+	if errors.Is(err, service.ErrNoWebhook) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries admin handler that outputs the latest webhook deliveries.
+func (s *Server) GetDeliveries(rw http.ResponseWriter, r *http.Request) {
+	filter, err := ValidateDeliveryFilter(r.URL.Query())
+	if err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := s.Referral.GetDeliveries(filter)
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, deliveries, http.StatusOK)
+}
+
+// Redeliver admin handler that queues a delivery to be sent again.
+func (s *Server) Redeliver(rw http.ResponseWriter, r *http.Request) {
+	var request RedeliverRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	if err := ValidateNumber(request.ID); err != nil {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err := s.Referral.Redeliver(request.ID, time.Now())
+	if errors.Is(err, service.ErrNoResult) {
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.Logger.ErrorLogger.Println(err)
+		sendResponse(rw, ErrorResponse{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+
+	sendResponse(rw, UpdateResponse{Message: fmt.Sprintf("delivery with %s ID has been queued", request.ID)}, http.StatusAccepted)
+}
+
+// ValidateDeliveryFilter validates query parameters of delivery log.
+func ValidateDeliveryFilter(query url.Values) (repository.DeliveryFilter, error) {
+	filter := repository.DeliveryFilter{
+		WebhookID: query.Get(webhookParameter),
+		Status:    query.Get(statusParameter),
+	}
+
+	if filter.WebhookID != "" {
+		if err := ValidateNumber(filter.WebhookID); err != nil {
+			return repository.DeliveryFilter{}, fmt.Errorf("%w: webhook id has bad format", ErrInvalidParameter)
+		}
+	}
+
+	switch filter.Status {
+	case "", repository.DeliveryPending, repository.DeliveryDelivered, repository.DeliveryFailed:
+	default:
+		return repository.DeliveryFilter{}, fmt.Errorf("%w: delivery status", ErrInvalidParameter)
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"fmt"
+)
+
+// Recipient presents contact data and notification settings of a user.
+type Recipient struct {
+	Email string
+	Muted []string
+}
+
+// RecipientFinder finds recipient of notifications by user id.
+type RecipientFinder interface {
+	GetRecipient(userID string) (Recipient, error)
+}
+
+// EmailNotifier sends notifications as emails rendered from templates of their categories.
+type EmailNotifier struct {
+	Mailer     Mailer
+	Recipients RecipientFinder
+	From       string
+}
+
+// Notify sends notification to user email. Nothing is sent if user has no email
+// or has opted out of the notification category.
+func (n *EmailNotifier) Notify(notification Notification) error {
+	recipient, err := n.Recipients.GetRecipient(notification.UserID)
+	if err != nil {
+		return fmt.Errorf("cannot get recipient: %w", err)
+	}
+
+	if recipient.Email == "" {
+		return nil
+	}
+
+	for _, category := range recipient.Muted {
+		if category == notification.Category {
+			return nil
+		}
+	}
+
+	subject, body, err := render(notification)
+	if err != nil {
+		return err
+	}
+
+	return n.Mailer.Send(Message{
+		From:    n.From,
+		To:      recipient.Email,
+		Subject: subject,
+		Body:    body,
+	})
+}
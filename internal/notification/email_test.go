@@ -0,0 +1,94 @@
+package notification
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recipientFinderFunc func(userID string) (Recipient, error)
+
+func (f recipientFinderFunc) GetRecipient(userID string) (Recipient, error) {
+	return f(userID)
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	data := map[string]string{"id": "10", "name": "Billie", "surname": "Jean", "status": "accepted"}
+
+	testTable := []struct {
+		testName         string
+		notification     Notification
+		recipient        Recipient
+		recipientError   error
+		expectedMessages []Message
+		isErrorExpected  bool
+	}{
+		{
+			testName:     "Success",
+			notification: Notification{UserID: "1", Category: CategoryStatusChanged, Data: data},
+			recipient:    Recipient{Email: "user@example.com", Muted: []string{CategorySLAReminder}},
+			expectedMessages: []Message{{
+				From:    "referral@example.com",
+				To:      "user@example.com",
+				Subject: "Referral of Billie Jean is accepted",
+				Body:    "Status of your referral #10 of Billie Jean has been changed to accepted.\n",
+			}},
+		},
+		{
+			testName:     "Success: category is muted",
+			notification: Notification{UserID: "1", Category: CategoryStatusChanged, Data: data},
+			recipient:    Recipient{Email: "user@example.com", Muted: []string{CategoryStatusChanged}},
+		},
+		{
+			testName:     "Success: user without email",
+			notification: Notification{UserID: "1", Category: CategoryStatusChanged, Data: data},
+		},
+		{
+			testName:        "Failure: unknown category",
+			notification:    Notification{UserID: "1", Category: "unknown", Data: data},
+			recipient:       Recipient{Email: "user@example.com"},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: cannot get recipient",
+			notification:    Notification{UserID: "1", Category: CategoryStatusChanged, Data: data},
+			recipientError:  errors.New("connection refused"),
+			isErrorExpected: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			mailer := &MemoryMailer{}
+			notifier := &EmailNotifier{
+				Mailer: mailer,
+				Recipients: recipientFinderFunc(func(userID string) (Recipient, error) {
+					assert.Equal(t, tc.notification.UserID, userID)
+					return tc.recipient, tc.recipientError
+				}),
+				From: "referral@example.com",
+			}
+
+			err := notifier.Notify(tc.notification)
+			if tc.isErrorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedMessages, mailer.Messages())
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	for _, category := range Categories {
+		t.Run(category, func(t *testing.T) {
+			subject, body, err := render(Notification{Category: category, Data: map[string]string{"name": "Billie", "surname": "Jean"}})
+			assert.NoError(t, err)
+			assert.Contains(t, subject, "Billie Jean")
+			assert.NotEmpty(t, body)
+			assert.NotContains(t, subject+body, "<no value>")
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+const (
+	// DriverSMTP presents mailer which sends emails via SMTP server.
+	DriverSMTP = "smtp"
+
+	// DriverFile presents mailer which writes emails to files in a directory.
+	DriverFile = "file"
+
+	// DriverLog presents absence of mailer, notifications are written to log.
+	DriverLog = "log"
+
+	fileMode = 0644
+	dirMode  = 0755
+)
+
+type mailConfig struct {
+	Driver   string `envconfig:"MAIL_DRIVER" default:"log"`
+	From     string `envconfig:"MAIL_FROM"`
+	Dir      string `envconfig:"MAIL_DIR" default:"mail"`
+	Host     string `envconfig:"MAIL_SMTP_HOST"`
+	Port     string `envconfig:"MAIL_SMTP_PORT" default:"587"`
+	Username string `envconfig:"MAIL_SMTP_USERNAME"`
+	Password string `envconfig:"MAIL_SMTP_PASSWORD"`
+}
+
+// Message presents an email.
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	Send(m Message) error
+}
+
+// NewNotifier creates notifier configured by MAIL_* environment variables. Emails are sent
+// via SMTP server or written to files, notifications are written to logger with log driver.
+func NewNotifier(recipients RecipientFinder, logger *log.Logger) (Notifier, error) {
+	var cfg mailConfig
+
+	if err := envconfig.Process("mail", &cfg); err != nil {
+		return nil, fmt.Errorf("error with config loading: %w", err)
+	}
+
+	var mailer Mailer
+
+	switch cfg.Driver {
+	case DriverSMTP:
+		var auth smtp.Auth
+		if cfg.Username != "" {
+			auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+		}
+
+		mailer = &SMTPMailer{Addr: net.JoinHostPort(cfg.Host, cfg.Port), Auth: auth}
+	case DriverFile:
+		mailer = &FileMailer{Dir: cfg.Dir}
+	case DriverLog:
+		return LogNotifier{Logger: logger}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+
+	return &EmailNotifier{Mailer: mailer, Recipients: recipients, From: cfg.From}, nil
+}
+
+// SMTPMailer sends emails via SMTP server.
+type SMTPMailer struct {
+	Addr string
+	Auth smtp.Auth
+}
+
+// Send sends email via SMTP server.
+func (m *SMTPMailer) Send(message Message) error {
+	if err := smtp.SendMail(m.Addr, m.Auth, message.From, []string{message.To}, format(message)); err != nil {
+		return fmt.Errorf("cannot send email: %w", err)
+	}
+
+	return nil
+}
+
+// FileMailer writes every email to a separate .eml file in the directory, it's intended for local development.
+type FileMailer struct {
+	Dir string
+
+	mu sync.Mutex
+	n  int
+}
+
+// Send writes email to file.
+func (m *FileMailer) Send(message Message) error {
+	m.mu.Lock()
+	m.n++
+	name := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102T150405.000000000"), m.n)
+	m.mu.Unlock()
+
+	if err := os.MkdirAll(m.Dir, dirMode); err != nil {
+		return fmt.Errorf("cannot create mail directory: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(m.Dir, name), format(message), fileMode); err != nil {
+		return fmt.Errorf("cannot write email: %w", err)
+	}
+
+	return nil
+}
+
+// MemoryMailer keeps sent emails in memory, it's intended for tests.
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// Send saves email.
+func (m *MemoryMailer) Send(message Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, message)
+
+	return nil
+}
+
+// Messages returns all sent emails.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// format formats email as RFC 5322 message.
+func format(m Message) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "From: %s\r\n", m.From)
+	fmt.Fprintf(&b, "To: %s\r\n", m.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+
+	return b.Bytes()
+}
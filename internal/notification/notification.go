@@ -0,0 +1,59 @@
+// Package notification delivers notifications about referral events to users.
+package notification
+
+import (
+	"log"
+)
+
+const (
+	// CategorySubmissionReceipt presents confirmations of submitted referrals.
+	CategorySubmissionReceipt = "submission_receipt"
+
+	// CategoryStatusChanged presents notifications about changed status of referrals.
+	CategoryStatusChanged = "status_changed"
+
+	// CategorySLAReminder presents reminders about requests which exceed their SLA.
+	CategorySLAReminder = "sla_reminder"
+)
+
+// Categories presents all categories of notifications, users can opt out of any of them.
+var Categories = []string{
+	CategorySubmissionReceipt,
+	CategoryStatusChanged,
+	CategorySLAReminder,
+}
+
+// Notification presents an event a user is notified about, data is rendered with the template of category.
+type Notification struct {
+	UserID   string
+	Category string
+	Data     map[string]string
+}
+
+// Notifier delivers notifications to users.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// LogNotifier writes notifications to log, it's used when there is no delivery channel.
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+// Notify writes notification to log.
+func (n LogNotifier) Notify(notification Notification) error {
+	n.Logger.Printf("notification to user %s: %s %v", notification.UserID, notification.Category, notification.Data)
+
+	return nil
+}
+
+// IsCategory checks if there is a category with the name.
+func IsCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// emailTemplate presents templates of subject and body of an email.
+type emailTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+var templates = map[string]emailTemplate{
+	CategorySubmissionReceipt: newEmailTemplate(
+		`Referral of {{.name}} {{.surname}} is received`,
+		`Thank you for referring {{.name}} {{.surname}}{{with .position}} to the {{.}} position{{end}}.
+
+Your referral #{{.id}} has been submitted and will be reviewed by a recruiter.
+`),
+	CategoryStatusChanged: newEmailTemplate(
+		`Referral of {{.name}} {{.surname}} is {{.status}}`,
+		`Status of your referral #{{.id}} of {{.name}} {{.surname}} has been changed to {{.status}}.
+`),
+	CategorySLAReminder: newEmailTemplate(
+		`Referral of {{.name}} {{.surname}} is overdue`,
+		`Referral #{{.id}} of {{.name}} {{.surname}} has been {{.status}} since {{.since}}, please review it.
+`),
+}
+
+func newEmailTemplate(subject, body string) emailTemplate {
+	return emailTemplate{
+		subject: template.Must(template.New("subject").Option("missingkey=zero").Parse(subject)),
+		body:    template.Must(template.New("body").Option("missingkey=zero").Parse(body)),
+	}
+}
+
+// render renders subject and body of notification.
+func render(n Notification) (string, string, error) {
+	t, ok := templates[n.Category]
+	if !ok {
+		return "", "", fmt.Errorf("there is no template of %s notification", n.Category)
+	}
+
+	var subject, body bytes.Buffer
+
+	if err := t.subject.Execute(&subject, n.Data); err != nil {
+		return "", "", fmt.Errorf("cannot render subject: %w", err)
+	}
+
+	if err := t.body.Execute(&body, n.Data); err != nil {
+		return "", "", fmt.Errorf("cannot render body: %w", err)
+	}
+
+	return subject.String(), body.String(), nil
+}
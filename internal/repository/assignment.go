@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/lib/pq"
+)
+
+const (
+	// AssignmentManual presents a strategy which leaves new requests unassigned.
+	AssignmentManual = "manual"
+
+	// AssignmentRoundRobin presents a strategy which assigns new request to the recruiter
+	// who has been waiting for a request of the same position for the longest time.
+	AssignmentRoundRobin = "round_robin"
+
+	// AssignmentLeastLoaded presents a strategy which assigns new request to the recruiter
+	// with the smallest number of open requests.
+	AssignmentLeastLoaded = "least_loaded"
+
+	// AnyPosition presents position of recruiters and assignment rules applied to every position.
+	AnyPosition = ""
+)
+
+// ErrNoRecruiter presents an error when request is assigned to a user who isn't a recruiter.
+var ErrNoRecruiter = errors.New("assignee must be an admin")
+
+// AssignmentRule presents strategy of automatic assignment of requests for a position.
+type AssignmentRule struct {
+	Position string `json:"position"`
+	Strategy string `json:"strategy"`
+}
+
+// Recruiter presents an admin who reviews requests.
+type Recruiter struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Positions []string `json:"positions"`
+	Open      int      `json:"open"`
+}
+
+// GetAssignmentRules returns all assignment rules.
+func (r *Repository) GetAssignmentRules() ([]AssignmentRule, error) {
+	query := `SELECT
+				position, strategy
+			  FROM
+			  	assignment_rules
+			  ORDER BY
+			  	position;`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get assignment rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []AssignmentRule{}
+	for rows.Next() {
+		var rule AssignmentRule
+
+		if err := rows.Scan(&rule.Position, &rule.Strategy); err != nil {
+			return nil, fmt.Errorf("cannot get assignment rule: %w", err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SaveAssignmentRule creates or replaces assignment rule of the position.
+func (r *Repository) SaveAssignmentRule(rule AssignmentRule) error {
+	query := `INSERT INTO
+				assignment_rules (position, strategy)
+			  VALUES
+			  	($1, $2)
+			  ON CONFLICT (position) DO UPDATE SET
+			  	strategy = EXCLUDED.strategy,
+			  	updated = CURRENT_TIMESTAMP;`
+
+	if _, err := r.db.Exec(query, rule.Position, rule.Strategy); err != nil {
+		return fmt.Errorf("cannot save assignment rule: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecruiters returns all admins with positions they review and number of their open requests.
+func (r *Repository) GetRecruiters() ([]Recruiter, error) {
+	query := `SELECT
+				users.id,
+				users.name,
+				ARRAY(SELECT position FROM recruiters WHERE user_id = users.id ORDER BY position),
+				(SELECT COUNT(*) FROM requests WHERE assignee_id = users.id AND status = $1)
+			  FROM
+			  	users
+			  WHERE
+			  	users.is_admin
+			  ORDER BY
+			  	users.id;`
+
+	rows, err := r.db.Query(query, StatusSubmitted)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get recruiters: %w", err)
+	}
+	defer rows.Close()
+
+	recruiters := []Recruiter{}
+	for rows.Next() {
+		var recruiter Recruiter
+
+		if err := rows.Scan(&recruiter.ID, &recruiter.Name, pq.Array(&recruiter.Positions), &recruiter.Open); err != nil {
+			return nil, fmt.Errorf("cannot get recruiter: %w", err)
+		}
+
+		recruiters = append(recruiters, recruiter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return recruiters, nil
+}
+
+// SetRecruiterPositions replaces positions which requests are automatically assigned to the recruiter for.
+func (r *Repository) SetRecruiterPositions(userID string, positions []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkRecruiter(tx, userID); err != nil {
+		return err
+	}
+
+	query := `DELETE FROM
+				recruiters
+			  WHERE
+			  	user_id = $1;`
+
+	if _, err := tx.Exec(query, userID); err != nil {
+		return fmt.Errorf("cannot delete recruiter positions: %w", err)
+	}
+
+	query = `INSERT INTO
+				recruiters (user_id, position)
+			 SELECT
+			 	$1, UNNEST($2::VARCHAR[])
+			 ON CONFLICT DO NOTHING;`
+
+	if _, err := tx.Exec(query, userID, pq.Array(positions)); err != nil {
+		return fmt.Errorf("cannot add recruiter positions: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// AssignRequest assigns request to the recruiter and records the event in the outbox,
+// empty assignee makes request unassigned.
+func (r *Repository) AssignRequest(id, assigneeID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var assignee sql.NullString
+	if assigneeID != "" {
+		if err := checkRecruiter(tx, assigneeID); err != nil {
+			return err
+		}
+
+		assignee = sql.NullString{String: assigneeID, Valid: true}
+	}
+
+	query := `UPDATE
+				requests
+			  SET
+			  	assignee_id = $1, assigned = CASE WHEN $1::INTEGER IS NULL THEN NULL ELSE CURRENT_TIMESTAMP END
+			  WHERE
+			  	id = $2;`
+
+	rows, err := tx.Exec(query, assignee, id)
+	if err != nil {
+		return fmt.Errorf("cannot assign request: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoResult
+	}
+
+	if err := addRequestEvents(tx, event.ReferralAssigned, []string{id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// checkRecruiter checks that user exists and is an admin.
+func checkRecruiter(tx *sql.Tx, userID string) error {
+	var isAdmin bool
+
+	query := `SELECT
+				is_admin
+			  FROM
+			  	users
+			  WHERE
+			  	id = $1;`
+
+	err := tx.QueryRow(query, userID).Scan(&isAdmin)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoUser
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get recruiter: %w", err)
+	}
+
+	if !isAdmin {
+		return ErrNoRecruiter
+	}
+
+	return nil
+}
+
+// autoAssignRequest assigns request according to the assignment rule of its position (or the default rule)
+// to one of recruiters who review the position (or any position). Request is left unassigned
+// if there is no rule, the rule is manual or there are no recruiters. Only recruiters who are still admins are picked.
+// Recruiters of the pool are locked until the end of transaction, so concurrent picks see each other's
+// assignments, and assignment time is taken from the clock, so requests assigned in one transaction are ordered.
+func autoAssignRequest(tx *sql.Tx, id string) error {
+	lock := `SELECT
+				recruiters.user_id
+			 FROM
+			 	recruiters, requests
+			 WHERE
+			 	requests.id = $1 AND recruiters.position IN (requests.position, $2)
+			 ORDER BY
+			 	recruiters.user_id, recruiters.position
+			 FOR UPDATE OF recruiters;`
+
+	if _, err := tx.Exec(lock, id, AnyPosition); err != nil {
+		return fmt.Errorf("cannot lock recruiters: %w", err)
+	}
+
+	query := `WITH target AS (
+				SELECT
+					id, position
+				FROM
+					requests
+				WHERE
+					id = $1
+			  ), rule AS (
+			  	SELECT
+			  		assignment_rules.strategy
+			  	FROM
+			  		assignment_rules, target
+			  	WHERE
+			  		assignment_rules.position IN (target.position, $2)
+			  	ORDER BY
+			  		assignment_rules.position = $2
+			  	LIMIT 1
+			  ), pool AS (
+			  	SELECT DISTINCT
+			  		recruiters.user_id
+			  	FROM
+			  		recruiters, users, target
+			  	WHERE
+			  		recruiters.position IN (target.position, $2) AND users.id = recruiters.user_id AND users.is_admin = TRUE
+			  ), picked AS (
+			  	SELECT
+			  		pool.user_id
+			  	FROM
+			  		pool, rule, target
+			  	WHERE
+			  		rule.strategy <> $3
+			  	ORDER BY
+			  		CASE WHEN rule.strategy = $4 THEN
+			  			(SELECT COUNT(*) FROM requests WHERE assignee_id = pool.user_id AND status = $5)
+			  		END,
+			  		(SELECT MAX(assigned) FROM requests WHERE assignee_id = pool.user_id AND position = target.position) NULLS FIRST,
+			  		pool.user_id
+			  	LIMIT 1
+			  )
+			  UPDATE
+			  	requests
+			  SET
+			  	assignee_id = picked.user_id, assigned = clock_timestamp()
+			  FROM
+			  	picked
+			  WHERE
+			  	requests.id = $1;`
+
+	if _, err := tx.Exec(query, id, AnyPosition, AssignmentManual, AssignmentLeastLoaded, StatusSubmitted); err != nil {
+		return fmt.Errorf("cannot assign request automatically: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/lib/pq"
+)
+
+const (
+	// BonusPending presents status of a bonus created for a hired candidate.
+	BonusPending = "pending"
+
+	// BonusApproved presents status of a bonus approved for payout.
+	BonusApproved = "approved"
+
+	// BonusPaid presents status of a paid bonus.
+	BonusPaid = "paid"
+
+	// BonusCancelled presents status of a bonus which won't be paid.
+	BonusCancelled = "cancelled"
+
+	// DefaultBonusPosition presents position of a bonus rule which is applied to positions without own rule.
+	DefaultBonusPosition = ""
+)
+
+// ErrNoBonusRule presents an error when there is no bonus rule for a position.
+var ErrNoBonusRule = errors.New("there is no bonus rule for the position")
+
+// BonusRule presents bonus paid for a hired candidate on a position.
+type BonusRule struct {
+	Position      string `json:"position"`
+	Amount        string `json:"amount"`
+	Currency      string `json:"currency"`
+	ProbationDays int    `json:"probationDays"`
+}
+
+// Bonus presents an entry of payout ledger.
+type Bonus struct {
+	ID               string     `json:"id"`
+	RequestID        string     `json:"requestId"`
+	UserID           string     `json:"userId"`
+	UserName         string     `json:"userName"`
+	CandidateName    string     `json:"candidateName"`
+	CandidateSurname string     `json:"candidateSurname"`
+	Position         string     `json:"position"`
+	Amount           string     `json:"amount"`
+	Currency         string     `json:"currency"`
+	Status           string     `json:"status"`
+	EligibleAt       time.Time  `json:"eligibleAt"`
+	ApprovedAt       *time.Time `json:"approvedAt"`
+	PaidAt           *time.Time `json:"paidAt"`
+	Created          time.Time  `json:"created"`
+}
+
+// BonusFilter presents filter of payout ledger entries.
+type BonusFilter struct {
+	UserID   string
+	Statuses []string
+}
+
+// GetBonusRules returns all bonus rules.
+func (r *Repository) GetBonusRules() ([]BonusRule, error) {
+	query := `SELECT
+				position, amount, currency, probation_days
+			  FROM
+			  	bonus_rules
+			  ORDER BY
+			  	position;`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get bonus rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []BonusRule{}
+	for rows.Next() {
+		var rule BonusRule
+
+		if err := rows.Scan(&rule.Position, &rule.Amount, &rule.Currency, &rule.ProbationDays); err != nil {
+			return nil, fmt.Errorf("cannot get bonus rule: %w", err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SaveBonusRule creates bonus rule for the position or replaces the existing one.
+func (r *Repository) SaveBonusRule(rule BonusRule) error {
+	query := `INSERT INTO
+				bonus_rules (position, amount, currency, probation_days)
+			  VALUES
+			  	($1, $2, $3, $4)
+			  ON CONFLICT (position) DO UPDATE SET
+			  	amount = EXCLUDED.amount,
+			  	currency = EXCLUDED.currency,
+			  	probation_days = EXCLUDED.probation_days,
+			  	updated = CURRENT_TIMESTAMP;`
+
+	_, err := r.db.Exec(query, rule.Position, rule.Amount, rule.Currency, rule.ProbationDays)
+	if err != nil {
+		return fmt.Errorf("cannot save bonus rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBonusRule deletes bonus rule of the position, already created bonuses are kept.
+func (r *Repository) DeleteBonusRule(position string) error {
+	query := `DELETE FROM
+				bonus_rules
+			  WHERE
+			  	position = $1;`
+
+	rows, err := r.db.Exec(query, position)
+	if err != nil {
+		return fmt.Errorf("cannot delete bonus rule: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoBonusRule
+	}
+
+	return nil
+}
+
+// syncBonuses keeps bonuses of requests in line with their new status: a pending bonus is created
+// (or a cancelled one is restored) when request is accepted, unpaid bonuses are cancelled otherwise.
+// Amount is taken from the rule of request position or from the default rule,
+// requests which were marked as not eligible for bonus on submission are skipped.
+func syncBonuses(tx *sql.Tx, ids []string, status string) error {
+	if status != StatusAccepted {
+		query := `UPDATE
+					bonuses
+				  SET
+				  	status = $1, updated = CURRENT_TIMESTAMP
+				  WHERE
+				  	request_id = ANY($2) AND status IN ($3, $4);`
+
+		if _, err := tx.Exec(query, BonusCancelled, pq.Array(ids), BonusPending, BonusApproved); err != nil {
+			return fmt.Errorf("cannot cancel bonuses: %w", err)
+		}
+
+		return nil
+	}
+
+	query := `INSERT INTO
+				bonuses (request_id, user_id, amount, currency, eligible_at)
+			  SELECT
+			  	requests.id, requests.author_id, rules.amount, rules.currency,
+			  	CURRENT_TIMESTAMP + rules.probation_days * INTERVAL '1 day'
+			  FROM
+			  	requests
+			  JOIN LATERAL (
+			  	SELECT
+			  		amount, currency, probation_days
+			  	FROM
+			  		bonus_rules
+			  	WHERE
+			  		position IN (requests.position, $2)
+			  	ORDER BY
+			  		position = $2
+			  	LIMIT 1
+			  ) rules ON TRUE
+			  WHERE
+			  	requests.id = ANY($1) AND requests.bonus_eligible
+			  ON CONFLICT (request_id) DO UPDATE SET
+			  	status = $3,
+			  	amount = EXCLUDED.amount,
+			  	currency = EXCLUDED.currency,
+			  	eligible_at = EXCLUDED.eligible_at,
+			  	updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	bonuses.status = $4;`
+
+	if _, err := tx.Exec(query, pq.Array(ids), DefaultBonusPosition, BonusPending, BonusCancelled); err != nil {
+		return fmt.Errorf("cannot create bonuses: %w", err)
+	}
+
+	return nil
+}
+
+// query returns query of ledger entries which match the filter.
+func (f BonusFilter) query() sq.SelectBuilder {
+	query := psql.Select(
+		"bonuses.id", "bonuses.request_id", "bonuses.user_id", "users.name",
+		"requests.candidate_name", "requests.candidate_surname", "requests.position",
+		"bonuses.amount", "bonuses.currency", "bonuses.status",
+		"bonuses.eligible_at", "bonuses.approved_at", "bonuses.paid_at", "bonuses.created",
+	).
+		From("bonuses").
+		Join("users ON users.id = bonuses.user_id").
+		Join("requests ON requests.id = bonuses.request_id").
+		OrderBy("bonuses.created", "bonuses.id")
+
+	if f.UserID != "" {
+		query = query.Where(sq.Eq{"bonuses.user_id": f.UserID})
+	}
+	if len(f.Statuses) != 0 {
+		query = query.Where(sq.Eq{"bonuses.status": f.Statuses})
+	}
+
+	return query
+}
+
+// ExportBonuses calls fn for every ledger entry which matches the filter without loading all of them into memory.
+func (r *Repository) ExportBonuses(filter BonusFilter, fn func(Bonus) error) error {
+	query, args, err := filter.query().ToSql()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("cannot get bonuses: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		bonus, err := scanBonus(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(bonus); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error with result set: %w", err)
+	}
+
+	return nil
+}
+
+// GetBonuses returns ledger entries which match the filter.
+func (r *Repository) GetBonuses(filter BonusFilter) ([]Bonus, error) {
+	bonuses := []Bonus{}
+
+	err := r.ExportBonuses(filter, func(bonus Bonus) error {
+		bonuses = append(bonuses, bonus)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bonuses, nil
+}
+
+// GetBonus returns ledger entry by its id.
+func (r *Repository) GetBonus(id string) (Bonus, error) {
+	query, args, err := BonusFilter{}.query().Where(sq.Eq{"bonuses.id": id}).ToSql()
+	if err != nil {
+		return Bonus{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	bonus, err := scanBonus(r.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Bonus{}, ErrNoResult
+	}
+
+	return bonus, err
+}
+
+// UpdateBonusStatus changes status of the bonus if it still has the expected one and records the event
+// in the outbox, time of approval or payout is recorded. ErrNoResult is returned if status was changed concurrently.
+func (r *Repository) UpdateBonusStatus(id, from, to string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE
+				bonuses
+			  SET
+			  	status = $1,
+			  	approved_at = CASE WHEN $1 = $4 THEN CURRENT_TIMESTAMP ELSE approved_at END,
+			  	paid_at = CASE WHEN $1 = $5 THEN CURRENT_TIMESTAMP ELSE paid_at END,
+			  	updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $2 AND status = $3;`
+
+	rows, err := tx.Exec(query, to, id, from, BonusApproved, BonusPaid)
+	if err != nil {
+		return fmt.Errorf("cannot update bonus: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoResult
+	}
+
+	selectQuery, args, err := BonusFilter{}.query().Where(sq.Eq{"bonuses.id": id}).ToSql()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	bonus, err := scanBonus(tx.QueryRow(selectQuery, args...))
+	if err != nil {
+		return err
+	}
+
+	if err := addEvent(tx, event.BonusStatusChanged, bonus); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// rowScanner presents a single row of query result.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBonus(row rowScanner) (Bonus, error) {
+	var bonus Bonus
+	var approvedAt, paidAt sql.NullTime
+
+	err := row.Scan(
+		&bonus.ID, &bonus.RequestID, &bonus.UserID, &bonus.UserName,
+		&bonus.CandidateName, &bonus.CandidateSurname, &bonus.Position,
+		&bonus.Amount, &bonus.Currency, &bonus.Status,
+		&bonus.EligibleAt, &approvedAt, &paidAt, &bonus.Created,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Bonus{}, err
+	}
+	if err != nil {
+		return Bonus{}, fmt.Errorf("cannot get bonus: %w", err)
+	}
+
+	if approvedAt.Valid {
+		bonus.ApprovedAt = &approvedAt.Time
+	}
+	if paidAt.Valid {
+		bonus.PaidAt = &paidAt.Time
+	}
+
+	return bonus, nil
+}
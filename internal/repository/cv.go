@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	// DownloadLink presents download of CV by a signed link of object storage.
+	DownloadLink = "link"
+
+	// DownloadStream presents download of CV streamed through the API.
+	DownloadStream = "stream"
+)
+
+// CV presents CV of a candidate.
+type CV struct {
+	FileID           string
+	ContentType      string
+	CandidateName    string
+	CandidateSurname string
+}
+
+// CVDownload presents an audit record of CV download.
+type CVDownload struct {
+	RequestID  string
+	FileID     string
+	UserID     string
+	Mode       string
+	Range      string
+	RemoteAddr string
+}
+
+// GetCV returns CV of the request, only requests of the user are searched if user id is set.
+func (r *Repository) GetCV(requestID, userID string) (CV, error) {
+	var cv CV
+
+	query := `SELECT
+				cv_file_id, cv_content_type, candidate_name, candidate_surname
+			  FROM
+			  	requests
+			  WHERE
+			  	id = $1 AND ` + scannedCondition
+
+	whereVal := []interface{}{
+		requestID,
+	}
+
+	if userID != "" {
+		query = fmt.Sprintf("%s AND author_id = $2", query)
+		whereVal = append(whereVal, userID)
+	}
+
+	err := r.db.QueryRow(query, whereVal...).Scan(&cv.FileID, &cv.ContentType, &cv.CandidateName, &cv.CandidateSurname)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CV{}, ErrNoFile
+	}
+	if err != nil {
+		return CV{}, fmt.Errorf("cannot get cv from database: %w", err)
+	}
+
+	return cv, nil
+}
+
+// AddCVDownload records download of CV.
+func (r *Repository) AddCVDownload(download CVDownload) error {
+	query := `INSERT INTO
+				cv_downloads (request_id, file_id, user_id, mode, byte_range, remote_addr)
+			  VALUES
+			  	($1, NULLIF($2, '')::INTEGER, $3, $4, $5, $6)`
+
+	_, err := r.db.Exec(query, download.RequestID, download.FileID, download.UserID, download.Mode, download.Range, download.RemoteAddr)
+	if err != nil {
+		return fmt.Errorf("cannot add cv download: %w", err)
+	}
+
+	return nil
+}
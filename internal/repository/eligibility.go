@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// EligibilityReject presents an action which rejects referral violating the rule.
+	EligibilityReject = "reject"
+
+	// EligibilityNoBonus presents an action which accepts referral violating the rule, but without bonus.
+	EligibilityNoBonus = "no_bonus"
+)
+
+// ErrDuplicateCandidate presents an error when candidate has been referred since the time of duplicate check.
+var ErrDuplicateCandidate = errors.New("candidate has already been referred")
+
+// referralSinceQuery checks if the candidate was referred since the time, case is ignored.
+const referralSinceQuery = `SELECT EXISTS (
+				SELECT
+					1
+				FROM
+					requests
+				WHERE
+					LOWER(candidate_name) = LOWER($1) AND LOWER(candidate_surname) = LOWER($2) AND created >= $3
+			  );`
+
+// EligibilityRule presents settings of a referral eligibility rule.
+type EligibilityRule struct {
+	Code    string `json:"code"`
+	Enabled bool   `json:"enabled"`
+	Action  string `json:"action"`
+	Value   int    `json:"value"`
+}
+
+// Employee presents employment data of user.
+type Employee struct {
+	UserID    string     `json:"userId"`
+	FirstName string     `json:"firstName"`
+	LastName  string     `json:"lastName"`
+	Hired     *time.Time `json:"hired"`
+}
+
+// DuplicateCheck presents duplicate candidate rule which is checked in the transaction adding request,
+// so concurrent referrals of the same candidate can't both pass it.
+type DuplicateCheck struct {
+	Since  time.Time
+	Action string
+}
+
+// GetEligibilityRules returns settings of all eligibility rules.
+func (r *Repository) GetEligibilityRules() ([]EligibilityRule, error) {
+	query := `SELECT
+				code, enabled, action, value
+			  FROM
+			  	eligibility_rules
+			  ORDER BY
+			  	code;`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get eligibility rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []EligibilityRule{}
+	for rows.Next() {
+		var rule EligibilityRule
+
+		if err := rows.Scan(&rule.Code, &rule.Enabled, &rule.Action, &rule.Value); err != nil {
+			return nil, fmt.Errorf("cannot get eligibility rule: %w", err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SaveEligibilityRule creates or replaces settings of eligibility rule.
+func (r *Repository) SaveEligibilityRule(rule EligibilityRule) error {
+	query := `INSERT INTO
+				eligibility_rules (code, enabled, action, value)
+			  VALUES
+			  	($1, $2, $3, $4)
+			  ON CONFLICT (code) DO UPDATE SET
+			  	enabled = EXCLUDED.enabled,
+			  	action = EXCLUDED.action,
+			  	value = EXCLUDED.value,
+			  	updated = CURRENT_TIMESTAMP;`
+
+	_, err := r.db.Exec(query, rule.Code, rule.Enabled, rule.Action, rule.Value)
+	if err != nil {
+		return fmt.Errorf("cannot save eligibility rule: %w", err)
+	}
+
+	return nil
+}
+
+// GetEmployee returns employment data of user.
+func (r *Repository) GetEmployee(userID string) (Employee, error) {
+	employee := Employee{UserID: userID}
+	var hired sql.NullTime
+
+	query := `SELECT
+				first_name, last_name, hired
+			  FROM
+			  	users
+			  WHERE
+			  	id = $1;`
+
+	err := r.db.QueryRow(query, userID).Scan(&employee.FirstName, &employee.LastName, &hired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Employee{}, ErrNoUser
+	}
+	if err != nil {
+		return Employee{}, fmt.Errorf("cannot get employee: %w", err)
+	}
+
+	if hired.Valid {
+		employee.Hired = &hired.Time
+	}
+
+	return employee, nil
+}
+
+// UpdateEmployee updates employment data of user.
+func (r *Repository) UpdateEmployee(employee Employee) error {
+	query := `UPDATE
+				users
+			  SET
+			  	first_name = $1, last_name = $2, hired = $3, updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $4;`
+
+	rows, err := r.db.Exec(query, employee.FirstName, employee.LastName, employee.Hired, employee.UserID)
+	if err != nil {
+		return fmt.Errorf("cannot update employee: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
+
+// IsEmployee checks if there is an employee with the name and surname, case is ignored.
+func (r *Repository) IsEmployee(name, surname string) (bool, error) {
+	var exists bool
+
+	query := `SELECT EXISTS (
+				SELECT
+					1
+				FROM
+					users
+				WHERE
+					LOWER(first_name) = LOWER($1) AND LOWER(last_name) = LOWER($2)
+			  );`
+
+	if err := r.db.QueryRow(query, name, surname).Scan(&exists); err != nil {
+		return false, fmt.Errorf("cannot check employee: %w", err)
+	}
+
+	return exists, nil
+}
+
+// HasReferralSince checks if the candidate was referred by anyone since the time, case is ignored.
+func (r *Repository) HasReferralSince(name, surname string, since time.Time) (bool, error) {
+	var exists bool
+
+	if err := r.db.QueryRow(referralSinceQuery, name, surname, since).Scan(&exists); err != nil {
+		return false, fmt.Errorf("cannot check previous referrals: %w", err)
+	}
+
+	return exists, nil
+}
+
+// checkDuplicate locks the candidate until the end of transaction, so referrals of the same candidate
+// are added one at a time, and applies duplicate check to it. ErrDuplicateCandidate is returned if
+// the candidate must be rejected, otherwise the result tells whether referral is still eligible for bonus.
+func checkDuplicate(tx *sql.Tx, name, surname string, check DuplicateCheck) (bool, error) {
+	query := `SELECT pg_advisory_xact_lock(hashtext(LOWER($1) || ' ' || LOWER($2)));`
+
+	if _, err := tx.Exec(query, name, surname); err != nil {
+		return false, fmt.Errorf("cannot lock candidate: %w", err)
+	}
+
+	var exists bool
+	if err := tx.QueryRow(referralSinceQuery, name, surname, check.Since).Scan(&exists); err != nil {
+		return false, fmt.Errorf("cannot check previous referrals: %w", err)
+	}
+
+	if !exists {
+		return true, nil
+	}
+
+	if check.Action == EligibilityReject {
+		return false, ErrDuplicateCandidate
+	}
+
+	return false, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// PendingExtraction presents CV of a request which text isn't extracted yet.
+type PendingExtraction struct {
+	RequestID   string
+	FileID      string
+	ContentType string
+}
+
+// GetPendingExtractions returns scanned CVs which text isn't extracted yet, the oldest first.
+func (r *Repository) GetPendingExtractions(limit int) ([]PendingExtraction, error) {
+	query := `SELECT
+				id, cv_file_id, cv_content_type
+			  FROM
+			  	requests
+			  WHERE
+			  	cv_extracted IS NULL AND cv_file_id <> '' AND ` + scannedCondition + `
+			  ORDER BY
+			  	id
+			  LIMIT $1`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get requests pending extraction: %w", err)
+	}
+	defer rows.Close()
+
+	var extractions []PendingExtraction
+	for rows.Next() {
+		var e PendingExtraction
+		if err := rows.Scan(&e.RequestID, &e.FileID, &e.ContentType); err != nil {
+			return nil, fmt.Errorf("cannot scan request pending extraction: %w", err)
+		}
+
+		extractions = append(extractions, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return extractions, nil
+}
+
+// SaveExtraction saves text and keywords extracted from CV of the request, the request isn't returned
+// by GetPendingExtractions afterwards.
+func (r *Repository) SaveExtraction(requestID, text string, keywords []string) error {
+	query := `UPDATE
+				requests
+			  SET
+			  	cv_text = $1, cv_keywords = $2, cv_extracted = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $3`
+
+	res, err := r.db.Exec(query, text, pq.Array(keywords), requestID)
+	if err != nil {
+		return fmt.Errorf("cannot save text of request %s: %w", requestID, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoResult
+	}
+
+	return nil
+}
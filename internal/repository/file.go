@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	// FileKindCV presents a version of candidate's CV, the latest one is the current CV of request.
+	FileKindCV = "cv"
+
+	// FileKindCoverLetter presents a cover letter of candidate.
+	FileKindCoverLetter = "cover_letter"
+
+	// FileKindPortfolio presents a portfolio of candidate.
+	FileKindPortfolio = "portfolio"
+
+	// FileKindOther presents any other document attached to request.
+	FileKindOther = "other"
+)
+
+const (
+	// FileScanPending presents scan status of a file which waits for malware scanning, it isn't served to users.
+	FileScanPending = "pending"
+
+	// FileScanClean presents scan status of a file which is clean or was attached without scanning.
+	FileScanClean = "clean"
+
+	// FileScanInfected presents scan status of an infected file, it's moved to quarantine.
+	FileScanInfected = "infected"
+)
+
+var fileKinds = map[string]bool{
+	FileKindCV:          true,
+	FileKindCoverLetter: true,
+	FileKindPortfolio:   true,
+	FileKindOther:       true,
+}
+
+// File presents a file attached to a request.
+type File struct {
+	ID          string `json:"id"`
+	RequestID   string `json:"requestId"`
+	Kind        string `json:"kind"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+	UploadedBy  string `json:"uploadedBy"`
+	ScanStatus  string `json:"scanStatus"`
+	Created     string `json:"created"`
+
+	// Current is set for the CV version which is the current CV of request.
+	Current bool `json:"current"`
+
+	// Key presents key of the file in object storage.
+	Key string `json:"-"`
+
+	// UploadID presents slot the file has been uploaded by directly to object storage, the slot is used
+	// when the file is added.
+	UploadID string `json:"-"`
+}
+
+// IsFileKind checks if files of the kind can be attached to requests.
+func IsFileKind(kind string) bool {
+	return fileKinds[kind]
+}
+
+const selectFiles = `SELECT
+				f.id, f.request_id, f.kind, f.filename, f.content_type, f.size, f.sha256, f.uploaded_by, f.created,
+				f.kind = 'cv' AND f.file_key = r.cv_file_id, f.file_key, f.scan_status
+			  FROM
+			  	files f
+			  JOIN
+			  	requests r ON r.id = f.request_id
+			  WHERE
+			  	`
+
+// addFile records file attached to the request. File is clean unless its scan status is set.
+func addFile(tx *sql.Tx, f File) (string, error) {
+	var id string
+
+	scanStatus := f.ScanStatus
+	if scanStatus == "" {
+		scanStatus = FileScanClean
+	}
+
+	if f.UploadID != "" {
+		if err := useUpload(tx, f.UploadID); err != nil {
+			return "", err
+		}
+	}
+
+	query := `INSERT INTO
+				files (request_id, kind, file_key, filename, content_type, size, sha256, uploaded_by, scan_status)
+			  VALUES
+			  	($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			  RETURNING id`
+
+	err := tx.QueryRow(query, f.RequestID, f.Kind, f.Key, f.Filename, f.ContentType, f.Size, f.SHA256, f.UploadedBy, scanStatus).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("cannot add file of request %s: %w", f.RequestID, err)
+	}
+
+	return id, nil
+}
+
+// AddFile attaches file to the request, only requests of the author are searched if author id is set.
+// A new clean CV version becomes the current CV of request and its text is extracted again, CV version
+// pending scan becomes current once it's found clean.
+func (r *Repository) AddFile(f File, authorID string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `SELECT
+				id
+			  FROM
+			  	requests
+			  WHERE
+			  	id = $1 AND ` + scannedCondition
+
+	whereVal := []interface{}{
+		f.RequestID,
+	}
+
+	if authorID != "" {
+		query = fmt.Sprintf("%s AND author_id = $2", query)
+		whereVal = append(whereVal, authorID)
+	}
+
+	var requestID string
+	err = tx.QueryRow(query+" FOR UPDATE", whereVal...).Scan(&requestID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNoResult
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot get request %s: %w", f.RequestID, err)
+	}
+
+	id, err := addFile(tx, f)
+	if err != nil {
+		return "", err
+	}
+
+	if f.Kind == FileKindCV && f.ScanStatus != FileScanPending {
+		f.ID = id
+
+		if err := setCurrentCV(tx, f); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return id, nil
+}
+
+// setCurrentCV makes clean CV version the current CV of its request unless there is a newer clean version.
+func setCurrentCV(tx *sql.Tx, f File) error {
+	query := `UPDATE
+				requests
+			  SET
+			  	cv_file_id = $1, cv_content_type = $2, cv_text = '', cv_keywords = '{}', cv_extracted = NULL,
+			  	updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $3 AND NOT EXISTS (
+			  		SELECT 1 FROM files WHERE request_id = $3 AND kind = 'cv' AND scan_status = 'clean' AND id > $4
+			  	)`
+
+	if _, err := tx.Exec(query, f.Key, f.ContentType, f.RequestID, f.ID); err != nil {
+		return fmt.Errorf("cannot update CV of request %s: %w", f.RequestID, err)
+	}
+
+	return nil
+}
+
+// GetFiles returns files attached to the request, the newest first. Only requests of the author
+// are searched if author id is set.
+func (r *Repository) GetFiles(requestID, authorID string) ([]File, error) {
+	query := selectFiles + "f.request_id = $1 AND " + scannedCondition
+
+	whereVal := []interface{}{
+		requestID,
+	}
+
+	if authorID != "" {
+		query = fmt.Sprintf("%s AND r.author_id = $2", query)
+		whereVal = append(whereVal, authorID)
+	}
+
+	rows, err := r.db.Query(query+" ORDER BY f.created DESC, f.id DESC", whereVal...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get files of request %s: %w", requestID, err)
+	}
+	defer rows.Close()
+
+	files := make([]File, 0)
+	for rows.Next() {
+		f, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return files, nil
+}
+
+// GetFile returns the file, only files of requests of the author are searched if author id is set.
+func (r *Repository) GetFile(fileID, authorID string) (File, error) {
+	query := selectFiles + "f.id = $1 AND " + scannedCondition
+
+	whereVal := []interface{}{
+		fileID,
+	}
+
+	if authorID != "" {
+		query = fmt.Sprintf("%s AND r.author_id = $2", query)
+		whereVal = append(whereVal, authorID)
+	}
+
+	f, err := scanFile(r.db.QueryRow(query, whereVal...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return File{}, ErrNoFile
+	}
+	if err != nil {
+		return File{}, err
+	}
+
+	return f, nil
+}
+
+// DeleteFile deletes the file unless it's the current CV of its request.
+func (r *Repository) DeleteFile(fileID string) error {
+	query := `DELETE FROM
+				files f
+			  USING
+			  	requests r
+			  WHERE
+			  	f.id = $1 AND r.id = f.request_id AND NOT (f.kind = 'cv' AND f.file_key = r.cv_file_id)`
+
+	res, err := r.db.Exec(query, fileID)
+	if err != nil {
+		return fmt.Errorf("cannot delete file %s: %w", fileID, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoResult
+	}
+
+	return nil
+}
+
+func scanFile(row rowScanner) (File, error) {
+	var f File
+
+	err := row.Scan(&f.ID, &f.RequestID, &f.Kind, &f.Filename, &f.ContentType, &f.Size, &f.SHA256, &f.UploadedBy, &f.Created, &f.Current, &f.Key, &f.ScanStatus)
+	if errors.Is(err, sql.ErrNoRows) {
+		return File{}, err
+	}
+	if err != nil {
+		return File{}, fmt.Errorf("cannot get file: %w", err)
+	}
+
+	return f, nil
+}
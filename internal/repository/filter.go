@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+const (
+	// SortOrderAsc presents ascending sort order.
+	SortOrderAsc = "asc"
+
+	// SortOrderDesc presents descending sort order.
+	SortOrderDesc = "desc"
+
+	defaultSortField = "created"
+	defaultSortOrder = SortOrderDesc
+)
+
+// sortColumns maps sort fields accepted by the API to requests table columns.
+var sortColumns = map[string]string{
+	"created": "created",
+	"updated": "updated",
+	"surname": "candidate_surname",
+	"status":  "status",
+}
+
+// ErrInvalidCursor presents an error when pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// psql is a statement builder which uses PostgreSQL placeholders.
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+// RequestFilter presents parameters for filtering, sorting and paging requests.
+type RequestFilter struct {
+	AuthorID    string
+	AssigneeID  string
+	Unassigned  bool
+	Statuses    []string
+	Positions   []string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	Keywords    []string
+	SortBy      string
+	SortOrder   string
+	PageNumber  int
+	PageSize    int
+
+	// Keyset enables keyset pagination: page number is ignored and requests
+	// are returned after the cursor position instead of using offset.
+	Keyset bool
+	After  *RequestCursor
+
+	// CountTotal enables counting of all matched requests with keyset pagination,
+	// it's costly on large tables, so it's opt-in. Offset pagination always counts them.
+	CountTotal bool
+}
+
+// RequestCursor presents a position of request in sorted requests list.
+type RequestCursor struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// EncodeCursor encodes cursor to opaque string which can be passed in URL.
+func EncodeCursor(c RequestCursor) string {
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes cursor encoded by EncodeCursor.
+func DecodeCursor(s string) (RequestCursor, error) {
+	var c RequestCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return RequestCursor{}, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return RequestCursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// CursorOf returns cursor which points to the request in list sorted by the filter.
+func (f RequestFilter) CursorOf(r UserRequests) RequestCursor {
+	var value string
+
+	switch f.sortColumn() {
+	case "updated":
+		value = r.Updated
+	case "candidate_surname":
+		value = r.Surname
+	case "status":
+		value = r.Status
+	default:
+		value = r.Created
+	}
+
+	return RequestCursor{Value: value, ID: r.ID}
+}
+
+// IsSortField checks if requests can be sorted by field.
+func IsSortField(field string) bool {
+	_, ok := sortColumns[field]
+	return ok
+}
+
+// where applies filter conditions to the query.
+// Lower date bounds are inclusive, upper date bounds are exclusive.
+// Every keyword must be among keywords extracted from CV or be found in CV text.
+func (f RequestFilter) where(query sq.SelectBuilder) sq.SelectBuilder {
+	if f.AuthorID != "" {
+		query = query.Where(sq.Eq{"author_id": f.AuthorID})
+	}
+	if f.AssigneeID != "" {
+		query = query.Where(sq.Eq{"assignee_id": f.AssigneeID})
+	}
+	if f.Unassigned {
+		query = query.Where(sq.Eq{"assignee_id": nil})
+	}
+	if len(f.Statuses) != 0 {
+		query = query.Where(sq.Eq{"status": f.Statuses})
+	}
+	if len(f.Positions) != 0 {
+		query = query.Where(sq.Eq{"position": f.Positions})
+	}
+	if !f.CreatedFrom.IsZero() {
+		query = query.Where(sq.GtOrEq{"created": f.CreatedFrom})
+	}
+	if !f.CreatedTo.IsZero() {
+		query = query.Where(sq.Lt{"created": f.CreatedTo})
+	}
+	if !f.UpdatedFrom.IsZero() {
+		query = query.Where(sq.GtOrEq{"updated": f.UpdatedFrom})
+	}
+	if !f.UpdatedTo.IsZero() {
+		query = query.Where(sq.Lt{"updated": f.UpdatedTo})
+	}
+	for _, keyword := range f.Keywords {
+		query = query.Where(sq.Expr(
+			"(? = ANY(cv_keywords) OR to_tsvector('simple', cv_text) @@ plainto_tsquery('simple', ?))", keyword, keyword,
+		))
+	}
+
+	return query
+}
+
+// page applies sorting and limits the query to a single page.
+func (f RequestFilter) page(query sq.SelectBuilder) sq.SelectBuilder {
+	query = query.OrderBy(f.orderBy()...).Limit(uint64(f.PageSize))
+
+	if !f.Keyset {
+		return query.Offset(uint64((f.PageNumber - 1) * f.PageSize))
+	}
+
+	if f.After != nil {
+		operator := ">"
+		if f.sortOrder() == SortOrderDesc {
+			operator = "<"
+		}
+
+		query = query.Where(sq.Expr(fmt.Sprintf("(%s, id) %s (?, ?)", f.sortColumn(), operator), f.After.Value, f.After.ID))
+	}
+
+	return query
+}
+
+func (f RequestFilter) sortColumn() string {
+	column, ok := sortColumns[f.SortBy]
+	if !ok {
+		return sortColumns[defaultSortField]
+	}
+
+	return column
+}
+
+func (f RequestFilter) sortOrder() string {
+	if f.SortOrder != SortOrderAsc && f.SortOrder != SortOrderDesc {
+		return defaultSortOrder
+	}
+
+	return f.SortOrder
+}
+
+// orderBy returns ORDER BY clauses for the filter, request id is used as a tie-breaker
+// to make pages deterministic.
+func (f RequestFilter) orderBy() []string {
+	return []string{
+		fmt.Sprintf("%s %s", f.sortColumn(), f.sortOrder()),
+		fmt.Sprintf("id %s", f.sortOrder()),
+	}
+}
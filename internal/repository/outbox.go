@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/lib/pq"
+)
+
+const (
+	// EventPending presents status of an event which waits for publishing.
+	EventPending = "pending"
+
+	// EventPublished presents status of an event accepted by all publishers.
+	EventPublished = "published"
+
+	// EventFailed presents status of an event which ran out of attempts.
+	EventFailed = "failed"
+)
+
+// ReferralEvent presents payload of referral events.
+type ReferralEvent struct {
+	ID               string  `json:"id"`
+	CandidateName    string  `json:"candidateName"`
+	CandidateSurname string  `json:"candidateSurname"`
+	Position         string  `json:"position"`
+	Status           string  `json:"status"`
+	AuthorID         string  `json:"authorId"`
+	AssigneeID       *string `json:"assigneeId"`
+	Updated          string  `json:"updated"`
+}
+
+// OutboxEvent presents an event which waits for publishing.
+type OutboxEvent struct {
+	event.Event
+	Attempts  int
+	Consumers []string
+}
+
+// addEvent records event in the outbox within the transaction which changes its entity.
+func addEvent(tx *sql.Tx, name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("cannot encode %s event: %w", name, err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO outbox (event, payload) VALUES ($1, $2);", name, string(payload)); err != nil {
+		return fmt.Errorf("cannot add %s event: %w", name, err)
+	}
+
+	return nil
+}
+
+// addRequestEvents records event with a snapshot of every request with given ids in the outbox.
+func addRequestEvents(tx *sql.Tx, name string, ids []string) error {
+	query := `INSERT INTO
+				outbox (event, payload)
+			  SELECT
+			  	$1,
+			  	json_build_object(
+			  		'id', id::TEXT,
+			  		'candidateName', candidate_name,
+			  		'candidateSurname', candidate_surname,
+			  		'position', position,
+			  		'status', status,
+			  		'authorId', author_id::TEXT,
+			  		'assigneeId', assignee_id::TEXT,
+			  		'updated', updated
+			  	)::TEXT
+			  FROM
+			  	requests
+			  WHERE
+			  	id = ANY($2)
+			  ORDER BY
+			  	id;`
+
+	if _, err := tx.Exec(query, name, pq.Array(ids)); err != nil {
+		return fmt.Errorf("cannot add %s events: %w", name, err)
+	}
+
+	return nil
+}
+
+// ClaimDueEvents claims at most limit pending events which are due at the time and returns them in order they happened.
+// Claimed events aren't due for other relays until the lease expires, events locked by another relay are skipped.
+func (r *Repository) ClaimDueEvents(now, leaseUntil time.Time, limit int) ([]OutboxEvent, error) {
+	query := `WITH due AS (
+				SELECT
+					id
+				FROM
+					outbox
+				WHERE
+					status = $1 AND next_attempt <= $2
+				ORDER BY
+					id
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			  ), claimed AS (
+				UPDATE
+					outbox
+				SET
+					next_attempt = $4
+				FROM
+					due
+				WHERE
+					outbox.id = due.id
+				RETURNING
+					outbox.id, outbox.event, outbox.payload, outbox.attempts, outbox.created, outbox.consumers
+			  )
+			  SELECT
+			  	id, event, payload, attempts, created, consumers
+			  FROM
+			  	claimed
+			  ORDER BY
+			  	id;`
+
+	rows, err := r.db.Query(query, EventPending, now, limit, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot claim due events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []OutboxEvent
+	for rows.Next() {
+		var e OutboxEvent
+		var payload string
+
+		if err := rows.Scan(&e.ID, &e.Name, &payload, &e.Attempts, &e.Created, pq.Array(&e.Consumers)); err != nil {
+			return nil, fmt.Errorf("cannot get due event: %w", err)
+		}
+
+		e.Payload = json.RawMessage(payload)
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return events, nil
+}
+
+// SaveEventAttempt records result of publishing attempt along with consumers which have accepted the event so far.
+// Next attempt is nil if event is finished.
+func (r *Repository) SaveEventAttempt(id, status string, attempts int, nextAttempt *time.Time, lastError string, consumers []string) error {
+	query := `UPDATE
+				outbox
+			  SET
+			  	status = $1,
+			  	attempts = $2,
+			  	next_attempt = $3,
+			  	last_error = $4,
+			  	consumers = $5,
+			  	published = CASE WHEN $1 = $6 THEN CURRENT_TIMESTAMP ELSE published END
+			  WHERE
+			  	id = $7;`
+
+	if _, err := r.db.Exec(query, status, attempts, nextAttempt, lastError, pq.Array(consumers), EventPublished, id); err != nil {
+		return fmt.Errorf("cannot save event attempt: %w", err)
+	}
+
+	return nil
+}
+
+// GetEventsAfter returns at most limit events with given names which were recorded after the event with id
+// regardless of their publishing status. If author isn't empty, only events about requests of the author are returned.
+func (r *Repository) GetEventsAfter(id string, names []string, authorID string, limit int) ([]event.Event, error) {
+	query := psql.
+		Select("id", "event", "payload", "created").
+		From("outbox").
+		Where(sq.Gt{"id": id}).
+		Where(sq.Eq{"event": names}).
+		OrderBy("id").
+		Limit(uint64(limit))
+
+	if authorID != "" {
+		query = query.Where(sq.Expr("payload::JSON->>'authorId' = ?", authorID))
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []event.Event{}
+	for rows.Next() {
+		var e event.Event
+		var payload string
+
+		if err := rows.Scan(&e.ID, &e.Name, &payload, &e.Created); err != nil {
+			return nil, fmt.Errorf("cannot get event: %w", err)
+		}
+
+		e.Payload = json.RawMessage(payload)
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return events, nil
+}
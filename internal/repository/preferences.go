@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Preferences presents user settings.
+type Preferences struct {
+	HideFromLeaderboard bool     `json:"hideFromLeaderboard"`
+	Email               string   `json:"email"`
+	MutedNotifications  []string `json:"mutedNotifications"`
+}
+
+// GetPreferences returns user settings.
+func (r *Repository) GetPreferences(userID string) (Preferences, error) {
+	var preferences Preferences
+
+	query := `SELECT
+				hide_from_leaderboard, email, muted_notifications
+			  FROM
+			  	users
+			  WHERE
+			  	id = $1;`
+
+	err := r.db.QueryRow(query, userID).Scan(
+		&preferences.HideFromLeaderboard,
+		&preferences.Email,
+		pq.Array(&preferences.MutedNotifications),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Preferences{}, ErrNoUser
+	}
+	if err != nil {
+		return Preferences{}, fmt.Errorf("cannot get user preferences: %w", err)
+	}
+
+	if preferences.MutedNotifications == nil {
+		preferences.MutedNotifications = []string{}
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences updates user settings.
+func (r *Repository) UpdatePreferences(userID string, preferences Preferences) error {
+	if preferences.MutedNotifications == nil {
+		preferences.MutedNotifications = []string{}
+	}
+
+	query := `UPDATE
+				users
+			  SET
+			  	hide_from_leaderboard = $1, email = $2, muted_notifications = $3, updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $4;`
+
+	rows, err := r.db.Exec(query, preferences.HideFromLeaderboard, preferences.Email, pq.Array(preferences.MutedNotifications), userID)
+	if err != nil {
+		return fmt.Errorf("cannot update user preferences: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoUser
+	}
+
+	return nil
+}
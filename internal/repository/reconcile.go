@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// StoredFile presents a file which is expected to be in object storage. ID is empty for CV which is
+// referenced only by its request.
+type StoredFile struct {
+	ID        string
+	RequestID string
+	Key       string
+}
+
+// GetReferencedKeys returns those of the keys which are referenced by files, requests or upload slots.
+func (r *Repository) GetReferencedKeys(keys []string) (map[string]bool, error) {
+	query := `SELECT file_key FROM files WHERE file_key = ANY($1)
+			  UNION
+			  SELECT cv_file_id FROM requests WHERE cv_file_id = ANY($1)
+			  UNION
+			  SELECT file_key FROM uploads WHERE file_key = ANY($1)`
+
+	rows, err := r.db.Query(query, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("cannot get referenced keys: %w", err)
+	}
+	defer rows.Close()
+
+	referenced := make(map[string]bool, len(keys))
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("cannot scan key: %w", err)
+		}
+
+		referenced[key] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return referenced, nil
+}
+
+// GetStoredFiles returns a page of files with id greater than afterID ordered by id,
+// iteration starts with afterID "0".
+func (r *Repository) GetStoredFiles(afterID string, limit int) ([]StoredFile, error) {
+	query := `SELECT
+				id, request_id, file_key
+			  FROM
+			  	files
+			  WHERE
+			  	id > $1
+			  ORDER BY
+			  	id
+			  LIMIT $2`
+
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get stored files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []StoredFile
+	for rows.Next() {
+		var f StoredFile
+		if err := rows.Scan(&f.ID, &f.RequestID, &f.Key); err != nil {
+			return nil, fmt.Errorf("cannot scan stored file: %w", err)
+		}
+
+		files = append(files, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return files, nil
+}
+
+// GetRequestCVs returns a page of CVs referenced by requests with id greater than afterID ordered by
+// request id which aren't files of the requests, e.g. CVs of requests created before files were tracked,
+// iteration starts with afterID "0".
+func (r *Repository) GetRequestCVs(afterID string, limit int) ([]StoredFile, error) {
+	query := `SELECT
+				r.id, r.cv_file_id
+			  FROM
+			  	requests r
+			  WHERE
+			  	r.id > $1 AND r.cv_file_id <> ''
+				AND NOT EXISTS (SELECT 1 FROM files f WHERE f.request_id = r.id AND f.file_key = r.cv_file_id)
+			  ORDER BY
+			  	r.id
+			  LIMIT $2`
+
+	rows, err := r.db.Query(query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get CVs of requests: %w", err)
+	}
+	defer rows.Close()
+
+	var files []StoredFile
+	for rows.Next() {
+		var f StoredFile
+		if err := rows.Scan(&f.RequestID, &f.Key); err != nil {
+			return nil, fmt.Errorf("cannot scan CV of request: %w", err)
+		}
+
+		files = append(files, f)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return files, nil
+}
@@ -4,16 +4,32 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/lib/pq"
 )
 
 // UserRequests presents a type for user requests data.
 type UserRequests struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
-	Status  string `json:"status"`
-	Updated string `json:"updated"`
-	Author  author `json:"author"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Surname  string `json:"surname"`
+	Position string `json:"position"`
+	Status   string `json:"status"`
+	Created  string `json:"created"`
+	Updated  string `json:"updated"`
+	Author   author `json:"author"`
+
+	// StatusChanged presents time the request got its status, SLA is measured from it.
+	StatusChanged string `json:"-"`
+
+	// Decided presents time of acceptance or rejection, it's exported only.
+	Decided string `json:"-"`
+
+	AssigneeID *string  `json:"assigneeId,omitempty"`
+	Overdue    bool     `json:"overdue"`
+	Keywords   []string `json:"keywords"`
 }
 
 type author struct {
@@ -22,60 +38,51 @@ type author struct {
 	IsAdmin bool   `json:"isadmin"`
 }
 
-// GetRequests gives user requests by id.
-func (r *Repository) GetRequests(id, status string, pageNumber, pageSize int) ([]UserRequests, error) {
-	var requests []UserRequests
-	var whereVal []interface{}
-
-	query := `
-			SELECT
-				id, candidate_name, candidate_surname, status, updated
-			FROM
-				requests%s
-			LIMIT $%d
-			OFFSET $%d
-			`
-
-	offset := (pageNumber - 1) * pageSize
-
-	if id == "" {
-		if status == "" {
-			query = fmt.Sprintf(query, "", 1, 2)
-			whereVal = append(whereVal, pageSize, offset)
-		} else {
-			query = fmt.Sprintf(query, " WHERE status = $1 ", 2, 3)
-			whereVal = append(whereVal, status, pageSize, offset)
-		}
-	} else {
-		if status == "" {
-			query = fmt.Sprintf(query, " WHERE author_id = $1 ", 2, 3)
-			whereVal = append(whereVal, id, pageSize, offset)
-		} else {
-			query = fmt.Sprintf(query, " WHERE author_id = $1 AND status = $2 ", 3, 4)
-			whereVal = append(whereVal, id, status, pageSize, offset)
-		}
+// GetRequests gives requests which match the filter.
+func (r *Repository) GetRequests(filter RequestFilter) ([]UserRequests, error) {
+	requests := make([]UserRequests, 0, filter.PageSize)
+
+	query := filter.page(filter.where(psql.
+		Select("id", "candidate_name", "candidate_surname", "position", "status", "created", "updated", "status_changed", "assignee_id", "cv_keywords").
+		From("requests")))
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
 	}
 
-	rows, err := r.db.Query(query, whereVal...)
+	rows, err := r.db.Query(sqlQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("error with query executing: %w", err)
 	}
-
-	// user, _ := r.GetUser(id)
+	defer rows.Close()
 
 	for rows.Next() {
 		request := UserRequests{}
+		var assigneeID sql.NullString
 
 		if err := rows.Scan(
 			&request.ID,
 			&request.Name,
 			&request.Surname,
+			&request.Position,
 			&request.Status,
+			&request.Created,
 			&request.Updated,
+			&request.StatusChanged,
+			&assigneeID,
+			pq.Array(&request.Keywords),
 		); err != nil {
 			return nil, fmt.Errorf("cannot get requests information: %w", err)
 		}
 
+		if assigneeID.Valid {
+			request.AssigneeID = &assigneeID.String
+		}
+		if request.Keywords == nil {
+			request.Keywords = []string{}
+		}
+
 		requests = append(requests, request)
 	}
 
@@ -86,46 +93,342 @@ func (r *Repository) GetRequests(id, status string, pageNumber, pageSize int) ([
 	return requests, nil
 }
 
-// AddCandidate adds submitted candidate.
-func (r *Repository) AddCandidate(userID, name, surname, fileID string) (string, error) {
+// ExportRequests passes every request which matches the filter to fn one by one, paging is ignored.
+// Requests are read from database as they are consumed, so result set is never kept in memory entirely.
+func (r *Repository) ExportRequests(filter RequestFilter, fn func(UserRequests) error) error {
+	query, args, err := filter.where(psql.
+		Select(
+			"id", "candidate_name", "candidate_surname", "position", "status", "created", "updated",
+			"decided_at", "author_id", "(SELECT name FROM users WHERE users.id = requests.author_id)",
+		).
+		From("requests")).
+		OrderBy(filter.orderBy()...).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("error with query executing: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		request := UserRequests{}
+		var decided sql.NullString
+
+		if err := rows.Scan(
+			&request.ID,
+			&request.Name,
+			&request.Surname,
+			&request.Position,
+			&request.Status,
+			&request.Created,
+			&request.Updated,
+			&decided,
+			&request.Author.ID,
+			&request.Author.Name,
+		); err != nil {
+			return fmt.Errorf("cannot get requests information: %w", err)
+		}
+
+		request.Decided = decided.String
+
+		if err := fn(request); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error with result set: %w", err)
+	}
+
+	return nil
+}
+
+// CountRequests returns number of requests which match the filter regardless of paging.
+func (r *Repository) CountRequests(filter RequestFilter) (int, error) {
+	var count int
+
+	query, args, err := filter.where(psql.Select("COUNT(*)").From("requests")).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("cannot count requests: %w", err)
+	}
+
+	return count, nil
+}
+
+// AddCandidate adds submitted candidate, assigns it according to assignment rules
+// and records the event in the outbox. bonusEligible is false if referral mustn't be paid.
+// If duplicate isn't nil, the candidate is checked against it before insertion.
+// Requests with pending_scan status aren't assigned and announced until their CVs are known to be clean.
+// CV becomes the first CV version of request.
+func (r *Repository) AddCandidate(userID, name, surname, position, department string, cv File, status string, bonusEligible bool, duplicate *DuplicateCheck) (string, error) {
 	var requestID string
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if duplicate != nil {
+		eligible, err := checkDuplicate(tx, name, surname, *duplicate)
+		if err != nil {
+			return "", err
+		}
+
+		bonusEligible = bonusEligible && eligible
+	}
+
 	query := `INSERT INTO 
-				requests(author_id, candidate_name, candidate_surname, cv_file_id) 
+				requests(author_id, candidate_name, candidate_surname, position, department, cv_file_id, cv_content_type, status, bonus_eligible) 
 			  VALUES
-			  	($1, $2, $3, $4)
+			  	($1, $2, $3, $4, $5, $6, $7, $8, $9)
 			  RETURNING id;`
 
-	err := r.db.QueryRow(query, userID, name, surname, fileID).Scan(&requestID)
+	err = tx.QueryRow(query, userID, name, surname, position, department, cv.Key, cv.ContentType, status, bonusEligible).Scan(&requestID)
 	if err != nil {
 		return "", fmt.Errorf("cannot add candidate to database: %w", err)
 	}
 
+	cv.RequestID, cv.Kind, cv.UploadedBy = requestID, FileKindCV, userID
+	if _, err := addFile(tx, cv); err != nil {
+		return "", err
+	}
+
+	if status != StatusPendingScan {
+		if err := submitRequest(tx, requestID); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
 	return requestID, nil
 }
 
-// UpdateRequest updates user request status.
+// ImportedRequest presents a historical request which is imported with its original status and dates.
+type ImportedRequest struct {
+	AuthorID   string
+	Name       string
+	Surname    string
+	Position   string
+	Department string
+	Status     string
+	Created    time.Time
+	Updated    time.Time
+
+	// CV presents uploaded CV of the request, its key is empty if request has no CV.
+	CV File
+}
+
+// ImportRequests adds all requests in a single transaction and returns their ids. Requests whose CVs
+// are pending scan are held with pending_scan status and get their imported status once CVs are clean.
+func (r *Repository) ImportRequests(requests []ImportedRequest) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO 
+				requests(author_id, candidate_name, candidate_surname, position, department, status, cv_file_id, cv_content_type, created, updated, status_changed, decided_at, held_status) 
+			  VALUES
+			  	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10, CASE WHEN COALESCE(NULLIF($11, ''), $6) IN ('accepted', 'rejected') THEN $10::TIMESTAMP END, $11)
+			  RETURNING id;`
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot prepare query: %w", err)
+	}
+	defer stmt.Close()
+
+	ids := make([]string, 0, len(requests))
+	for _, request := range requests {
+		var id string
+
+		status, held := request.Status, ""
+		if request.CV.Key != "" && request.CV.ScanStatus == FileScanPending {
+			status, held = StatusPendingScan, request.Status
+		}
+
+		err := stmt.QueryRow(
+			request.AuthorID,
+			request.Name,
+			request.Surname,
+			request.Position,
+			request.Department,
+			status,
+			request.CV.Key,
+			request.CV.ContentType,
+			request.Created,
+			request.Updated,
+			held,
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("cannot import request of candidate %s %s: %w", request.Name, request.Surname, err)
+		}
+
+		if request.CV.Key != "" {
+			cv := request.CV
+			cv.RequestID, cv.Kind, cv.UploadedBy = id, FileKindCV, request.AuthorID
+			if _, err := addFile(tx, cv); err != nil {
+				return nil, err
+			}
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return ids, nil
+}
+
+// UpdateRequest updates user request status and records the event in the outbox.
 func (r *Repository) UpdateRequest(id, newState string) error {
 	query := `UPDATE 
 				requests 
 			  SET 
-			  	status = $1
+			  	status = $1, updated = CURRENT_TIMESTAMP, ` + setDecidedAt + `, ` + setStatusChanged + `
 			  WHERE 
-			  	id = $2;`
+			  	id = $2 AND ` + scannedCondition
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	rows, err := r.db.Exec(query, newState, id)
+	rows, err := tx.Exec(query, newState, id)
+	if err != nil {
+		return fmt.Errorf("cannot update user request: %w", err)
+	}
 
 	n, _ := rows.RowsAffected()
 	if n == 0 {
 		return ErrNoResult
 	}
-	if err != nil {
-		return fmt.Errorf("cannot update user request: %w", err)
+
+	if err := syncBonuses(tx, []string{id}, newState); err != nil {
+		return err
+	}
+
+	if err := addRequestEvents(tx, event.ReferralStatusChanged, []string{id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
 	}
 
 	return nil
 }
 
+// UpdateRequests updates status of all requests with given ids in a single transaction.
+// If some of requests don't exist, nothing is updated and ids of missing requests are returned with ErrNoResult.
+func (r *Repository) UpdateRequests(ids []string, newState string) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE 
+				requests 
+			  SET 
+			  	status = $1, updated = CURRENT_TIMESTAMP, ` + setDecidedAt + `, ` + setStatusChanged + `
+			  WHERE 
+			  	id = ANY($2) AND ` + scannedCondition + `
+			  RETURNING id;`
+
+	rows, err := tx.Query(query, newState, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("cannot update user requests: %w", err)
+	}
+	defer rows.Close()
+
+	updated := make(map[string]bool, len(ids))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("cannot get id of updated request: %w", err)
+		}
+
+		updated[id] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !updated[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) != 0 {
+		return missing, ErrNoResult
+	}
+
+	if err := syncBonuses(tx, ids, newState); err != nil {
+		return nil, err
+	}
+
+	if err := addRequestEvents(tx, event.ReferralStatusChanged, ids); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return nil, nil
+}
+
+// GetRequestIDs returns ids of requests which match the filter regardless of paging, at most limit ids are returned.
+func (r *Repository) GetRequestIDs(filter RequestFilter, limit int) ([]string, error) {
+	var ids []string
+
+	query, args, err := filter.where(psql.Select("id").From("requests")).OrderBy("id").Limit(uint64(limit)).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error with query executing: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("cannot get request id: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return ids, nil
+}
+
 // GetCVID returns cv file id from object storage.
 func (r *Repository) GetCVID(candidateID, userID string) (string, error) {
 	var fileID string
@@ -135,7 +438,7 @@ func (r *Repository) GetCVID(candidateID, userID string) (string, error) {
 			  FROM 
 			  	requests
 			  WHERE 
-			  	id = $1`
+			  	id = $1 AND ` + scannedCondition
 
 	whereVal := []interface{}{
 		candidateID,
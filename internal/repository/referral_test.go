@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+var requestColumns = []string{"id", "candidate_name", "candidate_surname", "position", "status", "created", "updated", "status_changed", "assignee_id", "cv_keywords"}
+
+func TestRepository_GetRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		testName      string
+		filter        RequestFilter
+		expectedQuery string
+		expectedArgs  []driver.Value
+	}{
+		{
+			testName:      "Default sort",
+			filter:        RequestFilter{PageNumber: 1, PageSize: 10},
+			expectedQuery: "SELECT id, candidate_name, candidate_surname, position, status, created, updated, status_changed, assignee_id, cv_keywords FROM requests ORDER BY created desc, id desc LIMIT 10 OFFSET 0",
+		},
+		{
+			testName: "Filters and sort",
+			filter: RequestFilter{
+				AuthorID:    defaultID,
+				Statuses:    []string{"accepted", "rejected"},
+				Positions:   []string{"developer"},
+				CreatedFrom: from,
+				UpdatedTo:   to,
+				SortBy:      "surname",
+				SortOrder:   SortOrderAsc,
+				PageNumber:  3,
+				PageSize:    5,
+			},
+			expectedQuery: "SELECT id, candidate_name, candidate_surname, position, status, created, updated, status_changed, assignee_id, cv_keywords FROM requests " +
+				"WHERE author_id = $1 AND status IN ($2,$3) AND position IN ($4) AND created >= $5 AND updated < $6 " +
+				"ORDER BY candidate_surname asc, id asc LIMIT 5 OFFSET 10",
+			expectedArgs: []driver.Value{defaultID, "accepted", "rejected", "developer", from, to},
+		},
+		{
+			testName: "Keyset pagination",
+			filter: RequestFilter{
+				Statuses: []string{"submitted"},
+				PageSize: 10,
+				Keyset:   true,
+				After:    &RequestCursor{Value: "2022-01-01T00:00:00Z", ID: defaultID},
+			},
+			expectedQuery: "SELECT id, candidate_name, candidate_surname, position, status, created, updated, status_changed, assignee_id, cv_keywords FROM requests " +
+				"WHERE status IN ($1) AND (created, id) < ($2, $3) ORDER BY created desc, id desc LIMIT 10",
+			expectedArgs: []driver.Value{"submitted", "2022-01-01T00:00:00Z", defaultID},
+		},
+		{
+			testName: "Assignee",
+			filter:   RequestFilter{AssigneeID: defaultID, PageNumber: 1, PageSize: 10},
+			expectedQuery: "SELECT id, candidate_name, candidate_surname, position, status, created, updated, status_changed, assignee_id, cv_keywords FROM requests " +
+				"WHERE assignee_id = $1 ORDER BY created desc, id desc LIMIT 10 OFFSET 0",
+			expectedArgs: []driver.Value{defaultID},
+		},
+		{
+			testName: "Unassigned",
+			filter:   RequestFilter{Unassigned: true, PageNumber: 1, PageSize: 10},
+			expectedQuery: "SELECT id, candidate_name, candidate_surname, position, status, created, updated, status_changed, assignee_id, cv_keywords FROM requests " +
+				"WHERE assignee_id IS NULL ORDER BY created desc, id desc LIMIT 10 OFFSET 0",
+		},
+		{
+			testName: "Keywords",
+			filter:   RequestFilter{Keywords: []string{"go", "docker"}, PageNumber: 1, PageSize: 10},
+			expectedQuery: "SELECT id, candidate_name, candidate_surname, position, status, created, updated, status_changed, assignee_id, cv_keywords FROM requests " +
+				"WHERE ($1 = ANY(cv_keywords) OR to_tsvector('simple', cv_text) @@ plainto_tsquery('simple', $2)) " +
+				"AND ($3 = ANY(cv_keywords) OR to_tsvector('simple', cv_text) @@ plainto_tsquery('simple', $4)) " +
+				"ORDER BY created desc, id desc LIMIT 10 OFFSET 0",
+			expectedArgs: []driver.Value{"go", "go", "docker", "docker"},
+		},
+	}
+
+	r := NewRepository(db)
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			rows := sqlmock.NewRows(requestColumns).
+				AddRow(defaultID, defaultName, defaultName, "developer", "submitted", from.String(), from.String(), from.String(), nil, "{go,docker}")
+
+			mock.ExpectQuery(regexp.QuoteMeta(tc.expectedQuery)).WithArgs(tc.expectedArgs...).WillReturnRows(rows)
+
+			result, err := r.GetRequests(tc.filter)
+			assert.NoError(t, err)
+			assert.Len(t, result, 1)
+			assert.Equal(t, []string{"go", "docker"}, result[0].Keywords)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_CountRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	query := "SELECT COUNT(*) FROM requests WHERE author_id = $1"
+	rows := sqlmock.NewRows([]string{"count"}).AddRow(5)
+	mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(defaultID).WillReturnRows(rows)
+
+	r := NewRepository(db)
+
+	count, err := r.CountRequests(RequestFilter{AuthorID: defaultID, PageNumber: 2, PageSize: 10, Keyset: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_UpdateRequests(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	query := `UPDATE requests SET status = (.+) decided_at = CASE WHEN status = \$1 THEN decided_at`
+
+	testTable := []struct {
+		testName        string
+		ids             []string
+		updatedIDs      []string
+		expectedMissing []string
+		expectedError   error
+		mock            func(updated []string)
+	}{
+		{
+			testName:   "Success",
+			ids:        []string{"1", "2"},
+			updatedIDs: []string{"1", "2"},
+			mock: func(updated []string) {
+				rows := sqlmock.NewRows([]string{idColumn})
+				for _, id := range updated {
+					rows.AddRow(id)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(query).WillReturnRows(rows)
+				mock.ExpectExec("INSERT INTO bonuses").WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec("INSERT INTO outbox").
+					WithArgs(event.ReferralStatusChanged, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName:        "Failure: some requests don't exist",
+			ids:             []string{"1", "2", "3"},
+			updatedIDs:      []string{"2"},
+			expectedMissing: []string{"1", "3"},
+			expectedError:   ErrNoResult,
+			mock: func(updated []string) {
+				rows := sqlmock.NewRows([]string{idColumn})
+				for _, id := range updated {
+					rows.AddRow(id)
+				}
+
+				mock.ExpectBegin()
+				mock.ExpectQuery(query).WillReturnRows(rows)
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	r := NewRepository(db)
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			tc.mock(tc.updatedIDs)
+
+			missing, err := r.UpdateRequests(tc.ids, "accepted")
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.Equal(t, tc.expectedMissing, missing)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -5,6 +5,35 @@ import (
 	"time"
 )
 
+const (
+	// StatusSubmitted presents status of a newly submitted request.
+	StatusSubmitted = "submitted"
+
+	// StatusAccepted presents status of a request with accepted candidate.
+	StatusAccepted = "accepted"
+
+	// StatusRejected presents status of a request with rejected candidate.
+	StatusRejected = "rejected"
+
+	// StatusPendingScan presents status of a request whose CV isn't scanned for malware yet.
+	StatusPendingScan = "pending_scan"
+
+	// StatusQuarantined presents status of a request whose CV is infected, CV is moved to quarantine.
+	StatusQuarantined = "quarantined"
+
+	// scannedCondition excludes requests whose CVs aren't known to be clean, they can't be
+	// reviewed or downloaded.
+	scannedCondition = "status NOT IN ('pending_scan', 'quarantined')"
+
+	// setDecidedAt sets time of decision when request is accepted or rejected by status $1. The time is kept
+	// if status doesn't change and is reset if request isn't decided anymore.
+	setDecidedAt = "decided_at = CASE WHEN status = $1 THEN decided_at WHEN $1 IN ('accepted', 'rejected') THEN CURRENT_TIMESTAMP END"
+
+	// setStatusChanged sets time of status change to status $1, SLA is measured from it. The time is kept
+	// if status doesn't change.
+	setStatusChanged = "status_changed = CASE WHEN status = $1 THEN status_changed ELSE CURRENT_TIMESTAMP END"
+)
+
 // AuthRepository presents methods for user authorization/registration.
 type AuthRepository interface {
 	CreateUser(name, password string) (string, error)
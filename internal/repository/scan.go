@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/event"
+)
+
+// PendingScan presents CV of a request which waits for malware scanning.
+type PendingScan struct {
+	RequestID string
+	FileID    string
+	Attempts  int
+}
+
+// PendingFileScan presents a file attached to a request which waits for malware scanning.
+type PendingFileScan struct {
+	FileID   string
+	Key      string
+	Attempts int
+}
+
+// submitRequest assigns the new request and records its creation in the outbox.
+func submitRequest(tx *sql.Tx, id string) error {
+	if err := autoAssignRequest(tx, id); err != nil {
+		return err
+	}
+
+	return addRequestEvents(tx, event.ReferralCreated, []string{id})
+}
+
+// GetPendingScans returns CVs of requests with pending_scan status which are due at the time, the oldest first.
+func (r *Repository) GetPendingScans(now time.Time, limit int) ([]PendingScan, error) {
+	query := `SELECT
+				id, cv_file_id, scan_attempts
+			  FROM
+			  	requests
+			  WHERE
+			  	status = $1 AND (scan_next_attempt IS NULL OR scan_next_attempt <= $2)
+			  ORDER BY
+			  	id
+			  LIMIT $3`
+
+	rows, err := r.db.Query(query, StatusPendingScan, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get requests pending scan: %w", err)
+	}
+	defer rows.Close()
+
+	var scans []PendingScan
+	for rows.Next() {
+		var scan PendingScan
+		if err := rows.Scan(&scan.RequestID, &scan.FileID, &scan.Attempts); err != nil {
+			return nil, fmt.Errorf("cannot scan request pending scan: %w", err)
+		}
+
+		scans = append(scans, scan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return scans, nil
+}
+
+// SaveScanAttempt records failed attempt to scan CV of request pending scan, so it's retried at the next attempt.
+func (r *Repository) SaveScanAttempt(requestID string, attempts int, nextAttempt time.Time, lastError string) error {
+	query := `UPDATE
+				requests
+			  SET
+			  	scan_attempts = $1, scan_next_attempt = $2, scan_error = $3
+			  WHERE
+			  	id = $4 AND status = $5`
+
+	if _, err := r.db.Exec(query, attempts, nextAttempt, lastError, requestID, StatusPendingScan); err != nil {
+		return fmt.Errorf("cannot save scan attempt of request %s: %w", requestID, err)
+	}
+
+	return nil
+}
+
+// AcceptScannedCV submits request whose CV is clean: request gets submitted status, is assigned and announced.
+// Imported request gets its imported status instead and keeps its dates.
+func (r *Repository) AcceptScannedCV(requestID string) error {
+	return r.completeScan(requestID, StatusSubmitted, FileScanClean, "", "")
+}
+
+// QuarantineCV marks request whose CV is infected as quarantined, CV is replaced with its quarantined copy.
+func (r *Repository) QuarantineCV(requestID, fileID, signature string) error {
+	return r.completeScan(requestID, StatusQuarantined, FileScanInfected, fileID, signature)
+}
+
+// completeScan sets status of request pending scan and scan status of its CV. Requests which aren't
+// pending scan are left untouched, so the result of a repeated scan isn't applied twice.
+func (r *Repository) completeScan(requestID, status, scanStatus, fileID, signature string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE
+				files
+			  SET
+			  	file_key = COALESCE(NULLIF($1, ''), file_key), scan_status = $2
+			  WHERE
+			  	request_id = $3 AND file_key = (SELECT cv_file_id FROM requests WHERE id = $3 AND status = $4)`
+
+	if _, err := tx.Exec(query, fileID, scanStatus, requestID, StatusPendingScan); err != nil {
+		return fmt.Errorf("cannot save scan result of CV of request %s: %w", requestID, err)
+	}
+
+	query = `UPDATE
+				requests r
+			  SET
+			  	status = CASE WHEN $1 = 'submitted' AND p.held_status <> '' THEN p.held_status ELSE $1 END,
+			  	cv_file_id = COALESCE(NULLIF($2, ''), r.cv_file_id), cv_signature = $3, held_status = '',
+			  	updated = CASE WHEN p.held_status = '' THEN CURRENT_TIMESTAMP ELSE r.updated END,
+			  	status_changed = CASE WHEN p.held_status = '' THEN CURRENT_TIMESTAMP ELSE r.status_changed END
+			  FROM
+			  	(SELECT id, held_status FROM requests WHERE id = $4 AND status = $5 FOR UPDATE) p
+			  WHERE
+			  	r.id = p.id
+			  RETURNING
+			  	p.held_status`
+
+	var held string
+	err = tx.QueryRow(query, status, fileID, signature, requestID, StatusPendingScan).Scan(&held)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoResult
+	}
+	if err != nil {
+		return fmt.Errorf("cannot save scan result of request %s: %w", requestID, err)
+	}
+
+	// Imported requests are historical, so they are neither assigned nor announced.
+	switch {
+	case status == StatusSubmitted && held == "":
+		err = submitRequest(tx, requestID)
+	case status != StatusSubmitted:
+		err = addRequestEvents(tx, event.ReferralStatusChanged, []string{requestID})
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingFileScans returns attached files pending scan which are due at the time, the oldest first.
+// CVs of requests pending scan are scanned with their requests.
+func (r *Repository) GetPendingFileScans(now time.Time, limit int) ([]PendingFileScan, error) {
+	query := `SELECT
+				id, file_key, scan_attempts
+			  FROM
+			  	files
+			  WHERE
+			  	scan_status = $1 AND (scan_next_attempt IS NULL OR scan_next_attempt <= $2)
+			  	AND NOT EXISTS (SELECT 1 FROM requests r WHERE r.id = files.request_id AND r.status = 'pending_scan')
+			  ORDER BY
+			  	id
+			  LIMIT $3`
+
+	rows, err := r.db.Query(query, FileScanPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get files pending scan: %w", err)
+	}
+	defer rows.Close()
+
+	var scans []PendingFileScan
+	for rows.Next() {
+		var scan PendingFileScan
+		if err := rows.Scan(&scan.FileID, &scan.Key, &scan.Attempts); err != nil {
+			return nil, fmt.Errorf("cannot scan file pending scan: %w", err)
+		}
+
+		scans = append(scans, scan)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return scans, nil
+}
+
+// SaveFileScanAttempt records failed attempt to scan file pending scan, so it's retried at the next attempt.
+func (r *Repository) SaveFileScanAttempt(fileID string, attempts int, nextAttempt time.Time, lastError string) error {
+	query := `UPDATE
+				files
+			  SET
+			  	scan_attempts = $1, scan_next_attempt = $2, scan_error = $3
+			  WHERE
+			  	id = $4 AND scan_status = $5`
+
+	if _, err := r.db.Exec(query, attempts, nextAttempt, lastError, fileID, FileScanPending); err != nil {
+		return fmt.Errorf("cannot save scan attempt of file %s: %w", fileID, err)
+	}
+
+	return nil
+}
+
+// AcceptScannedFile marks file pending scan as clean, clean CV version becomes the current CV of its request.
+func (r *Repository) AcceptScannedFile(fileID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE
+				files
+			  SET
+			  	scan_status = $1
+			  WHERE
+			  	id = $2 AND scan_status = $3
+			  RETURNING
+			  	id, request_id, kind, file_key, content_type`
+
+	var f File
+	err = tx.QueryRow(query, FileScanClean, fileID, FileScanPending).Scan(&f.ID, &f.RequestID, &f.Kind, &f.Key, &f.ContentType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoResult
+	}
+	if err != nil {
+		return fmt.Errorf("cannot save scan result of file %s: %w", fileID, err)
+	}
+
+	if f.Kind == FileKindCV {
+		if err := setCurrentCV(tx, f); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// QuarantineFile marks file pending scan as infected, file is replaced with its quarantined copy.
+func (r *Repository) QuarantineFile(fileID, key, signature string) error {
+	query := `UPDATE
+				files
+			  SET
+			  	file_key = $1, scan_status = $2, signature = $3
+			  WHERE
+			  	id = $4 AND scan_status = $5`
+
+	res, err := r.db.Exec(query, key, FileScanInfected, signature, fileID, FileScanPending)
+	if err != nil {
+		return fmt.Errorf("cannot quarantine file %s: %w", fileID, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoResult
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+// OverdueRequest presents a request which stays in its status longer than allowed.
+type OverdueRequest struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Surname    string  `json:"surname"`
+	Position   string  `json:"position"`
+	Status     string  `json:"status"`
+	AuthorID   string  `json:"authorId"`
+	AssigneeID *string `json:"assigneeId"`
+
+	// StatusChanged presents time the request got its status.
+	StatusChanged time.Time `json:"statusChanged"`
+}
+
+// GetOverdueRequests returns requests which haven't changed status for longer than the limit of the status.
+// If remindedBefore isn't zero, requests reminded about after it are skipped.
+func (r *Repository) GetOverdueRequests(limits map[string]time.Duration, now, remindedBefore time.Time) ([]OverdueRequest, error) {
+	requests := []OverdueRequest{}
+
+	if len(limits) == 0 {
+		return requests, nil
+	}
+
+	statuses := make([]string, 0, len(limits))
+	for status := range limits {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	overdue := sq.Or{}
+	for _, status := range statuses {
+		overdue = append(overdue, sq.And{sq.Eq{"status": status}, sq.Lt{"status_changed": now.Add(-limits[status])}})
+	}
+
+	query := psql.
+		Select("id", "candidate_name", "candidate_surname", "position", "status", "author_id", "assignee_id", "status_changed").
+		From("requests").
+		Where(overdue).
+		OrderBy("status_changed", "id")
+
+	if !remindedBefore.IsZero() {
+		query = query.Where(sq.Or{sq.Eq{"reminded": nil}, sq.Lt{"reminded": remindedBefore}})
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get overdue requests: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var request OverdueRequest
+		var assigneeID sql.NullString
+
+		if err := rows.Scan(
+			&request.ID,
+			&request.Name,
+			&request.Surname,
+			&request.Position,
+			&request.Status,
+			&request.AuthorID,
+			&assigneeID,
+			&request.StatusChanged,
+		); err != nil {
+			return nil, fmt.Errorf("cannot get overdue request: %w", err)
+		}
+
+		if assigneeID.Valid {
+			request.AssigneeID = &assigneeID.String
+		}
+
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return requests, nil
+}
+
+// MarkReminded saves time of the last reminder about requests.
+func (r *Repository) MarkReminded(ids []string, now time.Time) error {
+	query := `UPDATE
+				requests
+			  SET
+			  	reminded = $1
+			  WHERE
+			  	id = ANY($2);`
+
+	if _, err := r.db.Exec(query, now, pq.Array(ids)); err != nil {
+		return fmt.Errorf("cannot mark requests as reminded: %w", err)
+	}
+
+	return nil
+}
+
+// GetAdminIDs returns ids of all admins.
+func (r *Repository) GetAdminIDs() ([]string, error) {
+	query := `SELECT
+				id
+			  FROM
+			  	users
+			  WHERE
+			  	is_admin
+			  ORDER BY
+			  	id;`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get admins: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("cannot get admin id: %w", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const unspecifiedGroup = "unspecified"
+
+// Stats presents aggregated statistics of requests created in a period.
+type Stats struct {
+	From                time.Time        `json:"from"`
+	To                  time.Time        `json:"to"`
+	Total               int              `json:"total"`
+	ByStatus            map[string]int   `json:"byStatus"`
+	ByPosition          map[string]int   `json:"byPosition"`
+	ByDepartment        map[string]int   `json:"byDepartment"`
+	ByReferrer          []ReferrerStats  `json:"byReferrer"`
+	ConversionRate      float64          `json:"conversionRate"`
+	AcceptanceRate      float64          `json:"acceptanceRate"`
+	MedianDecisionHours *float64         `json:"medianDecisionHours"`
+	Weekly              []WeeklyRequests `json:"weekly"`
+}
+
+// ReferrerStats presents number of requests of a particular referrer.
+type ReferrerStats struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Total    int    `json:"total"`
+	Accepted int    `json:"accepted"`
+}
+
+// WeeklyRequests presents number of requests created during a week.
+type WeeklyRequests struct {
+	Week     time.Time `json:"week"`
+	Total    int       `json:"total"`
+	Accepted int       `json:"accepted"`
+	Rejected int       `json:"rejected"`
+}
+
+// groupColumns presents columns requests can be grouped by in statistics.
+var groupColumns = map[string]bool{
+	"status":     true,
+	"position":   true,
+	"department": true,
+}
+
+// GetStats returns statistics of requests created in [from, to). Requests whose CVs aren't known
+// to be clean aren't counted.
+func (r *Repository) GetStats(from, to time.Time) (Stats, error) {
+	stats := Stats{From: from, To: to}
+	var err error
+
+	if stats.ByStatus, err = r.countRequestsBy("status", from, to); err != nil {
+		return Stats{}, err
+	}
+
+	if stats.ByPosition, err = r.countRequestsBy("position", from, to); err != nil {
+		return Stats{}, err
+	}
+
+	if stats.ByDepartment, err = r.countRequestsBy("department", from, to); err != nil {
+		return Stats{}, err
+	}
+
+	for _, count := range stats.ByStatus {
+		stats.Total += count
+	}
+
+	accepted := stats.ByStatus[StatusAccepted]
+	decided := accepted + stats.ByStatus[StatusRejected]
+
+	if stats.Total != 0 {
+		stats.ConversionRate = float64(accepted) / float64(stats.Total)
+	}
+	if decided != 0 {
+		stats.AcceptanceRate = float64(accepted) / float64(decided)
+	}
+
+	if stats.ByReferrer, err = r.getReferrerStats(from, to); err != nil {
+		return Stats{}, err
+	}
+
+	if stats.MedianDecisionHours, err = r.getMedianDecisionHours(from, to); err != nil {
+		return Stats{}, err
+	}
+
+	if stats.Weekly, err = r.getWeeklyRequests(from, to); err != nil {
+		return Stats{}, err
+	}
+
+	return stats, nil
+}
+
+// countRequestsBy returns number of requests per value of the column.
+func (r *Repository) countRequestsBy(column string, from, to time.Time) (map[string]int, error) {
+	if !groupColumns[column] {
+		return nil, fmt.Errorf("cannot group requests by %s", column)
+	}
+
+	query := fmt.Sprintf(`SELECT
+				COALESCE(NULLIF(%[1]s, ''), $3), COUNT(*)
+			  FROM
+			  	requests
+			  WHERE
+			  	created >= $1 AND created < $2 AND %[2]s
+			  GROUP BY
+			  	1;`, column, scannedCondition)
+
+	rows, err := r.db.Query(query, from, to, unspecifiedGroup)
+	if err != nil {
+		return nil, fmt.Errorf("cannot count requests by %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var value string
+		var count int
+
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, fmt.Errorf("cannot get number of requests by %s: %w", column, err)
+		}
+
+		counts[value] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (r *Repository) getReferrerStats(from, to time.Time) ([]ReferrerStats, error) {
+	query := `SELECT
+				users.id, users.name, COUNT(*), COUNT(*) FILTER (WHERE requests.status = $3)
+			  FROM
+			  	requests
+			  JOIN
+			  	users ON users.id = requests.author_id
+			  WHERE
+			  	requests.created >= $1 AND requests.created < $2 AND ` + scannedCondition + `
+			  GROUP BY
+			  	users.id, users.name
+			  ORDER BY
+			  	3 DESC, users.id;`
+
+	rows, err := r.db.Query(query, from, to, StatusAccepted)
+	if err != nil {
+		return nil, fmt.Errorf("cannot count requests by referrer: %w", err)
+	}
+	defer rows.Close()
+
+	referrers := []ReferrerStats{}
+	for rows.Next() {
+		var referrer ReferrerStats
+
+		if err := rows.Scan(&referrer.ID, &referrer.Name, &referrer.Total, &referrer.Accepted); err != nil {
+			return nil, fmt.Errorf("cannot get number of requests by referrer: %w", err)
+		}
+
+		referrers = append(referrers, referrer)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return referrers, nil
+}
+
+// getMedianDecisionHours returns median time between request creating and its acceptance or rejection,
+// nil is returned if there are no decided requests.
+func (r *Repository) getMedianDecisionHours(from, to time.Time) (*float64, error) {
+	var median sql.NullFloat64
+
+	query := `SELECT
+				PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM decided_at - created)) / 3600
+			  FROM
+			  	requests
+			  WHERE
+			  	created >= $1 AND created < $2 AND status IN ($3, $4) AND decided_at IS NOT NULL;`
+
+	err := r.db.QueryRow(query, from, to, StatusAccepted, StatusRejected).Scan(&median)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get median decision time: %w", err)
+	}
+
+	if !median.Valid {
+		return nil, nil
+	}
+
+	return &median.Float64, nil
+}
+
+// getWeeklyRequests returns number of created requests per week, weeks without requests are included.
+func (r *Repository) getWeeklyRequests(from, to time.Time) ([]WeeklyRequests, error) {
+	query := `SELECT
+				weeks.week,
+				COUNT(requests.id),
+				COUNT(requests.id) FILTER (WHERE requests.status = $3),
+				COUNT(requests.id) FILTER (WHERE requests.status = $4)
+			  FROM
+			  	GENERATE_SERIES(DATE_TRUNC('week', $1::TIMESTAMP), $2::TIMESTAMP - INTERVAL '1 microsecond', INTERVAL '1 week') AS weeks(week)
+			  LEFT JOIN
+			  	requests ON requests.created >= GREATEST(weeks.week, $1::TIMESTAMP)
+			  		AND requests.created < LEAST(weeks.week + INTERVAL '1 week', $2::TIMESTAMP)
+			  		AND ` + scannedCondition + `
+			  GROUP BY
+			  	weeks.week
+			  ORDER BY
+			  	weeks.week;`
+
+	rows, err := r.db.Query(query, from, to, StatusAccepted, StatusRejected)
+	if err != nil {
+		return nil, fmt.Errorf("cannot count requests by week: %w", err)
+	}
+	defer rows.Close()
+
+	weeks := []WeeklyRequests{}
+	for rows.Next() {
+		var week WeeklyRequests
+
+		if err := rows.Scan(&week.Week, &week.Total, &week.Accepted, &week.Rejected); err != nil {
+			return nil, fmt.Errorf("cannot get number of requests by week: %w", err)
+		}
+
+		weeks = append(weeks, week)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return weeks, nil
+}
+
+// UserStats presents statistics of requests of a particular user.
+type UserStats struct {
+	Total                int            `json:"total"`
+	ByStatus             map[string]int `json:"byStatus"`
+	AverageDecisionHours *float64       `json:"averageDecisionHours"`
+}
+
+// LeaderboardEntry presents a place of referrer in leaderboard.
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	Name     string `json:"name"`
+	Accepted int    `json:"accepted"`
+}
+
+// GetUserStats returns statistics of all requests of the user.
+func (r *Repository) GetUserStats(userID string) (UserStats, error) {
+	stats := UserStats{ByStatus: map[string]int{}}
+
+	query := `SELECT
+				status, COUNT(*)
+			  FROM
+			  	requests
+			  WHERE
+			  	author_id = $1
+			  GROUP BY
+			  	status;`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return UserStats{}, fmt.Errorf("cannot count user requests by status: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status string
+		var count int
+
+		if err := rows.Scan(&status, &count); err != nil {
+			return UserStats{}, fmt.Errorf("cannot get number of user requests by status: %w", err)
+		}
+
+		stats.ByStatus[status] = count
+		stats.Total += count
+	}
+
+	if err := rows.Err(); err != nil {
+		return UserStats{}, fmt.Errorf("error with result set: %w", err)
+	}
+
+	var average sql.NullFloat64
+
+	query = `SELECT
+				AVG(EXTRACT(EPOCH FROM decided_at - created)) / 3600
+			 FROM
+			 	requests
+			 WHERE
+			 	author_id = $1 AND status IN ($2, $3) AND decided_at IS NOT NULL;`
+
+	err = r.db.QueryRow(query, userID, StatusAccepted, StatusRejected).Scan(&average)
+	if err != nil {
+		return UserStats{}, fmt.Errorf("cannot get average decision time: %w", err)
+	}
+
+	if average.Valid {
+		stats.AverageDecisionHours = &average.Float64
+	}
+
+	return stats, nil
+}
+
+// GetLeaderboard returns referrers ranked by number of requests accepted in [from, to).
+// Users who have hidden themselves from leaderboard are skipped.
+func (r *Repository) GetLeaderboard(from, to time.Time, limit int) ([]LeaderboardEntry, error) {
+	query := `SELECT
+				RANK() OVER (ORDER BY COUNT(*) DESC), users.name, COUNT(*)
+			  FROM
+			  	requests
+			  JOIN
+			  	users ON users.id = requests.author_id
+			  WHERE
+			  	requests.status = $1 AND requests.decided_at >= $2 AND requests.decided_at < $3
+			  	AND NOT users.hide_from_leaderboard
+			  GROUP BY
+			  	users.id, users.name
+			  ORDER BY
+			  	1, users.name
+			  LIMIT $4;`
+
+	rows, err := r.db.Query(query, StatusAccepted, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []LeaderboardEntry{}
+	for rows.Next() {
+		var entry LeaderboardEntry
+
+		if err := rows.Scan(&entry.Rank, &entry.Name, &entry.Accepted); err != nil {
+			return nil, fmt.Errorf("cannot get leaderboard entry: %w", err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return entries, nil
+}
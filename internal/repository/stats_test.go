@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_GetStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	from := time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2022, 1, 17, 0, 0, 0, 0, time.UTC)
+	median := 36.0
+
+	mock.ExpectQuery("SELECT (.+) FROM requests WHERE (.+) AND status NOT IN (.+) GROUP BY").WithArgs(from, to, unspecifiedGroup).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+			AddRow(StatusSubmitted, 2).AddRow(StatusAccepted, 1).AddRow(StatusRejected, 1))
+	mock.ExpectQuery("SELECT (.+) FROM requests WHERE (.+) AND status NOT IN (.+) GROUP BY").WithArgs(from, to, unspecifiedGroup).
+		WillReturnRows(sqlmock.NewRows([]string{"position", "count"}).AddRow("developer", 3).AddRow(unspecifiedGroup, 1))
+	mock.ExpectQuery("SELECT (.+) FROM requests WHERE (.+) AND status NOT IN (.+) GROUP BY").WithArgs(from, to, unspecifiedGroup).
+		WillReturnRows(sqlmock.NewRows([]string{"department", "count"}).AddRow(unspecifiedGroup, 4))
+	mock.ExpectQuery("SELECT (.+) FROM requests JOIN users (.+) AND status NOT IN (.+) GROUP BY").WithArgs(from, to, StatusAccepted).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "total", "accepted"}).AddRow(defaultID, defaultName, 4, 1))
+	mock.ExpectQuery("SELECT PERCENTILE_CONT").WithArgs(from, to, StatusAccepted, StatusRejected).
+		WillReturnRows(sqlmock.NewRows([]string{"median"}).AddRow(median))
+	mock.ExpectQuery("SELECT (.+) FROM GENERATE_SERIES(.+) AND status NOT IN (.+) GROUP BY").WithArgs(from, to, StatusAccepted, StatusRejected).
+		WillReturnRows(sqlmock.NewRows([]string{"week", "total", "accepted", "rejected"}).
+			AddRow(from, 3, 1, 0).AddRow(from.AddDate(0, 0, 7), 1, 0, 1))
+
+	r := NewRepository(db)
+
+	stats, err := r.GetStats(from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, Stats{
+		From:                from,
+		To:                  to,
+		Total:               4,
+		ByStatus:            map[string]int{StatusSubmitted: 2, StatusAccepted: 1, StatusRejected: 1},
+		ByPosition:          map[string]int{"developer": 3, unspecifiedGroup: 1},
+		ByDepartment:        map[string]int{unspecifiedGroup: 4},
+		ByReferrer:          []ReferrerStats{{ID: defaultID, Name: defaultName, Total: 4, Accepted: 1}},
+		ConversionRate:      0.25,
+		AcceptanceRate:      0.5,
+		MedianDecisionHours: &median,
+		Weekly: []WeeklyRequests{
+			{Week: from, Total: 3, Accepted: 1},
+			{Week: from.AddDate(0, 0, 7), Total: 1, Rejected: 1},
+		},
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GetUserStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	average := 12.5
+
+	mock.ExpectQuery("SELECT status, COUNT(.+) FROM requests WHERE author_id").WithArgs(defaultID).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow(StatusSubmitted, 1).AddRow(StatusAccepted, 2))
+	mock.ExpectQuery("SELECT AVG").WithArgs(defaultID, StatusAccepted, StatusRejected).
+		WillReturnRows(sqlmock.NewRows([]string{"avg"}).AddRow(average))
+
+	r := NewRepository(db)
+
+	stats, err := r.GetUserStats(defaultID)
+	assert.NoError(t, err)
+	assert.Equal(t, UserStats{
+		Total:                3,
+		ByStatus:             map[string]int{StatusSubmitted: 1, StatusAccepted: 2},
+		AverageDecisionHours: &average,
+	}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
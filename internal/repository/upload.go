@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoUpload presents an error when upload doesn't exist, has expired or has been used.
+var ErrNoUpload = errors.New("there is no upload with input id")
+
+// Upload presents a slot for a file uploaded directly to object storage. The slot is used
+// by a single request until it expires.
+type Upload struct {
+	ID          string
+	UserID      string
+	Key         string
+	Filename    string
+	ContentType string
+	Size        int64
+	Expires     time.Time
+
+	// SHA256 presents SHA-256 checksum of the file in hex, object storage rejects uploads of other content.
+	SHA256 string
+}
+
+// AddUpload adds upload slot.
+func (r *Repository) AddUpload(u Upload) error {
+	query := `INSERT INTO
+				uploads (id, user_id, file_key, filename, content_type, size, sha256, expires)
+			  VALUES
+			  	($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := r.db.Exec(query, u.ID, u.UserID, u.Key, u.Filename, u.ContentType, u.Size, u.SHA256, u.Expires)
+	if err != nil {
+		return fmt.Errorf("cannot add upload: %w", err)
+	}
+
+	return nil
+}
+
+// GetUpload returns upload slot of the user which isn't used and hasn't expired.
+func (r *Repository) GetUpload(id, userID string) (Upload, error) {
+	var u Upload
+
+	query := `SELECT
+				id, user_id, file_key, filename, content_type, size, sha256, expires
+			  FROM
+			  	uploads
+			  WHERE
+			  	id = $1 AND user_id = $2 AND used IS NULL AND expires > CURRENT_TIMESTAMP`
+
+	err := r.db.QueryRow(query, id, userID).Scan(&u.ID, &u.UserID, &u.Key, &u.Filename, &u.ContentType, &u.Size, &u.SHA256, &u.Expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Upload{}, ErrNoUpload
+	}
+	if err != nil {
+		return Upload{}, fmt.Errorf("cannot get upload: %w", err)
+	}
+
+	return u, nil
+}
+
+// GetExpiredUploads returns upload slots which have expired without being used, the oldest first.
+func (r *Repository) GetExpiredUploads(limit int) ([]Upload, error) {
+	query := `SELECT
+				id, user_id, file_key, filename, content_type, size, sha256, expires
+			  FROM
+			  	uploads
+			  WHERE
+			  	used IS NULL AND expires <= CURRENT_TIMESTAMP
+			  ORDER BY
+			  	expires
+			  LIMIT $1`
+
+	rows, err := r.db.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get expired uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var uploads []Upload
+	for rows.Next() {
+		var u Upload
+		if err := rows.Scan(&u.ID, &u.UserID, &u.Key, &u.Filename, &u.ContentType, &u.Size, &u.SHA256, &u.Expires); err != nil {
+			return nil, fmt.Errorf("cannot scan upload: %w", err)
+		}
+
+		uploads = append(uploads, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return uploads, nil
+}
+
+// DeleteUpload deletes upload slot which has expired without being used.
+func (r *Repository) DeleteUpload(id string) error {
+	query := `DELETE FROM
+				uploads
+			  WHERE
+			  	id = $1 AND used IS NULL AND expires <= CURRENT_TIMESTAMP`
+
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("cannot delete upload %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// DeleteUsedUploads deletes expired upload slots which have been used, their files are kept by requests.
+func (r *Repository) DeleteUsedUploads() (int, error) {
+	query := `DELETE FROM
+				uploads
+			  WHERE
+			  	used IS NOT NULL AND expires <= CURRENT_TIMESTAMP`
+
+	res, err := r.db.Exec(query)
+	if err != nil {
+		return 0, fmt.Errorf("cannot delete used uploads: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+
+	return int(n), nil
+}
+
+// useUpload marks upload slot as used, so it can't be used by another request.
+func useUpload(tx *sql.Tx, id string) error {
+	query := `UPDATE
+				uploads
+			  SET
+			  	used = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $1 AND used IS NULL AND expires > CURRENT_TIMESTAMP`
+
+	res, err := tx.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("cannot use upload %s: %w", id, err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNoUpload
+	}
+
+	return nil
+}
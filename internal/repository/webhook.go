@@ -0,0 +1,367 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+)
+
+const (
+	// DeliveryPending presents status of a delivery which waits for the next attempt.
+	DeliveryPending = "pending"
+
+	// DeliveryDelivered presents status of a delivery accepted by endpoint.
+	DeliveryDelivered = "delivered"
+
+	// DeliveryFailed presents status of a delivery which ran out of attempts.
+	DeliveryFailed = "failed"
+
+	maxDeliveries = 100
+)
+
+// ErrNoWebhook presents an error when there is no webhook with the id.
+var ErrNoWebhook = errors.New("there is no webhook with the id")
+
+// Webhook presents a subscription of an endpoint to events. Secret is never returned to clients.
+type Webhook struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events"`
+	Secret  string    `json:"secret,omitempty"`
+	Enabled bool      `json:"enabled"`
+	Created time.Time `json:"created"`
+}
+
+// Delivery presents delivery of an event to a webhook.
+type Delivery struct {
+	ID           string          `json:"id"`
+	WebhookID    string          `json:"webhookId"`
+	Event        string          `json:"event"`
+	Payload      json.RawMessage `json:"payload"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	NextAttempt  *time.Time      `json:"nextAttempt"`
+	ResponseCode *int            `json:"responseCode"`
+	LastError    string          `json:"lastError"`
+	Created      time.Time       `json:"created"`
+	Updated      time.Time       `json:"updated"`
+}
+
+// DeliveryFilter presents filter of webhook deliveries.
+type DeliveryFilter struct {
+	WebhookID string
+	Status    string
+}
+
+// DueDelivery presents a pending delivery together with endpoint it's sent to.
+type DueDelivery struct {
+	ID       string
+	Event    string
+	Payload  []byte
+	Attempts int
+	URL      string
+	Secret   string
+}
+
+// GetWebhooks returns all webhooks without their secrets.
+func (r *Repository) GetWebhooks() ([]Webhook, error) {
+	query := `SELECT
+				id, url, events, enabled, created
+			  FROM
+			  	webhooks
+			  ORDER BY
+			  	id;`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		var webhook Webhook
+
+		if err := rows.Scan(&webhook.ID, &webhook.URL, pq.Array(&webhook.Events), &webhook.Enabled, &webhook.Created); err != nil {
+			return nil, fmt.Errorf("cannot get webhook: %w", err)
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// AddWebhook creates webhook and returns its id.
+func (r *Repository) AddWebhook(webhook Webhook) (string, error) {
+	query := `INSERT INTO
+				webhooks (url, events, secret, enabled)
+			  VALUES
+			  	($1, $2, $3, $4)
+			  RETURNING
+			  	id;`
+
+	var id string
+	if err := r.db.QueryRow(query, webhook.URL, pq.Array(webhook.Events), webhook.Secret, webhook.Enabled).Scan(&id); err != nil {
+		return "", fmt.Errorf("cannot add webhook: %w", err)
+	}
+
+	return id, nil
+}
+
+// UpdateWebhook updates webhook, secret is kept if the new one is empty.
+func (r *Repository) UpdateWebhook(webhook Webhook) error {
+	query := `UPDATE
+				webhooks
+			  SET
+			  	url = $1,
+			  	events = $2,
+			  	secret = COALESCE(NULLIF($3, ''), secret),
+			  	enabled = $4,
+			  	updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $5;`
+
+	rows, err := r.db.Exec(query, webhook.URL, pq.Array(webhook.Events), webhook.Secret, webhook.Enabled, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("cannot update webhook: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoWebhook
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes webhook together with its deliveries.
+func (r *Repository) DeleteWebhook(id string) error {
+	rows, err := r.db.Exec("DELETE FROM webhooks WHERE id = $1;", id)
+	if err != nil {
+		return fmt.Errorf("cannot delete webhook: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoWebhook
+	}
+
+	return nil
+}
+
+// AddDeliveries queues delivery of the event to every enabled webhook subscribed to it.
+// Event is queued only once per webhook, so it's safe to add deliveries of the same event again.
+func (r *Repository) AddDeliveries(eventID, name string, payload []byte) error {
+	query := `INSERT INTO
+				webhook_deliveries (webhook_id, event_id, event, payload)
+			  SELECT
+			  	id, $1, $2, $3
+			  FROM
+			  	webhooks
+			  WHERE
+			  	enabled AND $2 = ANY(events)
+			  ON CONFLICT (webhook_id, event_id) DO NOTHING;`
+
+	if _, err := r.db.Exec(query, eventID, name, string(payload)); err != nil {
+		return fmt.Errorf("cannot add %s deliveries: %w", name, err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries which match the filter, the newest go first.
+func (r *Repository) GetDeliveries(filter DeliveryFilter) ([]Delivery, error) {
+	query := psql.
+		Select("id", "webhook_id", "event", "payload", "status", "attempts", "next_attempt", "response_code", "last_error", "created", "updated").
+		From("webhook_deliveries").
+		OrderBy("id DESC").
+		Limit(maxDeliveries)
+
+	if filter.WebhookID != "" {
+		query = query.Where(sq.Eq{"webhook_id": filter.WebhookID})
+	}
+	if filter.Status != "" {
+		query = query.Where(sq.Eq{"status": filter.Status})
+	}
+
+	sqlQuery, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		var delivery Delivery
+		var payload string
+		var nextAttempt sql.NullTime
+		var responseCode sql.NullInt32
+
+		if err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.Event,
+			&payload,
+			&delivery.Status,
+			&delivery.Attempts,
+			&nextAttempt,
+			&responseCode,
+			&delivery.LastError,
+			&delivery.Created,
+			&delivery.Updated,
+		); err != nil {
+			return nil, fmt.Errorf("cannot get delivery: %w", err)
+		}
+
+		delivery.Payload = json.RawMessage(payload)
+		if nextAttempt.Valid {
+			delivery.NextAttempt = &nextAttempt.Time
+		}
+		if responseCode.Valid {
+			code := int(responseCode.Int32)
+			delivery.ResponseCode = &code
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDueDeliveries claims at most limit pending deliveries to enabled webhooks which are due at the time,
+// the oldest go first. Claimed deliveries aren't due for other senders until the lease expires,
+// deliveries locked by another sender are skipped.
+func (r *Repository) ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]DueDelivery, error) {
+	query := `WITH due AS (
+				SELECT
+					webhook_deliveries.id
+				FROM
+					webhook_deliveries
+					JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+				WHERE
+					webhook_deliveries.status = $1 AND
+					webhook_deliveries.next_attempt <= $2 AND
+					webhooks.enabled
+				ORDER BY
+					webhook_deliveries.next_attempt, webhook_deliveries.id
+				LIMIT $3
+				FOR UPDATE OF webhook_deliveries SKIP LOCKED
+			  ), claimed AS (
+				UPDATE
+					webhook_deliveries
+				SET
+					next_attempt = $4
+				FROM
+					due
+				WHERE
+					webhook_deliveries.id = due.id
+				RETURNING
+					webhook_deliveries.id,
+					webhook_deliveries.webhook_id,
+					webhook_deliveries.event,
+					webhook_deliveries.payload,
+					webhook_deliveries.attempts
+			  )
+			  SELECT
+			  	claimed.id,
+			  	claimed.event,
+			  	claimed.payload,
+			  	claimed.attempts,
+			  	webhooks.url,
+			  	webhooks.secret
+			  FROM
+			  	claimed
+			  	JOIN webhooks ON webhooks.id = claimed.webhook_id
+			  ORDER BY
+			  	claimed.id;`
+
+	rows, err := r.db.Query(query, DeliveryPending, now, limit, leaseUntil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot claim due deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []DueDelivery
+	for rows.Next() {
+		var delivery DueDelivery
+		var payload string
+
+		if err := rows.Scan(&delivery.ID, &delivery.Event, &payload, &delivery.Attempts, &delivery.URL, &delivery.Secret); err != nil {
+			return nil, fmt.Errorf("cannot get due delivery: %w", err)
+		}
+
+		delivery.Payload = []byte(payload)
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error with result set: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// SaveDeliveryAttempt records result of an attempt. Next attempt is nil if delivery is finished.
+func (r *Repository) SaveDeliveryAttempt(id, status string, attempts int, nextAttempt *time.Time, responseCode int, lastError string) error {
+	query := `UPDATE
+				webhook_deliveries
+			  SET
+			  	status = $1,
+			  	attempts = $2,
+			  	next_attempt = $3,
+			  	response_code = NULLIF($4, 0),
+			  	last_error = $5,
+			  	updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $6;`
+
+	if _, err := r.db.Exec(query, status, attempts, nextAttempt, responseCode, lastError, id); err != nil {
+		return fmt.Errorf("cannot save delivery attempt: %w", err)
+	}
+
+	return nil
+}
+
+// Redeliver queues the delivery again with a fresh set of attempts.
+func (r *Repository) Redeliver(id string, now time.Time) error {
+	query := `UPDATE
+				webhook_deliveries
+			  SET
+			  	status = $1,
+			  	attempts = 0,
+			  	next_attempt = $2,
+			  	updated = CURRENT_TIMESTAMP
+			  WHERE
+			  	id = $3;`
+
+	rows, err := r.db.Exec(query, DeliveryPending, now, id)
+	if err != nil {
+		return fmt.Errorf("cannot redeliver: %w", err)
+	}
+
+	n, _ := rows.RowsAffected()
+	if n == 0 {
+		return ErrNoResult
+	}
+
+	return nil
+}
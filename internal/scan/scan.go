@@ -0,0 +1,167 @@
+// Package scan implements malware scanning of uploaded files.
+package scan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+const (
+	// DriverNone disables scanning.
+	DriverNone = "none"
+
+	// DriverClamd presents scanning by ClamAV daemon.
+	DriverClamd = "clamd"
+
+	chunkSize = 64 << 10
+
+	instreamCommand = "zINSTREAM\x00"
+	okReply         = "OK"
+	foundSuffix     = " FOUND"
+	errorSuffix     = " ERROR"
+)
+
+// ErrScan presents an error when scanner can't give a verdict about the file.
+var ErrScan = errors.New("cannot scan file")
+
+// ErrUnavailable presents an error when scanner can't be reached, so it can't give a verdict about any file.
+var ErrUnavailable = fmt.Errorf("%w: scanner is unavailable", ErrScan)
+
+type config struct {
+	Driver       string        `envconfig:"SCAN_DRIVER" default:"none"`
+	ClamdAddress string        `envconfig:"SCAN_CLAMD_ADDRESS" default:"localhost:3310"`
+	Timeout      time.Duration `envconfig:"SCAN_TIMEOUT" default:"1m"`
+}
+
+// Result presents verdict of scanner about a file.
+type Result struct {
+	Infected bool
+
+	// Signature presents name of found malware.
+	Signature string
+}
+
+// Scanner scans files for malware.
+type Scanner interface {
+	Scan(r io.Reader) (Result, error)
+}
+
+// NewScanner creates scanner selected by SCAN_DRIVER environment variable, nil is returned if scanning is disabled.
+func NewScanner() (Scanner, error) {
+	var cfg config
+
+	if err := envconfig.Process("scan", &cfg); err != nil {
+		return nil, fmt.Errorf("error with config loading: %w", err)
+	}
+
+	switch cfg.Driver {
+	case DriverNone:
+		return nil, nil
+	case DriverClamd:
+		return NewClamd(cfg.ClamdAddress, cfg.Timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown scan driver %q", cfg.Driver)
+	}
+}
+
+// Clamd scans files by ClamAV daemon with INSTREAM command.
+type Clamd struct {
+	Network string
+	Address string
+	Timeout time.Duration
+}
+
+// NewClamd creates Clamd which connects to the address, addresses starting with slash are unix sockets.
+func NewClamd(address string, timeout time.Duration) *Clamd {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+
+	return &Clamd{Network: network, Address: address, Timeout: timeout}
+}
+
+// Scan streams content to the daemon and returns its verdict.
+func (c *Clamd) Scan(r io.Reader) (Result, error) {
+	conn, err := net.DialTimeout(c.Network, c.Address, c.Timeout)
+	if err != nil {
+		return Result{}, fmt.Errorf("%w: cannot connect to clamd: %s", ErrUnavailable, err)
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return Result{}, fmt.Errorf("%w: %s", ErrScan, err)
+	}
+
+	if err := writeStream(conn, r); err != nil {
+		return Result{}, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Result{}, fmt.Errorf("%w: cannot read clamd reply: %s", ErrScan, err)
+	}
+
+	return parseReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// writeStream sends INSTREAM command with content split into length-prefixed chunks.
+func writeStream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, instreamCommand); err != nil {
+		return fmt.Errorf("%w: cannot send command to clamd: %s", ErrScan, err)
+	}
+
+	buf := make([]byte, chunkSize)
+	size := make([]byte, 4)
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+
+			if _, err := w.Write(append(size, buf[:n]...)); err != nil {
+				return fmt.Errorf("%w: cannot send file to clamd: %s", ErrScan, err)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read file: %w", err)
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := w.Write(size); err != nil {
+		return fmt.Errorf("%w: cannot send file to clamd: %s", ErrScan, err)
+	}
+
+	return nil
+}
+
+// parseReply parses reply like "stream: OK" or "stream: Eicar-Signature FOUND".
+func parseReply(reply string) (Result, error) {
+	verdict := reply
+	if i := strings.Index(reply, ": "); i >= 0 {
+		verdict = reply[i+2:]
+	}
+
+	switch {
+	case verdict == okReply:
+		return Result{}, nil
+	case strings.HasSuffix(verdict, foundSuffix):
+		return Result{Infected: true, Signature: strings.TrimSuffix(verdict, foundSuffix)}, nil
+	case strings.HasSuffix(verdict, errorSuffix):
+		return Result{}, fmt.Errorf("%w: clamd error: %s", ErrScan, strings.TrimSuffix(verdict, errorSuffix))
+	default:
+		return Result{}, fmt.Errorf("%w: unexpected clamd reply %q", ErrScan, reply)
+	}
+}
@@ -0,0 +1,135 @@
+package scan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd is a stand-in of ClamAV daemon which reads INSTREAM command and finds EICAR test string.
+func fakeClamd(t *testing.T, reply func(content string) string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				r := bufio.NewReader(conn)
+				command, err := r.ReadString(0)
+				if err != nil || command != instreamCommand {
+					io.WriteString(conn, "UNKNOWN COMMAND\x00")
+					return
+				}
+
+				var content strings.Builder
+				for {
+					var size uint32
+					if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&content, r, int64(size)); err != nil {
+						return
+					}
+				}
+
+				io.WriteString(conn, reply(content.String())+"\x00")
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func clamdReply(content string) string {
+	if strings.Contains(content, eicar) {
+		return "stream: Eicar-Test-Signature FOUND"
+	}
+
+	return "stream: OK"
+}
+
+func TestClamd_Scan(t *testing.T) {
+	address := fakeClamd(t, clamdReply)
+
+	testTable := []struct {
+		testName       string
+		content        string
+		expectedResult Result
+	}{
+		{
+			testName: "Clean file",
+			content:  "%PDF-1.7\n",
+		},
+		{
+			testName:       "Infected file",
+			content:        "%PDF-1.7\n" + eicar,
+			expectedResult: Result{Infected: true, Signature: "Eicar-Test-Signature"},
+		},
+		{
+			testName: "File larger than chunk",
+			content:  strings.Repeat("a", 3*chunkSize+1),
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			result, err := NewClamd(address, time.Second).Scan(strings.NewReader(tc.content))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedResult, result)
+		})
+	}
+}
+
+func TestClamd_Scan_Error(t *testing.T) {
+	address := fakeClamd(t, func(string) string { return "INSTREAM size limit exceeded. ERROR" })
+
+	_, err := NewClamd(address, time.Second).Scan(strings.NewReader("content"))
+	assert.ErrorIs(t, err, ErrScan)
+	assert.NotErrorIs(t, err, ErrUnavailable)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	closed := listener.Addr().String()
+	listener.Close()
+
+	_, err = NewClamd(closed, time.Second).Scan(strings.NewReader("content"))
+	assert.ErrorIs(t, err, ErrScan)
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestParseReply(t *testing.T) {
+	result, err := parseReply("stream: OK")
+	assert.NoError(t, err)
+	assert.False(t, result.Infected)
+
+	result, err = parseReply("stream: Win.Test.EICAR_HDB-1 FOUND")
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Infected: true, Signature: "Win.Test.EICAR_HDB-1"}, result)
+
+	_, err = parseReply("")
+	assert.ErrorIs(t, err, ErrScan)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+)
+
+// ErrNoRecruiter presents an error when request is assigned to a user who isn't a recruiter.
+var ErrNoRecruiter = errors.New("assignee must be an admin")
+
+var assignmentStrategies = map[string]bool{
+	repository.AssignmentManual:      true,
+	repository.AssignmentRoundRobin:  true,
+	repository.AssignmentLeastLoaded: true,
+}
+
+// ValidateAssignmentRule validates assignment rule, rule with empty position is applied by default.
+func ValidateAssignmentRule(rule *repository.AssignmentRule) error {
+	rule.Position = strings.TrimSpace(rule.Position)
+	rule.Strategy = strings.ToLower(strings.TrimSpace(rule.Strategy))
+
+	if len(rule.Position) > maxPositionLength {
+		return fmt.Errorf("%w: position must be at most %d symbols", ErrInvalidParameter, maxPositionLength)
+	}
+
+	if !assignmentStrategies[rule.Strategy] {
+		return fmt.Errorf("%w: assignment strategy", ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// GetAssignmentRules returns all assignment rules.
+func (s *ReferralService) GetAssignmentRules() ([]repository.AssignmentRule, error) {
+	rules, err := s.repo.GetAssignmentRules()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get assignment rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SaveAssignmentRule creates or replaces assignment rule of the position.
+func (s *ReferralService) SaveAssignmentRule(rule repository.AssignmentRule) error {
+	if err := ValidateAssignmentRule(&rule); err != nil {
+		return err
+	}
+
+	if err := s.repo.SaveAssignmentRule(rule); err != nil {
+		return fmt.Errorf("cannot save assignment rule: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecruiters returns all recruiters with their positions and workload.
+func (s *ReferralService) GetRecruiters() ([]repository.Recruiter, error) {
+	recruiters, err := s.repo.GetRecruiters()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get recruiters: %w", err)
+	}
+
+	return recruiters, nil
+}
+
+// SetRecruiterPositions replaces positions the recruiter reviews, empty position means any position.
+func (s *ReferralService) SetRecruiterPositions(userID string, positions []string) error {
+	for i, position := range positions {
+		positions[i] = strings.TrimSpace(position)
+		if len(positions[i]) > maxPositionLength {
+			return fmt.Errorf("%w: position must be at most %d symbols", ErrInvalidParameter, maxPositionLength)
+		}
+	}
+
+	err := s.repo.SetRecruiterPositions(userID, positions)
+	if errors.Is(err, repository.ErrNoUser) {
+		return ErrNoUser
+	}
+	if errors.Is(err, repository.ErrNoRecruiter) {
+		return ErrNoRecruiter
+	}
+	if err != nil {
+		return fmt.Errorf("cannot set recruiter positions: %w", err)
+	}
+
+	return nil
+}
+
+// AssignRequest assigns request to the recruiter or reassigns it, empty assignee makes request unassigned.
+func (s *ReferralService) AssignRequest(id, assigneeID string) error {
+	err := s.repo.AssignRequest(id, assigneeID)
+	if errors.Is(err, repository.ErrNoResult) {
+		return ErrNoResult
+	}
+	if errors.Is(err, repository.ErrNoUser) {
+		return ErrNoUser
+	}
+	if errors.Is(err, repository.ErrNoRecruiter) {
+		return ErrNoRecruiter
+	}
+	if err != nil {
+		return fmt.Errorf("cannot assign request: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+)
+
+const maxProbationDays = 366
+
+var (
+	// ErrNoBonusRule presents an error when there is no bonus rule for a position.
+	ErrNoBonusRule = errors.New("there is no bonus rule for the position")
+
+	// ErrInvalidBonusTransition presents an error when bonus cannot be moved to the requested status.
+	ErrInvalidBonusTransition = errors.New("invalid bonus status transition")
+
+	// ErrBonusNotEligible presents an error when bonus is approved before the end of probation.
+	ErrBonusNotEligible = errors.New("bonus is not eligible yet")
+
+	amountExp   = regexp.MustCompile(`^\d{1,10}(\.\d{1,2})?$`)
+	currencyExp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+	// bonusTransitions presents statuses bonus can be moved to from a particular status.
+	bonusTransitions = map[string][]string{
+		repository.BonusPending:  {repository.BonusApproved, repository.BonusCancelled},
+		repository.BonusApproved: {repository.BonusPaid, repository.BonusCancelled},
+	}
+)
+
+// ValidateBonusRule validates bonus rule and normalizes its currency.
+func ValidateBonusRule(rule *repository.BonusRule) error {
+	rule.Position = strings.TrimSpace(rule.Position)
+	rule.Currency = strings.ToUpper(strings.TrimSpace(rule.Currency))
+
+	if len(rule.Position) > maxPositionLength {
+		return fmt.Errorf("%w: position must be at most %d symbols", ErrInvalidParameter, maxPositionLength)
+	}
+
+	if !amountExp.MatchString(rule.Amount) {
+		return fmt.Errorf("%w: amount must be a non-negative number with at most 2 decimal places", ErrInvalidParameter)
+	}
+
+	if !currencyExp.MatchString(rule.Currency) {
+		return fmt.Errorf("%w: currency must be ISO 4217 code", ErrInvalidParameter)
+	}
+
+	if rule.ProbationDays < 0 || rule.ProbationDays > maxProbationDays {
+		return fmt.Errorf("%w: probation days must be in range [0, %d]", ErrInvalidParameter, maxProbationDays)
+	}
+
+	return nil
+}
+
+// GetBonusRules returns all bonus rules.
+func (s *ReferralService) GetBonusRules() ([]repository.BonusRule, error) {
+	rules, err := s.repo.GetBonusRules()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get bonus rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SaveBonusRule creates or replaces bonus rule of the position, rule with empty position is applied by default.
+func (s *ReferralService) SaveBonusRule(rule repository.BonusRule) error {
+	if err := ValidateBonusRule(&rule); err != nil {
+		return err
+	}
+
+	if err := s.repo.SaveBonusRule(rule); err != nil {
+		return fmt.Errorf("cannot save bonus rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteBonusRule deletes bonus rule of the position.
+func (s *ReferralService) DeleteBonusRule(position string) error {
+	err := s.repo.DeleteBonusRule(position)
+	if errors.Is(err, repository.ErrNoBonusRule) {
+		return ErrNoBonusRule
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete bonus rule: %w", err)
+	}
+
+	return nil
+}
+
+// GetBonuses returns payout ledger entries which match the filter.
+func (s *ReferralService) GetBonuses(filter repository.BonusFilter) ([]repository.Bonus, error) {
+	bonuses, err := s.repo.GetBonuses(filter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get bonuses: %w", err)
+	}
+
+	return bonuses, nil
+}
+
+// ExportBonuses calls fn for every payout ledger entry which matches the filter.
+func (s *ReferralService) ExportBonuses(filter repository.BonusFilter, fn func(repository.Bonus) error) error {
+	if err := s.repo.ExportBonuses(filter, fn); err != nil {
+		return fmt.Errorf("cannot export bonuses: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateBonus moves bonus to a new status: pending bonuses are approved once eligible,
+// approved ones are marked as paid, unpaid bonuses can be cancelled.
+func (s *ReferralService) UpdateBonus(id, status string, now time.Time) error {
+	bonus, err := s.repo.GetBonus(id)
+	if errors.Is(err, repository.ErrNoResult) {
+		return ErrNoResult
+	}
+	if err != nil {
+		return fmt.Errorf("cannot get bonus: %w", err)
+	}
+
+	if !canMoveBonus(bonus.Status, status) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidBonusTransition, bonus.Status, status)
+	}
+
+	if status == repository.BonusApproved && now.Before(bonus.EligibleAt) {
+		return fmt.Errorf("%w: eligible at %s", ErrBonusNotEligible, bonus.EligibleAt.Format(time.RFC3339))
+	}
+
+	err = s.repo.UpdateBonusStatus(id, bonus.Status, status)
+	if errors.Is(err, repository.ErrNoResult) {
+		return fmt.Errorf("%w: bonus status was changed concurrently", ErrInvalidBonusTransition)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update bonus: %w", err)
+	}
+
+	return nil
+}
+
+func canMoveBonus(from, to string) bool {
+	for _, status := range bonusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var bonusColumns = []string{
+	"id", "request_id", "user_id", "name", "candidate_name", "candidate_surname", "position",
+	"amount", "currency", "status", "eligible_at", "approved_at", "paid_at", "created",
+}
+
+func TestReferralService_UpdateBonus(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	bonusRow := func(status string, eligibleAt time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows(bonusColumns).AddRow(
+			"1", "10", "2", "user1", "Billie", "Jean", "developer",
+			"500.00", "USD", status, eligibleAt, nil, nil, now.AddDate(0, -3, 0),
+		)
+	}
+
+	testTable := []struct {
+		testName      string
+		status        string
+		expectedError error
+		mock          func(mock sqlmock.Sqlmock)
+	}{
+		{
+			testName: "Success: approve eligible bonus",
+			status:   repository.BonusApproved,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusPending, now.AddDate(0, 0, -1)))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE bonuses").
+					WithArgs(repository.BonusApproved, "1", repository.BonusPending, repository.BonusApproved, repository.BonusPaid).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusApproved, now.AddDate(0, 0, -1)))
+				mock.ExpectExec("INSERT INTO outbox").
+					WithArgs(event.BonusStatusChanged, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName: "Success: mark approved bonus as paid",
+			status:   repository.BonusPaid,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusApproved, now.AddDate(0, 0, -1)))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE bonuses").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusPaid, now.AddDate(0, 0, -1)))
+				mock.ExpectExec("INSERT INTO outbox").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			testName:      "Failure: probation is not over",
+			status:        repository.BonusApproved,
+			expectedError: ErrBonusNotEligible,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusPending, now.AddDate(0, 0, 1)))
+			},
+		},
+		{
+			testName:      "Failure: pending bonus cannot be paid",
+			status:        repository.BonusPaid,
+			expectedError: ErrInvalidBonusTransition,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusPending, now.AddDate(0, 0, -1)))
+			},
+		},
+		{
+			testName:      "Failure: paid bonus cannot be cancelled",
+			status:        repository.BonusCancelled,
+			expectedError: ErrInvalidBonusTransition,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusPaid, now.AddDate(0, 0, -1)))
+			},
+		},
+		{
+			testName:      "Failure: status was changed concurrently",
+			status:        repository.BonusCancelled,
+			expectedError: ErrInvalidBonusTransition,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(bonusRow(repository.BonusPending, now.AddDate(0, 0, 1)))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE bonuses").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			testName:      "Failure: bonus doesn't exist",
+			status:        repository.BonusApproved,
+			expectedError: ErrNoResult,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM bonuses").WithArgs("1").
+					WillReturnRows(sqlmock.NewRows(bonusColumns))
+			},
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			tc.mock(mock)
+
+			s := NewReferralService(repository.NewRepository(db), nil)
+
+			err = s.UpdateBonus("1", tc.status, now)
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestValidateBonusRule(t *testing.T) {
+	testTable := []struct {
+		testName      string
+		rule          repository.BonusRule
+		expectedRule  repository.BonusRule
+		expectedError error
+	}{
+		{
+			testName:     "Success",
+			rule:         repository.BonusRule{Position: " developer ", Amount: "1500.50", Currency: "usd", ProbationDays: 90},
+			expectedRule: repository.BonusRule{Position: "developer", Amount: "1500.50", Currency: "USD", ProbationDays: 90},
+		},
+		{
+			testName:     "Success: default rule",
+			rule:         repository.BonusRule{Amount: "500", Currency: "EUR"},
+			expectedRule: repository.BonusRule{Amount: "500", Currency: "EUR"},
+		},
+		{
+			testName:      "Failure: negative amount",
+			rule:          repository.BonusRule{Amount: "-1", Currency: "USD"},
+			expectedError: ErrInvalidParameter,
+		},
+		{
+			testName:      "Failure: too precise amount",
+			rule:          repository.BonusRule{Amount: "1.005", Currency: "USD"},
+			expectedError: ErrInvalidParameter,
+		},
+		{
+			testName:      "Failure: unknown currency format",
+			rule:          repository.BonusRule{Amount: "1", Currency: "dollars"},
+			expectedError: ErrInvalidParameter,
+		},
+		{
+			testName:      "Failure: negative probation",
+			rule:          repository.BonusRule{Amount: "1", Currency: "USD", ProbationDays: -1},
+			expectedError: ErrInvalidParameter,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := ValidateBonusRule(&tc.rule)
+			assert.ErrorIs(t, err, tc.expectedError)
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.expectedRule, tc.rule)
+			}
+		})
+	}
+}
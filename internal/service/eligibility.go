@@ -0,0 +1,308 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+)
+
+const (
+	// RuleSelfReferral forbids referring yourself.
+	RuleSelfReferral = "self_referral"
+
+	// RuleCurrentEmployee forbids referring current employees.
+	RuleCurrentEmployee = "current_employee"
+
+	// RuleDuplicateCandidate forbids referring the same candidate twice within the number of days set in rule value.
+	RuleDuplicateCandidate = "duplicate_candidate"
+
+	// RuleReferrerTenure requires referrer to be employed at least the number of days set in rule value.
+	RuleReferrerTenure = "referrer_tenure"
+)
+
+var (
+	// ErrNotEligible presents an error when referral violates an eligibility rule.
+	ErrNotEligible = errors.New("referral is not eligible")
+
+	// ErrUnknownEligibilityRule presents an error when there is no check with the rule code.
+	ErrUnknownEligibilityRule = errors.New("unknown eligibility rule")
+
+	eligibilityActions = map[string]bool{
+		repository.EligibilityReject:  true,
+		repository.EligibilityNoBonus: true,
+	}
+
+	// eligibilityChecksMu guards eligibilityChecks, checks may be registered while referrals are checked.
+	eligibilityChecksMu sync.RWMutex
+
+	// eligibilityChecks presents registered checks in order of evaluation.
+	eligibilityChecks = []EligibilityCheck{
+		EligibilityCheckFunc{RuleCode: RuleSelfReferral, Func: checkSelfReferral},
+		EligibilityCheckFunc{RuleCode: RuleCurrentEmployee, Func: checkCurrentEmployee},
+		EligibilityCheckFunc{RuleCode: RuleDuplicateCandidate, Func: checkDuplicateCandidate},
+		EligibilityCheckFunc{RuleCode: RuleReferrerTenure, Func: checkReferrerTenure},
+	}
+)
+
+// EligibilityError presents a violation of a particular eligibility rule.
+type EligibilityError struct {
+	Code    string
+	Message string
+}
+
+// Error returns description of the violation.
+func (e *EligibilityError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrNotEligible, e.Message)
+}
+
+// Unwrap returns ErrNotEligible, so all violations are matched by errors.Is.
+func (e *EligibilityError) Unwrap() error {
+	return ErrNotEligible
+}
+
+// Eligibility presents result of eligibility check.
+type Eligibility struct {
+	BonusEligible bool
+
+	// duplicate presents enabled duplicate candidate rule, it's checked again in the transaction
+	// which adds request, so concurrent referrals of the same candidate can't both pass it.
+	duplicate *repository.EligibilityRule
+	now       time.Time
+}
+
+// duplicateCheck returns duplicate candidate rule to apply when request is added, it's nil if the rule is disabled.
+func (e Eligibility) duplicateCheck() *repository.DuplicateCheck {
+	if e.duplicate == nil {
+		return nil
+	}
+
+	return &repository.DuplicateCheck{
+		Since:  e.now.AddDate(0, 0, -e.duplicate.Value),
+		Action: e.duplicate.Action,
+	}
+}
+
+// EligibilityContext presents data a referral is checked against.
+type EligibilityContext struct {
+	Repo             *repository.Repository
+	Referrer         repository.Employee
+	CandidateName    string
+	CandidateSurname string
+	Now              time.Time
+}
+
+// EligibilityCheck checks referral against a rule. Check returns description of violation
+// or empty string if referral satisfies the rule, value is configured in rule settings.
+type EligibilityCheck interface {
+	Code() string
+	Check(c EligibilityContext, value int) (string, error)
+}
+
+// EligibilityCheckFunc adapts a function to EligibilityCheck.
+type EligibilityCheckFunc struct {
+	RuleCode string
+	Func     func(c EligibilityContext, value int) (string, error)
+}
+
+// Code returns code of the rule the function checks.
+func (f EligibilityCheckFunc) Code() string {
+	return f.RuleCode
+}
+
+// Check calls the function.
+func (f EligibilityCheckFunc) Check(c EligibilityContext, value int) (string, error) {
+	return f.Func(c, value)
+}
+
+// RegisterEligibilityCheck adds check to the rule set, checks are evaluated in order of registration.
+// Check is applied only if its rule is enabled in settings.
+func RegisterEligibilityCheck(check EligibilityCheck) {
+	eligibilityChecksMu.Lock()
+	defer eligibilityChecksMu.Unlock()
+
+	eligibilityChecks = append(eligibilityChecks, check)
+}
+
+// registeredChecks returns checks registered so far, checks registered later aren't included.
+func registeredChecks() []EligibilityCheck {
+	eligibilityChecksMu.RLock()
+	defer eligibilityChecksMu.RUnlock()
+
+	return eligibilityChecks[:len(eligibilityChecks):len(eligibilityChecks)]
+}
+
+func checkSelfReferral(c EligibilityContext, value int) (string, error) {
+	if c.Referrer.FirstName == "" || c.Referrer.LastName == "" {
+		return "", nil
+	}
+
+	if strings.EqualFold(c.Referrer.FirstName, c.CandidateName) && strings.EqualFold(c.Referrer.LastName, c.CandidateSurname) {
+		return "you cannot refer yourself", nil
+	}
+
+	return "", nil
+}
+
+func checkCurrentEmployee(c EligibilityContext, value int) (string, error) {
+	employee, err := c.Repo.IsEmployee(c.CandidateName, c.CandidateSurname)
+	if err != nil {
+		return "", err
+	}
+
+	if employee {
+		return "candidate is a current employee", nil
+	}
+
+	return "", nil
+}
+
+func checkDuplicateCandidate(c EligibilityContext, value int) (string, error) {
+	referred, err := c.Repo.HasReferralSince(c.CandidateName, c.CandidateSurname, c.Now.AddDate(0, 0, -value))
+	if err != nil {
+		return "", err
+	}
+
+	if referred {
+		return duplicateViolation(value), nil
+	}
+
+	return "", nil
+}
+
+func duplicateViolation(days int) string {
+	return fmt.Sprintf("candidate has already been referred within %d days", days)
+}
+
+func checkReferrerTenure(c EligibilityContext, value int) (string, error) {
+	if c.Referrer.Hired == nil || c.Now.Before(c.Referrer.Hired.AddDate(0, 0, value)) {
+		return fmt.Sprintf("referrer must be employed at least %d days", value), nil
+	}
+
+	return "", nil
+}
+
+// CheckEligibility evaluates enabled eligibility rules against referral. An error is returned
+// for the first violated rule with reject action, violation of rules with no_bonus action
+// only makes referral not eligible for bonus.
+func (s *ReferralService) CheckEligibility(userID, name, surname string, now time.Time) (Eligibility, error) {
+	rules, err := s.repo.GetEligibilityRules()
+	if err != nil {
+		return Eligibility{}, fmt.Errorf("cannot get eligibility rules: %w", err)
+	}
+
+	settings := make(map[string]repository.EligibilityRule, len(rules))
+	for _, rule := range rules {
+		settings[rule.Code] = rule
+	}
+
+	referrer, err := s.repo.GetEmployee(userID)
+	if err != nil {
+		return Eligibility{}, fmt.Errorf("cannot get referrer: %w", err)
+	}
+
+	c := EligibilityContext{
+		Repo:             s.repo,
+		Referrer:         referrer,
+		CandidateName:    name,
+		CandidateSurname: surname,
+		Now:              now,
+	}
+
+	eligibility := Eligibility{BonusEligible: true, now: now}
+
+	for _, check := range registeredChecks() {
+		rule, ok := settings[check.Code()]
+		if !ok || !rule.Enabled {
+			continue
+		}
+
+		if rule.Code == RuleDuplicateCandidate {
+			duplicate := rule
+			eligibility.duplicate = &duplicate
+		}
+
+		violation, err := check.Check(c, rule.Value)
+		if err != nil {
+			return Eligibility{}, fmt.Errorf("cannot check rule %s: %w", rule.Code, err)
+		}
+		if violation == "" {
+			continue
+		}
+
+		if rule.Action == repository.EligibilityReject {
+			return Eligibility{}, &EligibilityError{Code: rule.Code, Message: violation}
+		}
+
+		eligibility.BonusEligible = false
+	}
+
+	return eligibility, nil
+}
+
+// GetEligibilityRules returns settings of all eligibility rules.
+func (s *ReferralService) GetEligibilityRules() ([]repository.EligibilityRule, error) {
+	rules, err := s.repo.GetEligibilityRules()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get eligibility rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ValidateEligibilityRule validates settings of eligibility rule, only rules with registered check are accepted.
+func ValidateEligibilityRule(rule *repository.EligibilityRule) error {
+	rule.Code = strings.ToLower(strings.TrimSpace(rule.Code))
+	rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+
+	known := false
+	for _, check := range registeredChecks() {
+		if check.Code() == rule.Code {
+			known = true
+			break
+		}
+	}
+
+	if !known {
+		return fmt.Errorf("%w: %s", ErrUnknownEligibilityRule, rule.Code)
+	}
+
+	if !eligibilityActions[rule.Action] {
+		return fmt.Errorf("%w: action", ErrInvalidParameter)
+	}
+
+	if rule.Value < 0 {
+		return fmt.Errorf("%w: value must not be negative", ErrInvalidParameter)
+	}
+
+	return nil
+}
+
+// SaveEligibilityRule updates settings of eligibility rule, changes are applied to the next referral.
+func (s *ReferralService) SaveEligibilityRule(rule repository.EligibilityRule) error {
+	if err := ValidateEligibilityRule(&rule); err != nil {
+		return err
+	}
+
+	if err := s.repo.SaveEligibilityRule(rule); err != nil {
+		return fmt.Errorf("cannot save eligibility rule: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateEmployee updates employment data of user used by eligibility rules.
+func (s *ReferralService) UpdateEmployee(employee repository.Employee) error {
+	err := s.repo.UpdateEmployee(employee)
+	if errors.Is(err, repository.ErrNoUser) {
+		return ErrNoUser
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update employee: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	eligibilityRuleColumns = []string{"code", "enabled", "action", "value"}
+	employeeColumns        = []string{"first_name", "last_name", "hired"}
+)
+
+func TestReferralService_CheckEligibility(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	longAgo := now.AddDate(-1, 0, 0)
+	recently := now.AddDate(0, 0, -10)
+
+	rules := func(disabled ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows(eligibilityRuleColumns)
+		for _, rule := range []repository.EligibilityRule{
+			{Code: RuleSelfReferral, Action: repository.EligibilityReject},
+			{Code: RuleCurrentEmployee, Action: repository.EligibilityReject},
+			{Code: RuleDuplicateCandidate, Action: repository.EligibilityReject, Value: 183},
+			{Code: RuleReferrerTenure, Action: repository.EligibilityNoBonus, Value: 90},
+		} {
+			enabled := true
+			for _, code := range disabled {
+				if code == rule.Code {
+					enabled = false
+				}
+			}
+
+			rows.AddRow(rule.Code, enabled, rule.Action, rule.Value)
+		}
+
+		return rows
+	}
+
+	exists := func(value bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"exists"}).AddRow(value)
+	}
+
+	testTable := []struct {
+		testName              string
+		name                  string
+		expectedBonusEligible bool
+		expectedCode          string
+		mock                  func(mock sqlmock.Sqlmock)
+	}{
+		{
+			testName:              "Success",
+			name:                  "Billie",
+			expectedBonusEligible: true,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").WillReturnRows(rules())
+				mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlmock.NewRows(employeeColumns).AddRow("John", "Smith", longAgo))
+				mock.ExpectQuery("FROM users").WithArgs("Billie", "Jean").WillReturnRows(exists(false))
+				mock.ExpectQuery("FROM requests").WillReturnRows(exists(false))
+			},
+		},
+		{
+			testName:     "Failure: self referral",
+			name:         "john",
+			expectedCode: RuleSelfReferral,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").WillReturnRows(rules())
+				mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlmock.NewRows(employeeColumns).AddRow("John", "Jean", longAgo))
+			},
+		},
+		{
+			testName:     "Failure: current employee",
+			name:         "Billie",
+			expectedCode: RuleCurrentEmployee,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").WillReturnRows(rules())
+				mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlmock.NewRows(employeeColumns).AddRow("John", "Smith", longAgo))
+				mock.ExpectQuery("FROM users").WithArgs("Billie", "Jean").WillReturnRows(exists(true))
+			},
+		},
+		{
+			testName:     "Failure: candidate was referred recently",
+			name:         "Billie",
+			expectedCode: RuleDuplicateCandidate,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").WillReturnRows(rules(RuleCurrentEmployee))
+				mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlmock.NewRows(employeeColumns).AddRow("", "", nil))
+				mock.ExpectQuery("FROM requests").WithArgs("Billie", "Jean", now.AddDate(0, 0, -183)).WillReturnRows(exists(true))
+			},
+		},
+		{
+			testName: "Success: referrer without tenure isn't eligible for bonus",
+			name:     "Billie",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").WillReturnRows(rules(RuleCurrentEmployee, RuleDuplicateCandidate))
+				mock.ExpectQuery("SELECT (.+) FROM users").WillReturnRows(sqlmock.NewRows(employeeColumns).AddRow("John", "Smith", recently))
+			},
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			tc.mock(mock)
+
+			s := NewReferralService(repository.NewRepository(db), nil)
+
+			eligibility, err := s.CheckEligibility("1", tc.name, "Jean", now)
+			if tc.expectedCode != "" {
+				var eligibilityErr *EligibilityError
+				assert.True(t, errors.As(err, &eligibilityErr))
+				assert.ErrorIs(t, err, ErrNotEligible)
+				assert.Equal(t, tc.expectedCode, eligibilityErr.Code)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedBonusEligible, eligibility.BonusEligible)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestValidateEligibilityRule(t *testing.T) {
+	testTable := []struct {
+		testName      string
+		rule          repository.EligibilityRule
+		expectedError error
+	}{
+		{
+			testName: "Success",
+			rule:     repository.EligibilityRule{Code: " Duplicate_Candidate", Enabled: true, Action: "REJECT", Value: 30},
+		},
+		{
+			testName:      "Failure: unknown rule",
+			rule:          repository.EligibilityRule{Code: "unknown", Action: repository.EligibilityReject},
+			expectedError: ErrUnknownEligibilityRule,
+		},
+		{
+			testName:      "Failure: unknown action",
+			rule:          repository.EligibilityRule{Code: RuleSelfReferral, Action: "warn"},
+			expectedError: ErrInvalidParameter,
+		},
+		{
+			testName:      "Failure: negative value",
+			rule:          repository.EligibilityRule{Code: RuleReferrerTenure, Action: repository.EligibilityNoBonus, Value: -1},
+			expectedError: ErrInvalidParameter,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := ValidateEligibilityRule(&tc.rule)
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
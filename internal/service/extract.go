@@ -0,0 +1,106 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/cyberdr0id/referral/pkg/filetype"
+	"github.com/cyberdr0id/referral/pkg/textract"
+)
+
+const extractBatchSize = 20
+
+// cvKeywords presents skills which are searched in CVs.
+var cvKeywords = []textract.Keyword{
+	{Name: "go", Aliases: []string{"golang"}},
+	{Name: "java"},
+	{Name: "kotlin"},
+	{Name: "scala"},
+	{Name: "python"},
+	{Name: "ruby"},
+	{Name: "php"},
+	{Name: "c++", Aliases: []string{"cpp"}},
+	{Name: "c#", Aliases: []string{"csharp", ".net", "dotnet"}},
+	{Name: "rust"},
+	{Name: "swift"},
+	{Name: "javascript", Aliases: []string{"js", "ecmascript"}},
+	{Name: "typescript"},
+	{Name: "react", Aliases: []string{"react.js", "reactjs"}},
+	{Name: "angular"},
+	{Name: "vue", Aliases: []string{"vue.js", "vuejs"}},
+	{Name: "node.js", Aliases: []string{"nodejs"}},
+	{Name: "sql"},
+	{Name: "postgresql", Aliases: []string{"postgres"}},
+	{Name: "mysql"},
+	{Name: "mongodb", Aliases: []string{"mongo"}},
+	{Name: "redis"},
+	{Name: "kafka"},
+	{Name: "rabbitmq"},
+	{Name: "docker"},
+	{Name: "kubernetes", Aliases: []string{"k8s"}},
+	{Name: "terraform"},
+	{Name: "aws"},
+	{Name: "azure"},
+	{Name: "gcp", Aliases: []string{"google cloud"}},
+	{Name: "linux"},
+	{Name: "git"},
+	{Name: "grpc"},
+	{Name: "graphql"},
+	{Name: "machine learning", Aliases: []string{"ml"}},
+	{Name: "qa", Aliases: []string{"quality assurance"}},
+	{Name: "devops"},
+	{Name: "android"},
+	{Name: "ios"},
+}
+
+// ExtractCVs extracts text and keywords from scanned CVs. CVs of unsupported types or malformed
+// ones get empty text, so they aren't retried. Number of processed CVs is returned.
+func (s *ReferralService) ExtractCVs() (int, error) {
+	pending, err := s.repo.GetPendingExtractions(extractBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get requests pending extraction: %w", err)
+	}
+
+	extracted := 0
+	for _, p := range pending {
+		text, err := s.extractCV(p.FileID)
+		if errors.Is(err, textract.ErrInvalid) && s.Logger != nil {
+			s.Logger.Println(fmt.Errorf("cannot extract text of request %s: %w", p.RequestID, err))
+		}
+		if err != nil && !isPermanentExtractionError(err) {
+			return extracted, fmt.Errorf("cannot extract text of request %s: %w", p.RequestID, err)
+		}
+
+		if err := s.repo.SaveExtraction(p.RequestID, text, textract.Keywords(text, cvKeywords)); err != nil &&
+			!errors.Is(err, repository.ErrNoResult) {
+			return extracted, err
+		}
+
+		extracted++
+	}
+
+	return extracted, nil
+}
+
+func (s *ReferralService) extractCV(fileID string) (string, error) {
+	file, err := s.storage.Open(fileID)
+	if err != nil {
+		return "", fmt.Errorf("cannot open file from object storage: %w", err)
+	}
+	defer file.Close()
+
+	t, err := filetype.Detect(file)
+	if err != nil {
+		return "", err
+	}
+
+	return textract.Extract(t, file)
+}
+
+// isPermanentExtractionError checks if extraction fails the same way when it's retried.
+func isPermanentExtractionError(err error) bool {
+	return errors.Is(err, textract.ErrUnsupported) || errors.Is(err, textract.ErrInvalid) ||
+		errors.Is(err, filetype.ErrUnsupported) || errors.Is(err, storage.ErrNotFound)
+}
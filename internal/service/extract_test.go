@@ -0,0 +1,55 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferralService_ExtractCVs(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, content := range map[string]string{"1.txt": "Golang and  Docker\ndeveloper", "2.rtf": `{\rtf1 Go}`} {
+		if err := files.Upload(key, strings.NewReader(content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM requests").
+		WithArgs(extractBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "cv_file_id", "cv_content_type"}).
+			AddRow("1", "1.txt", "text/plain").
+			AddRow("2", "2.rtf", "application/rtf").
+			AddRow("3", "3.pdf", "application/pdf"))
+
+	mock.ExpectExec("UPDATE requests").
+		WithArgs("Golang and Docker\ndeveloper", "{\"go\",\"docker\"}", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Text of unsupported and missing files isn't extracted, they aren't retried.
+	mock.ExpectExec("UPDATE requests").
+		WithArgs("", "{}", "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE requests").
+		WithArgs("", "{}", "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s := NewReferralService(repository.NewRepository(db), files)
+
+	extracted, err := s.ExtractCVs()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, extracted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/pborman/uuid"
+)
+
+// AttachFileRequest presents a file uploaded to a request.
+type AttachFileRequest struct {
+	RequestID string
+	Kind      string
+	Filename  string
+	File      io.ReadSeeker
+
+	// AuthorID restricts uploads to requests of the author if it's set.
+	AuthorID string
+	UserID   string
+}
+
+// GetFiles returns files attached to the request including previous CV versions, only requests
+// of the author are searched if author id is set.
+func (s *ReferralService) GetFiles(requestID, authorID string) ([]repository.File, error) {
+	files, err := s.repo.GetFiles(requestID, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get files: %w", err)
+	}
+
+	return files, nil
+}
+
+// GetFile returns the file, only files of requests of the author are searched if author id is set.
+func (s *ReferralService) GetFile(fileID, authorID string) (repository.File, error) {
+	f, err := s.repo.GetFile(fileID, authorID)
+	if errors.Is(err, repository.ErrNoFile) {
+		return repository.File{}, ErrNoFile
+	}
+	if err != nil {
+		return repository.File{}, fmt.Errorf("cannot get file: %w", err)
+	}
+
+	return f, nil
+}
+
+// AttachFile uploads file and attaches it to the request. File must be a document of allowed type,
+// it's pending malware scan if scanner is set and isn't served until it's found clean. A new CV version
+// becomes the current CV once it's clean, previous versions are kept.
+func (s *ReferralService) AttachFile(request AttachFileRequest) (repository.File, error) {
+	if !repository.IsFileKind(request.Kind) {
+		return repository.File{}, fmt.Errorf("%w: file kind", ErrInvalidParameter)
+	}
+
+	fileType, err := DetectCVType(request.File, request.Filename)
+	if err != nil {
+		return repository.File{}, err
+	}
+
+	f := repository.File{
+		RequestID:   request.RequestID,
+		Kind:        request.Kind,
+		Filename:    path.Base(request.Filename),
+		ContentType: fileType.MIME,
+		UploadedBy:  request.UserID,
+		ScanStatus:  repository.FileScanClean,
+		Key:         s.Keys.Key(uuid.NewRandom().String()+"."+fileType.Extension, time.Now()),
+	}
+
+	if s.Scanner != nil {
+		f.ScanStatus = repository.FileScanPending
+	}
+
+	f.Size, f.SHA256, err = digest(request.File)
+	if err != nil {
+		return repository.File{}, err
+	}
+
+	f.UploadID, err = s.storeFile(request.UserID, f, request.File)
+	if err != nil {
+		return repository.File{}, err
+	}
+
+	f.ID, err = s.repo.AddFile(f, request.AuthorID)
+	if err != nil {
+		s.deleteObject(f.Key)
+
+		if errors.Is(err, repository.ErrNoResult) {
+			return repository.File{}, ErrNoResult
+		}
+
+		return repository.File{}, fmt.Errorf("cannot attach file: %w", err)
+	}
+
+	f.Current = f.Kind == repository.FileKindCV && f.ScanStatus == repository.FileScanClean
+
+	return f, nil
+}
+
+// DeleteFile deletes the file, only files of requests of the author are searched if author id is set.
+// The current CV can't be deleted.
+func (s *ReferralService) DeleteFile(fileID, authorID string) error {
+	f, err := s.GetFile(fileID, authorID)
+	if err != nil {
+		return err
+	}
+	if f.Current {
+		return ErrCurrentCV
+	}
+
+	err = s.repo.DeleteFile(fileID)
+	if errors.Is(err, repository.ErrNoResult) {
+		return ErrNoFile
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete file: %w", err)
+	}
+
+	s.deleteObject(f.Key)
+
+	return nil
+}
+
+// FileLink returns a signed link to the file, files which aren't clean aren't served.
+func (s *ReferralService) FileLink(f repository.File) (string, error) {
+	if f.ScanStatus != repository.FileScanClean {
+		return "", ErrUnscannedFile
+	}
+
+	url, err := s.storage.SignedURL(f.Key, cvURLExpiry)
+	if err != nil {
+		return "", fmt.Errorf("cannot download file from object storage: %w", err)
+	}
+
+	return url, nil
+}
+
+// OpenFile opens the file to be streamed to user with its original name, files which aren't clean aren't served.
+func (s *ReferralService) OpenFile(f repository.File) (CVFile, error) {
+	if f.ScanStatus != repository.FileScanClean {
+		return CVFile{}, ErrUnscannedFile
+	}
+
+	file, err := s.storage.Open(f.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return CVFile{}, ErrNoFile
+	}
+	if err != nil {
+		return CVFile{}, fmt.Errorf("cannot open file from object storage: %w", err)
+	}
+
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	return CVFile{
+		ReadSeekCloser: file,
+		Name:           f.Filename,
+		ContentType:    contentType,
+	}, nil
+}
+
+// deleteObject deletes file from object storage, failure is only logged as the object is just left unused.
+func (s *ReferralService) deleteObject(key string) {
+	if err := s.storage.Delete(key); err != nil && s.Logger != nil {
+		s.Logger.Println(fmt.Errorf("cannot delete file %s from object storage: %w", key, err))
+	}
+}
+
+// digest returns size and hex encoded SHA-256 of file, file is rewound afterwards.
+func digest(file io.ReadSeeker) (int64, string, error) {
+	h := sha256.New()
+
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return 0, "", fmt.Errorf("cannot read file: %w", err)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, "", fmt.Errorf("cannot rewind file: %w", err)
+	}
+
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
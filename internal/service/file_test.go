@@ -0,0 +1,185 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/scan"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var fileColumns = []string{"id", "request_id", "kind", "filename", "content_type", "size", "sha256", "uploaded_by", "created", "current", "file_key", "scan_status"}
+
+func TestReferralService_AttachFile(t *testing.T) {
+	const content = "%PDF-1.7\n"
+
+	sum := sha256.Sum256([]byte(content))
+
+	testTable := []struct {
+		testName      string
+		kind          string
+		scanner       scan.Scanner
+		requestFound  bool
+		scanStatus    string
+		expectedError error
+	}{
+		{
+			testName:     "New CV version",
+			kind:         repository.FileKindCV,
+			requestFound: true,
+			scanStatus:   repository.FileScanClean,
+		},
+		{
+			testName:     "Portfolio without scanner",
+			kind:         repository.FileKindPortfolio,
+			requestFound: true,
+			scanStatus:   repository.FileScanClean,
+		},
+		{
+			testName: "CV version pending scan",
+			kind:     repository.FileKindCV,
+			scanner: scannerFunc(func(io.Reader) (scan.Result, error) {
+				t.Fatal("file is scanned synchronously")
+				return scan.Result{}, nil
+			}),
+			requestFound: true,
+			scanStatus:   repository.FileScanPending,
+		},
+		{
+			testName:      "Request of another user",
+			kind:          repository.FileKindCoverLetter,
+			expectedError: ErrNoResult,
+		},
+		{
+			testName:      "Unknown kind",
+			kind:          "photo",
+			expectedError: ErrInvalidParameter,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			dir := t.TempDir()
+
+			files, err := storage.NewLocalStore(dir, "http://localhost:8000", "secret")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			if tc.expectedError != ErrInvalidParameter {
+				mock.ExpectExec("INSERT INTO uploads").
+					WithArgs(sqlmock.AnyArg(), "2", sqlmock.AnyArg(), "cv.pdf", "application/pdf", int64(len(content)), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				mock.ExpectBegin()
+
+				rows := sqlmock.NewRows([]string{"id"})
+				if tc.requestFound {
+					rows.AddRow("1")
+				}
+				mock.ExpectQuery("SELECT id FROM requests (.+) FOR UPDATE").WithArgs("1", "2").WillReturnRows(rows)
+
+				if tc.requestFound {
+					mock.ExpectExec("UPDATE uploads SET used").
+						WithArgs(sqlmock.AnyArg()).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+
+					mock.ExpectQuery("INSERT INTO files").
+						WithArgs("1", tc.kind, sqlmock.AnyArg(), "cv.pdf", "application/pdf", int64(len(content)), hex.EncodeToString(sum[:]), "2", tc.scanStatus).
+						WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("5"))
+
+					if tc.kind == repository.FileKindCV && tc.scanStatus == repository.FileScanClean {
+						mock.ExpectExec("UPDATE requests SET cv_file_id = (.+) cv_extracted = NULL").
+							WithArgs(sqlmock.AnyArg(), "application/pdf", "1", "5").
+							WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+
+					mock.ExpectCommit()
+				} else {
+					mock.ExpectRollback()
+				}
+			}
+
+			s := NewReferralService(repository.NewRepository(db), files)
+			s.Scanner = tc.scanner
+
+			f, err := s.AttachFile(AttachFileRequest{
+				RequestID: "1",
+				Kind:      tc.kind,
+				Filename:  "cv.pdf",
+				File:      strings.NewReader(content),
+				AuthorID:  "2",
+				UserID:    "2",
+			})
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			stored, _ := os.ReadDir(dir)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Empty(t, stored)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "5", f.ID)
+			assert.Equal(t, tc.scanStatus, f.ScanStatus)
+			assert.Equal(t, tc.kind == repository.FileKindCV && tc.scanStatus == repository.FileScanClean, f.Current)
+			assert.Len(t, stored, 1)
+		})
+	}
+}
+
+func TestReferralService_DeleteFile(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, key := range []string{"old.pdf", "new.pdf"} {
+		if err := files.Upload(key, strings.NewReader("%PDF-1.7\n")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM files f JOIN requests r").
+		WithArgs("6", "2").
+		WillReturnRows(sqlmock.NewRows(fileColumns).
+			AddRow("6", "1", repository.FileKindCV, "cv.pdf", "application/pdf", 9, "-", "2", "2022-01-01", true, "new.pdf", repository.FileScanClean))
+
+	mock.ExpectQuery("SELECT (.+) FROM files f JOIN requests r").
+		WithArgs("5", "2").
+		WillReturnRows(sqlmock.NewRows(fileColumns).
+			AddRow("5", "1", repository.FileKindCV, "cv.pdf", "application/pdf", 9, "-", "2", "2022-01-01", false, "old.pdf", repository.FileScanClean))
+	mock.ExpectExec("DELETE FROM files").WithArgs("5").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s := NewReferralService(repository.NewRepository(db), files)
+
+	assert.ErrorIs(t, s.DeleteFile("6", "2"), ErrCurrentCV)
+	assert.NoError(t, s.DeleteFile("5", "2"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = files.Stat("old.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = files.Stat("new.pdf")
+	assert.NoError(t, err)
+}
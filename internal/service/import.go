@@ -0,0 +1,304 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/pborman/uuid"
+)
+
+const (
+	importAuthorColumn     = "author"
+	importNameColumn       = "candidate_name"
+	importSurnameColumn    = "candidate_surname"
+	importPositionColumn   = "position"
+	importDepartmentColumn = "department"
+	importStatusColumn     = "status"
+	importCreatedColumn    = "created"
+	importUpdatedColumn    = "updated"
+	importCVPathColumn     = "cv_path"
+
+	importDateLayout = "2006-01-02"
+)
+
+var (
+	// ErrInvalidImportFile presents an error when imported file cannot be read as CSV with known header.
+	ErrInvalidImportFile = errors.New("invalid import file")
+
+	// ErrNoCVSource presents an error when imported row references CV, but there is no way to open it.
+	ErrNoCVSource = errors.New("CV files are not provided")
+
+	importRequiredColumns = []string{importAuthorColumn, importNameColumn, importSurnameColumn}
+
+	importStatuses = map[string]bool{
+		repository.StatusSubmitted: true,
+		repository.StatusAccepted:  true,
+		repository.StatusRejected:  true,
+	}
+)
+
+// CVOpener opens CV file referenced by cv_path column of imported row.
+type CVOpener func(path string) (io.ReadSeekCloser, error)
+
+// ImportReport presents result of requests import.
+type ImportReport struct {
+	DryRun   bool          `json:"dryRun"`
+	Total    int           `json:"total"`
+	Valid    int           `json:"valid"`
+	Imported int           `json:"imported"`
+	IDs      []string      `json:"ids,omitempty"`
+	Errors   []ImportError `json:"errors,omitempty"`
+}
+
+// ImportError presents an error in a particular line of imported file.
+type ImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"error"`
+}
+
+// importRow presents a validated row of imported file.
+type importRow struct {
+	line    int
+	request repository.ImportedRequest
+	cvPath  string
+}
+
+// ImportRequests imports historical requests from CSV file. Every row is validated with
+// the same rules as submitted candidates, invalid rows are skipped and listed in report.
+// Valid rows are inserted in a single transaction unless dryRun is set.
+func (s *ReferralService) ImportRequests(r io.Reader, openCV CVOpener, dryRun bool) (ImportReport, error) {
+	report := ImportReport{DryRun: dryRun}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("%w: cannot read header: %s", ErrInvalidImportFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, column := range importRequiredColumns {
+		if _, ok := columns[column]; !ok {
+			return ImportReport{}, fmt.Errorf("%w: missing column %s", ErrInvalidImportFile, column)
+		}
+	}
+
+	authors := map[string]string{}
+	var rows []importRow
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		report.Total++
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				report.Errors = append(report.Errors, ImportError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+				continue
+			}
+
+			return ImportReport{}, fmt.Errorf("cannot read import file: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+
+		row, err := s.validateImportRow(authors, value)
+		if err != nil {
+			report.Errors = append(report.Errors, ImportError{Line: line, Message: err.Error()})
+			continue
+		}
+
+		if row.cvPath != "" {
+			if err := checkImportedCV(openCV, row.cvPath); err != nil {
+				report.Errors = append(report.Errors, ImportError{Line: line, Message: err.Error()})
+				continue
+			}
+		}
+
+		row.line = line
+		rows = append(rows, row)
+	}
+
+	report.Valid = len(rows)
+
+	if dryRun || len(rows) == 0 {
+		return report, nil
+	}
+
+	requests := make([]repository.ImportedRequest, 0, len(rows))
+	for _, row := range rows {
+		if row.cvPath != "" {
+			row.request.CV, err = s.uploadImportedCV(openCV, row.cvPath, row.request.AuthorID)
+			if err != nil {
+				s.deleteImportedCVs(requests)
+				return ImportReport{}, fmt.Errorf("cannot upload CV from line %d: %w", row.line, err)
+			}
+		}
+
+		requests = append(requests, row.request)
+	}
+
+	report.IDs, err = s.repo.ImportRequests(requests)
+	if err != nil {
+		s.deleteImportedCVs(requests)
+		return ImportReport{}, fmt.Errorf("cannot import requests: %w", err)
+	}
+	report.Imported = len(report.IDs)
+
+	return report, nil
+}
+
+// validateImportRow validates values of imported row, authors are looked up by name and cached.
+func (s *ReferralService) validateImportRow(authors map[string]string, value func(column string) string) (importRow, error) {
+	request := repository.ImportedRequest{
+		Name:       value(importNameColumn),
+		Surname:    value(importSurnameColumn),
+		Position:   value(importPositionColumn),
+		Department: value(importDepartmentColumn),
+		Status:     strings.ToLower(value(importStatusColumn)),
+	}
+
+	if err := ValidateCandidate(request.Name, request.Surname, request.Position, request.Department); err != nil {
+		return importRow{}, err
+	}
+
+	if request.Status == "" {
+		request.Status = repository.StatusSubmitted
+	}
+	if !importStatuses[request.Status] {
+		return importRow{}, fmt.Errorf("%w: request status", ErrInvalidParameter)
+	}
+
+	author := value(importAuthorColumn)
+	authorID, ok := authors[author]
+	if !ok {
+		user, err := s.repo.GetUser(author)
+		if errors.Is(err, repository.ErrNoUser) {
+			return importRow{}, fmt.Errorf("%w: author %q doesn't exist", ErrInvalidParameter, author)
+		}
+		if err != nil {
+			return importRow{}, fmt.Errorf("cannot get author: %w", err)
+		}
+
+		authorID = user.ID
+		authors[author] = authorID
+	}
+	request.AuthorID = authorID
+
+	var err error
+
+	request.Created, err = parseImportDate(value(importCreatedColumn), time.Now())
+	if err != nil {
+		return importRow{}, fmt.Errorf("%w: created has bad format", ErrInvalidParameter)
+	}
+
+	request.Updated, err = parseImportDate(value(importUpdatedColumn), request.Created)
+	if err != nil {
+		return importRow{}, fmt.Errorf("%w: updated has bad format", ErrInvalidParameter)
+	}
+
+	if request.Updated.Before(request.Created) {
+		return importRow{}, fmt.Errorf("%w: updated must not be before created", ErrInvalidParameter)
+	}
+
+	return importRow{request: request, cvPath: value(importCVPathColumn)}, nil
+}
+
+// checkImportedCV checks if CV of imported row can be opened and is a document of allowed type.
+func checkImportedCV(openCV CVOpener, cvPath string) error {
+	if openCV == nil {
+		return ErrNoCVSource
+	}
+
+	file, err := openCV(cvPath)
+	if err != nil {
+		return fmt.Errorf("cannot open CV %s: %w", cvPath, err)
+	}
+	defer file.Close()
+
+	_, err = DetectCVType(file, cvPath)
+
+	return err
+}
+
+// uploadImportedCV uploads CV of imported row to object storage on behalf of the author and returns it.
+// CV is pending scan if scanner is set, so its request is held until CV is found clean.
+func (s *ReferralService) uploadImportedCV(openCV CVOpener, cvPath, authorID string) (repository.File, error) {
+	file, err := openCV(cvPath)
+	if err != nil {
+		return repository.File{}, fmt.Errorf("cannot open CV %s: %w", cvPath, err)
+	}
+	defer file.Close()
+
+	cvType, err := DetectCVType(file, cvPath)
+	if err != nil {
+		return repository.File{}, err
+	}
+
+	cv := repository.File{
+		Filename:    path.Base(cvPath),
+		ContentType: cvType.MIME,
+		Key:         s.Keys.Key(uuid.NewRandom().String()+"."+cvType.Extension, time.Now()),
+	}
+	if s.Scanner != nil {
+		cv.ScanStatus = repository.FileScanPending
+	}
+
+	cv.Size, cv.SHA256, err = digest(file)
+	if err != nil {
+		return repository.File{}, err
+	}
+
+	cv.UploadID, err = s.storeFile(authorID, cv, file)
+	if err != nil {
+		return repository.File{}, err
+	}
+
+	return cv, nil
+}
+
+// deleteImportedCVs deletes uploaded CVs of requests which haven't been imported.
+func (s *ReferralService) deleteImportedCVs(requests []repository.ImportedRequest) {
+	for _, request := range requests {
+		if request.CV.Key != "" {
+			s.deleteObject(request.CV.Key)
+		}
+	}
+}
+
+// parseImportDate parses date either in RFC 3339 format or as a date, empty value is replaced with def.
+func parseImportDate(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+
+	return time.Parse(importDateLayout, value)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/scan"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var userColumns = []string{"id", "name", "password", "is_admin", "created", "updated"}
+
+func TestReferralService_ImportRequests(t *testing.T) {
+	file := "author,candidate_name,candidate_surname,position,status,created,updated,cv_path\n" +
+		"user1,Billie,Jean,developer,accepted,2021-01-01,2021-02-01,\n" +
+		"user1,Igor,Nikolaev,,,2021-03-01,,\n" +
+		"user1,B1llie,Jean,,,,,\n" +
+		"user1,Joseph,Smith,,hired,,,\n" +
+		"unknown,Bjarne,Stroustrup,,,,,\n" +
+		"user1,Ken,Thompson,,,2021-03-01,2021-02-01,\n" +
+		"user1,Rob,Pike,,,,,cv.pdf\n"
+
+	testTable := []struct {
+		testName       string
+		dryRun         bool
+		expectedReport ImportReport
+		mock           func(mock sqlmock.Sqlmock)
+	}{
+		{
+			testName: "Dry run",
+			dryRun:   true,
+			expectedReport: ImportReport{
+				DryRun: true,
+				Total:  7,
+				Valid:  2,
+				Errors: []ImportError{
+					{Line: 4, Message: "invalid parameter: name has invalid format"},
+					{Line: 5, Message: "invalid parameter: request status"},
+					{Line: 6, Message: `invalid parameter: author "unknown" doesn't exist`},
+					{Line: 7, Message: "invalid parameter: updated must not be before created"},
+					{Line: 8, Message: ErrNoCVSource.Error()},
+				},
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows(userColumns).AddRow("1", "user1", "password", false, time.Now(), time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("unknown").
+					WillReturnRows(sqlmock.NewRows(userColumns))
+			},
+		},
+		{
+			testName: "Import",
+			expectedReport: ImportReport{
+				Total:    7,
+				Valid:    2,
+				Imported: 2,
+				IDs:      []string{"10", "11"},
+				Errors: []ImportError{
+					{Line: 4, Message: "invalid parameter: name has invalid format"},
+					{Line: 5, Message: "invalid parameter: request status"},
+					{Line: 6, Message: `invalid parameter: author "unknown" doesn't exist`},
+					{Line: 7, Message: "invalid parameter: updated must not be before created"},
+					{Line: 8, Message: ErrNoCVSource.Error()},
+				},
+			},
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user1").
+					WillReturnRows(sqlmock.NewRows(userColumns).AddRow("1", "user1", "password", false, time.Now(), time.Now()))
+				mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("unknown").
+					WillReturnRows(sqlmock.NewRows(userColumns))
+
+				mock.ExpectBegin()
+				prepared := mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO"))
+				prepared.ExpectQuery().
+					WithArgs("1", "Billie", "Jean", "developer", "", repository.StatusAccepted, "", "",
+						time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), "").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("10"))
+				prepared.ExpectQuery().
+					WithArgs("1", "Igor", "Nikolaev", "", "", repository.StatusSubmitted, "", "",
+						time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), "").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("11"))
+				mock.ExpectCommit()
+			},
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			tc.mock(mock)
+
+			s := NewReferralService(repository.NewRepository(db), nil)
+
+			report, err := s.ImportRequests(strings.NewReader(file), nil, tc.dryRun)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReport, report)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReferralService_ImportRequests_InvalidFile(t *testing.T) {
+	s := NewReferralService(nil, nil)
+
+	_, err := s.ImportRequests(strings.NewReader("name,surname\nBillie,Jean\n"), nil, true)
+	assert.ErrorIs(t, err, ErrInvalidImportFile)
+}
+
+func TestReferralService_ImportRequests_ScannedCV(t *testing.T) {
+	const content = "%PDF-1.7\n"
+
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("user1").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow("1", "user1", "password", false, time.Now(), time.Now()))
+	mock.ExpectExec("INSERT INTO uploads").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectBegin()
+	prepared := mock.ExpectPrepare(regexp.QuoteMeta("INSERT INTO"))
+	prepared.ExpectQuery().
+		WithArgs("1", "Rob", "Pike", "", "", repository.StatusPendingScan, sqlmock.AnyArg(), "application/pdf",
+			time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC), repository.StatusAccepted).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("10"))
+	mock.ExpectExec("UPDATE uploads SET used").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO files").
+		WithArgs("10", repository.FileKindCV, sqlmock.AnyArg(), "cv.pdf", "application/pdf", int64(len(content)),
+			sqlmock.AnyArg(), "1", repository.FileScanPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+	mock.ExpectCommit()
+
+	s := NewReferralService(repository.NewRepository(db), files)
+	s.Scanner = scannerFunc(func(r io.Reader) (scan.Result, error) {
+		return scan.Result{}, nil
+	})
+
+	openCV := func(path string) (io.ReadSeekCloser, error) {
+		return memoryFile{strings.NewReader(content)}, nil
+	}
+
+	file := "author,candidate_name,candidate_surname,status,created,updated,cv_path\n" +
+		"user1,Rob,Pike,accepted,2021-01-01,2021-02-01,cv.pdf\n"
+
+	report, err := s.ImportRequests(strings.NewReader(file), openCV, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10"}, report.IDs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -6,8 +6,11 @@ package mock_service
 
 import (
 	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
+	event "github.com/cyberdr0id/referral/internal/event"
 	repository "github.com/cyberdr0id/referral/internal/repository"
 	service "github.com/cyberdr0id/referral/internal/service"
 	jwt "github.com/cyberdr0id/referral/pkg/jwt"
@@ -120,34 +123,605 @@ func (mr *MockReferralMockRecorder) AddCandidate(ctx, request interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCandidate", reflect.TypeOf((*MockReferral)(nil).AddCandidate), ctx, request)
 }
 
+// AddUploadedCandidate mocks base method.
+func (m *MockReferral) AddUploadedCandidate(ctx context.Context, request service.SubmitCandidateRequest) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUploadedCandidate", ctx, request)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddUploadedCandidate indicates an expected call of AddUploadedCandidate.
+func (mr *MockReferralMockRecorder) AddUploadedCandidate(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUploadedCandidate", reflect.TypeOf((*MockReferral)(nil).AddUploadedCandidate), ctx, request)
+}
+
+// AddWebhook mocks base method.
+func (m *MockReferral) AddWebhook(w repository.Webhook) (repository.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhook", w)
+	ret0, _ := ret[0].(repository.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWebhook indicates an expected call of AddWebhook.
+func (mr *MockReferralMockRecorder) AddWebhook(w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhook", reflect.TypeOf((*MockReferral)(nil).AddWebhook), w)
+}
+
+// AssignRequest mocks base method.
+func (m *MockReferral) AssignRequest(id, assigneeID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRequest", id, assigneeID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRequest indicates an expected call of AssignRequest.
+func (mr *MockReferralMockRecorder) AssignRequest(id, assigneeID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRequest", reflect.TypeOf((*MockReferral)(nil).AssignRequest), id, assigneeID)
+}
+
+// AttachFile mocks base method.
+func (m *MockReferral) AttachFile(request service.AttachFileRequest) (repository.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AttachFile", request)
+	ret0, _ := ret[0].(repository.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AttachFile indicates an expected call of AttachFile.
+func (mr *MockReferralMockRecorder) AttachFile(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AttachFile", reflect.TypeOf((*MockReferral)(nil).AttachFile), request)
+}
+
+// CreateUpload mocks base method.
+func (m *MockReferral) CreateUpload(userID, filename string, size int64, sha256 string) (service.UploadSlot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", userID, filename, size, sha256)
+	ret0, _ := ret[0].(service.UploadSlot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockReferralMockRecorder) CreateUpload(userID, filename, size, sha256 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockReferral)(nil).CreateUpload), userID, filename, size, sha256)
+}
+
+// DeleteBonusRule mocks base method.
+func (m *MockReferral) DeleteBonusRule(position string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteBonusRule", position)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteBonusRule indicates an expected call of DeleteBonusRule.
+func (mr *MockReferralMockRecorder) DeleteBonusRule(position interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBonusRule", reflect.TypeOf((*MockReferral)(nil).DeleteBonusRule), position)
+}
+
+// DeleteFile mocks base method.
+func (m *MockReferral) DeleteFile(fileID, authorID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFile", fileID, authorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFile indicates an expected call of DeleteFile.
+func (mr *MockReferralMockRecorder) DeleteFile(fileID, authorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFile", reflect.TypeOf((*MockReferral)(nil).DeleteFile), fileID, authorID)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockReferral) DeleteWebhook(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockReferralMockRecorder) DeleteWebhook(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockReferral)(nil).DeleteWebhook), id)
+}
+
 // DownloadFile mocks base method.
-func (m *MockReferral) DownloadFile(id, userID string) (string, error) {
+func (m *MockReferral) DownloadFile(ctx context.Context, id, userID string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DownloadFile", id, userID)
+	ret := m.ctrl.Call(m, "DownloadFile", ctx, id, userID)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // DownloadFile indicates an expected call of DownloadFile.
-func (mr *MockReferralMockRecorder) DownloadFile(id, userID interface{}) *gomock.Call {
+func (mr *MockReferralMockRecorder) DownloadFile(ctx, id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockReferral)(nil).DownloadFile), ctx, id, userID)
+}
+
+// ExportBonuses mocks base method.
+func (m *MockReferral) ExportBonuses(filter repository.BonusFilter, fn func(repository.Bonus) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportBonuses", filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportBonuses indicates an expected call of ExportBonuses.
+func (mr *MockReferralMockRecorder) ExportBonuses(filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportBonuses", reflect.TypeOf((*MockReferral)(nil).ExportBonuses), filter, fn)
+}
+
+// ExportRequests mocks base method.
+func (m *MockReferral) ExportRequests(filter repository.RequestFilter, fn func(repository.UserRequests) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportRequests", filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportRequests indicates an expected call of ExportRequests.
+func (mr *MockReferralMockRecorder) ExportRequests(filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportRequests", reflect.TypeOf((*MockReferral)(nil).ExportRequests), filter, fn)
+}
+
+// FileLink mocks base method.
+func (m *MockReferral) FileLink(f repository.File) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FileLink", f)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FileLink indicates an expected call of FileLink.
+func (mr *MockReferralMockRecorder) FileLink(f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FileLink", reflect.TypeOf((*MockReferral)(nil).FileLink), f)
+}
+
+// GetAssignmentRules mocks base method.
+func (m *MockReferral) GetAssignmentRules() ([]repository.AssignmentRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssignmentRules")
+	ret0, _ := ret[0].([]repository.AssignmentRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssignmentRules indicates an expected call of GetAssignmentRules.
+func (mr *MockReferralMockRecorder) GetAssignmentRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignmentRules", reflect.TypeOf((*MockReferral)(nil).GetAssignmentRules))
+}
+
+// GetBonusRules mocks base method.
+func (m *MockReferral) GetBonusRules() ([]repository.BonusRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBonusRules")
+	ret0, _ := ret[0].([]repository.BonusRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBonusRules indicates an expected call of GetBonusRules.
+func (mr *MockReferralMockRecorder) GetBonusRules() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBonusRules", reflect.TypeOf((*MockReferral)(nil).GetBonusRules))
+}
+
+// GetBonuses mocks base method.
+func (m *MockReferral) GetBonuses(filter repository.BonusFilter) ([]repository.Bonus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBonuses", filter)
+	ret0, _ := ret[0].([]repository.Bonus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBonuses indicates an expected call of GetBonuses.
+func (mr *MockReferralMockRecorder) GetBonuses(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBonuses", reflect.TypeOf((*MockReferral)(nil).GetBonuses), filter)
+}
+
+// GetDeliveries mocks base method.
+func (m *MockReferral) GetDeliveries(filter repository.DeliveryFilter) ([]repository.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeliveries", filter)
+	ret0, _ := ret[0].([]repository.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeliveries indicates an expected call of GetDeliveries.
+func (mr *MockReferralMockRecorder) GetDeliveries(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeliveries", reflect.TypeOf((*MockReferral)(nil).GetDeliveries), filter)
+}
+
+// GetEligibilityRules mocks base method.
+func (m *MockReferral) GetEligibilityRules() ([]repository.EligibilityRule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEligibilityRules")
+	ret0, _ := ret[0].([]repository.EligibilityRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEligibilityRules indicates an expected call of GetEligibilityRules.
+func (mr *MockReferralMockRecorder) GetEligibilityRules() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadFile", reflect.TypeOf((*MockReferral)(nil).DownloadFile), id, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEligibilityRules", reflect.TypeOf((*MockReferral)(nil).GetEligibilityRules))
+}
+
+// GetFile mocks base method.
+func (m *MockReferral) GetFile(fileID, authorID string) (repository.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFile", fileID, authorID)
+	ret0, _ := ret[0].(repository.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFile indicates an expected call of GetFile.
+func (mr *MockReferralMockRecorder) GetFile(fileID, authorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFile", reflect.TypeOf((*MockReferral)(nil).GetFile), fileID, authorID)
+}
+
+// GetFiles mocks base method.
+func (m *MockReferral) GetFiles(requestID, authorID string) ([]repository.File, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFiles", requestID, authorID)
+	ret0, _ := ret[0].([]repository.File)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFiles indicates an expected call of GetFiles.
+func (mr *MockReferralMockRecorder) GetFiles(requestID, authorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFiles", reflect.TypeOf((*MockReferral)(nil).GetFiles), requestID, authorID)
+}
+
+// GetLeaderboard mocks base method.
+func (m *MockReferral) GetLeaderboard(from, to time.Time, limit int) ([]repository.LeaderboardEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeaderboard", from, to, limit)
+	ret0, _ := ret[0].([]repository.LeaderboardEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeaderboard indicates an expected call of GetLeaderboard.
+func (mr *MockReferralMockRecorder) GetLeaderboard(from, to, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeaderboard", reflect.TypeOf((*MockReferral)(nil).GetLeaderboard), from, to, limit)
+}
+
+// GetOverdueRequests mocks base method.
+func (m *MockReferral) GetOverdueRequests(now time.Time) ([]service.OverdueRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOverdueRequests", now)
+	ret0, _ := ret[0].([]service.OverdueRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOverdueRequests indicates an expected call of GetOverdueRequests.
+func (mr *MockReferralMockRecorder) GetOverdueRequests(now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOverdueRequests", reflect.TypeOf((*MockReferral)(nil).GetOverdueRequests), now)
+}
+
+// GetPreferences mocks base method.
+func (m *MockReferral) GetPreferences(userID string) (repository.Preferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPreferences", userID)
+	ret0, _ := ret[0].(repository.Preferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPreferences indicates an expected call of GetPreferences.
+func (mr *MockReferralMockRecorder) GetPreferences(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPreferences", reflect.TypeOf((*MockReferral)(nil).GetPreferences), userID)
+}
+
+// GetRecruiters mocks base method.
+func (m *MockReferral) GetRecruiters() ([]repository.Recruiter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecruiters")
+	ret0, _ := ret[0].([]repository.Recruiter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecruiters indicates an expected call of GetRecruiters.
+func (mr *MockReferralMockRecorder) GetRecruiters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecruiters", reflect.TypeOf((*MockReferral)(nil).GetRecruiters))
 }
 
 // GetRequests mocks base method.
-func (m *MockReferral) GetRequests(userID, status string, pageNumber, pageSize int) ([]repository.UserRequests, error) {
+func (m *MockReferral) GetRequests(filter repository.RequestFilter) ([]repository.UserRequests, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetRequests", userID, status, pageNumber, pageSize)
+	ret := m.ctrl.Call(m, "GetRequests", filter)
 	ret0, _ := ret[0].([]repository.UserRequests)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRequests indicates an expected call of GetRequests.
+func (mr *MockReferralMockRecorder) GetRequests(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequests", reflect.TypeOf((*MockReferral)(nil).GetRequests), filter)
+}
+
+// GetStats mocks base method.
+func (m *MockReferral) GetStats(from, to time.Time) (repository.Stats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", from, to)
+	ret0, _ := ret[0].(repository.Stats)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetRequests indicates an expected call of GetRequests.
-func (mr *MockReferralMockRecorder) GetRequests(userID, status, pageNumber, pageSize interface{}) *gomock.Call {
+// GetStats indicates an expected call of GetStats.
+func (mr *MockReferralMockRecorder) GetStats(from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockReferral)(nil).GetStats), from, to)
+}
+
+// GetUserStats mocks base method.
+func (m *MockReferral) GetUserStats(userID string) (repository.UserStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserStats", userID)
+	ret0, _ := ret[0].(repository.UserStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserStats indicates an expected call of GetUserStats.
+func (mr *MockReferralMockRecorder) GetUserStats(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserStats", reflect.TypeOf((*MockReferral)(nil).GetUserStats), userID)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockReferral) GetWebhooks() ([]repository.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks")
+	ret0, _ := ret[0].([]repository.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockReferralMockRecorder) GetWebhooks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockReferral)(nil).GetWebhooks))
+}
+
+// ImportRequests mocks base method.
+func (m *MockReferral) ImportRequests(r io.Reader, openCV service.CVOpener, dryRun bool) (service.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportRequests", r, openCV, dryRun)
+	ret0, _ := ret[0].(service.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportRequests indicates an expected call of ImportRequests.
+func (mr *MockReferralMockRecorder) ImportRequests(r, openCV, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportRequests", reflect.TypeOf((*MockReferral)(nil).ImportRequests), r, openCV, dryRun)
+}
+
+// OpenCV mocks base method.
+func (m *MockReferral) OpenCV(candidateID, userID string) (service.CVFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenCV", candidateID, userID)
+	ret0, _ := ret[0].(service.CVFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenCV indicates an expected call of OpenCV.
+func (mr *MockReferralMockRecorder) OpenCV(candidateID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenCV", reflect.TypeOf((*MockReferral)(nil).OpenCV), candidateID, userID)
+}
+
+// OpenFile mocks base method.
+func (m *MockReferral) OpenFile(f repository.File) (service.CVFile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenFile", f)
+	ret0, _ := ret[0].(service.CVFile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenFile indicates an expected call of OpenFile.
+func (mr *MockReferralMockRecorder) OpenFile(f interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequests", reflect.TypeOf((*MockReferral)(nil).GetRequests), userID, status, pageNumber, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenFile", reflect.TypeOf((*MockReferral)(nil).OpenFile), f)
+}
+
+// RecordCVDownload mocks base method.
+func (m *MockReferral) RecordCVDownload(download repository.CVDownload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordCVDownload", download)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordCVDownload indicates an expected call of RecordCVDownload.
+func (mr *MockReferralMockRecorder) RecordCVDownload(download interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordCVDownload", reflect.TypeOf((*MockReferral)(nil).RecordCVDownload), download)
+}
+
+// Redeliver mocks base method.
+func (m *MockReferral) Redeliver(id string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeliver", id, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Redeliver indicates an expected call of Redeliver.
+func (mr *MockReferralMockRecorder) Redeliver(id, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeliver", reflect.TypeOf((*MockReferral)(nil).Redeliver), id, now)
+}
+
+// ReplayEvents mocks base method.
+func (m *MockReferral) ReplayEvents(afterID, authorID string) ([]event.Event, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayEvents", afterID, authorID)
+	ret0, _ := ret[0].([]event.Event)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReplayEvents indicates an expected call of ReplayEvents.
+func (mr *MockReferralMockRecorder) ReplayEvents(afterID, authorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayEvents", reflect.TypeOf((*MockReferral)(nil).ReplayEvents), afterID, authorID)
+}
+
+// SaveAssignmentRule mocks base method.
+func (m *MockReferral) SaveAssignmentRule(rule repository.AssignmentRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAssignmentRule", rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAssignmentRule indicates an expected call of SaveAssignmentRule.
+func (mr *MockReferralMockRecorder) SaveAssignmentRule(rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAssignmentRule", reflect.TypeOf((*MockReferral)(nil).SaveAssignmentRule), rule)
+}
+
+// SaveBonusRule mocks base method.
+func (m *MockReferral) SaveBonusRule(rule repository.BonusRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveBonusRule", rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveBonusRule indicates an expected call of SaveBonusRule.
+func (mr *MockReferralMockRecorder) SaveBonusRule(rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBonusRule", reflect.TypeOf((*MockReferral)(nil).SaveBonusRule), rule)
+}
+
+// SaveEligibilityRule mocks base method.
+func (m *MockReferral) SaveEligibilityRule(rule repository.EligibilityRule) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEligibilityRule", rule)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEligibilityRule indicates an expected call of SaveEligibilityRule.
+func (mr *MockReferralMockRecorder) SaveEligibilityRule(rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEligibilityRule", reflect.TypeOf((*MockReferral)(nil).SaveEligibilityRule), rule)
+}
+
+// SetRecruiterPositions mocks base method.
+func (m *MockReferral) SetRecruiterPositions(userID string, positions []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRecruiterPositions", userID, positions)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRecruiterPositions indicates an expected call of SetRecruiterPositions.
+func (mr *MockReferralMockRecorder) SetRecruiterPositions(userID, positions interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRecruiterPositions", reflect.TypeOf((*MockReferral)(nil).SetRecruiterPositions), userID, positions)
+}
+
+// SubscribeEvents mocks base method.
+func (m *MockReferral) SubscribeEvents(authorID string) *event.Subscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeEvents", authorID)
+	ret0, _ := ret[0].(*event.Subscription)
+	return ret0
+}
+
+// SubscribeEvents indicates an expected call of SubscribeEvents.
+func (mr *MockReferralMockRecorder) SubscribeEvents(authorID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeEvents", reflect.TypeOf((*MockReferral)(nil).SubscribeEvents), authorID)
+}
+
+// UpdateBonus mocks base method.
+func (m *MockReferral) UpdateBonus(id, status string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBonus", id, status, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBonus indicates an expected call of UpdateBonus.
+func (mr *MockReferralMockRecorder) UpdateBonus(id, status, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBonus", reflect.TypeOf((*MockReferral)(nil).UpdateBonus), id, status, now)
+}
+
+// UpdateEmployee mocks base method.
+func (m *MockReferral) UpdateEmployee(employee repository.Employee) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmployee", employee)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEmployee indicates an expected call of UpdateEmployee.
+func (mr *MockReferralMockRecorder) UpdateEmployee(employee interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmployee", reflect.TypeOf((*MockReferral)(nil).UpdateEmployee), employee)
+}
+
+// UpdatePreferences mocks base method.
+func (m *MockReferral) UpdatePreferences(userID string, preferences repository.Preferences) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePreferences", userID, preferences)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePreferences indicates an expected call of UpdatePreferences.
+func (mr *MockReferralMockRecorder) UpdatePreferences(userID, preferences interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePreferences", reflect.TypeOf((*MockReferral)(nil).UpdatePreferences), userID, preferences)
 }
 
 // UpdateRequest mocks base method.
@@ -163,3 +737,32 @@ func (mr *MockReferralMockRecorder) UpdateRequest(id, status interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequest", reflect.TypeOf((*MockReferral)(nil).UpdateRequest), id, status)
 }
+
+// UpdateRequests mocks base method.
+func (m *MockReferral) UpdateRequests(request service.BulkUpdateRequest) ([]service.UpdateResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRequests", request)
+	ret0, _ := ret[0].([]service.UpdateResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateRequests indicates an expected call of UpdateRequests.
+func (mr *MockReferralMockRecorder) UpdateRequests(request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRequests", reflect.TypeOf((*MockReferral)(nil).UpdateRequests), request)
+}
+
+// UpdateWebhook mocks base method.
+func (m *MockReferral) UpdateWebhook(w repository.Webhook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhook", w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhook indicates an expected call of UpdateWebhook.
+func (mr *MockReferralMockRecorder) UpdateWebhook(w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhook", reflect.TypeOf((*MockReferral)(nil).UpdateWebhook), w)
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"github.com/cyberdr0id/referral/internal/notification"
+	"github.com/cyberdr0id/referral/internal/repository"
+)
+
+// recipientFinder finds recipients of notifications by their preferences.
+type recipientFinder struct {
+	repo *repository.Repository
+}
+
+// NewRecipientFinder creates finder of notification recipients which uses user preferences.
+func NewRecipientFinder(repo *repository.Repository) notification.RecipientFinder {
+	return recipientFinder{repo: repo}
+}
+
+func (f recipientFinder) GetRecipient(userID string) (notification.Recipient, error) {
+	preferences, err := f.repo.GetPreferences(userID)
+	if err != nil {
+		return notification.Recipient{}, err
+	}
+
+	return notification.Recipient{Email: preferences.Email, Muted: preferences.MutedNotifications}, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/cyberdr0id/referral/internal/notification"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePreferences(t *testing.T) {
+	testTable := []struct {
+		testName        string
+		preferences     repository.Preferences
+		expected        repository.Preferences
+		isErrorExpected bool
+	}{
+		{
+			testName: "Success",
+			preferences: repository.Preferences{
+				Email:              " user@example.com ",
+				MutedNotifications: []string{" SLA_Reminder"},
+			},
+			expected: repository.Preferences{
+				Email:              "user@example.com",
+				MutedNotifications: []string{notification.CategorySLAReminder},
+			},
+		},
+		{
+			testName: "Success: without email",
+		},
+		{
+			testName:        "Failure: bad email",
+			preferences:     repository.Preferences{Email: "user at example.com"},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: email with display name",
+			preferences:     repository.Preferences{Email: "User <user@example.com>"},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: unknown category",
+			preferences:     repository.Preferences{MutedNotifications: []string{"newsletter"}},
+			isErrorExpected: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := ValidatePreferences(&tc.preferences)
+			if tc.isErrorExpected {
+				assert.ErrorIs(t, err, ErrInvalidParameter)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, tc.preferences)
+		})
+	}
+}
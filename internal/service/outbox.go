@@ -0,0 +1,170 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/notification"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/webhook"
+)
+
+const (
+	eventBatchSize   = 100
+	maxEventAttempts = 10
+	eventClaimLease  = 10 * time.Minute
+)
+
+const (
+	consumerHandler  = "handler"
+	consumerLive     = "live"
+	consumerExternal = "external"
+)
+
+// eventConsumer presents a publisher whose progress with outbox events is tracked by name.
+type eventConsumer struct {
+	name      string
+	publisher event.Publisher
+}
+
+// RelayEvents publishes due events from the outbox to the service itself, which notifies users and
+// queues webhook deliveries, to subscribers of live events and to the external publisher. Events which fail
+// are retried with exponential backoff only for consumers which haven't accepted them yet, so every consumer
+// gets them at least once and a failing consumer doesn't make the others get them again. Number of published events is returned.
+func (s *ReferralService) RelayEvents(now time.Time) (int, error) {
+	consumers := []eventConsumer{
+		{name: consumerHandler, publisher: event.PublisherFunc(s.HandleEvent)},
+		{name: consumerLive, publisher: s.Events},
+	}
+	if s.Publisher != nil {
+		consumers = append(consumers, eventConsumer{name: consumerExternal, publisher: s.Publisher})
+	}
+
+	events, err := s.repo.ClaimDueEvents(now, now.Add(eventClaimLease), eventBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get due events: %w", err)
+	}
+
+	published := 0
+	for _, e := range events {
+		done, err := consume(consumers, e)
+
+		attempts := e.Attempts + 1
+		status := repository.EventPublished
+		lastError := ""
+		var nextAttempt *time.Time
+
+		if err != nil {
+			lastError = err.Error()
+			status = repository.EventFailed
+
+			if attempts < maxEventAttempts {
+				next := now.Add(event.RetryDelay(attempts))
+				nextAttempt = &next
+				status = repository.EventPending
+			}
+
+			if s.Logger != nil {
+				s.Logger.Println(fmt.Errorf("cannot publish event %s %s: %w", e.ID, e.Name, err))
+			}
+		}
+
+		if err := s.repo.SaveEventAttempt(e.ID, status, attempts, nextAttempt, lastError, done); err != nil {
+			return published, err
+		}
+
+		if status == repository.EventPublished {
+			published++
+		}
+	}
+
+	return published, nil
+}
+
+// consume publishes event to consumers which haven't accepted it yet, even if some of them fail.
+// Names of all consumers which have accepted the event are returned along with the first error.
+func consume(consumers []eventConsumer, e repository.OutboxEvent) ([]string, error) {
+	done := append([]string{}, e.Consumers...)
+	var firstErr error
+
+	for _, c := range consumers {
+		if contains(e.Consumers, c.name) {
+			continue
+		}
+
+		if err := c.publisher.Publish(e.Event); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			continue
+		}
+
+		done = append(done, c.name)
+	}
+
+	return done, firstErr
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HandleEvent reacts to a domain event: queues its delivery to subscribed webhooks
+// and notifies users concerned.
+func (s *ReferralService) HandleEvent(e event.Event) error {
+	if s.Webhooks != nil && webhook.IsEvent(e.Name) {
+		payload, err := webhook.NewPayload(e.Name, e.Payload, e.Created)
+		if err != nil {
+			return err
+		}
+
+		if err := s.repo.AddDeliveries(e.ID, e.Name, payload); err != nil {
+			return fmt.Errorf("cannot queue webhook deliveries: %w", err)
+		}
+	}
+
+	var category string
+	switch e.Name {
+	case event.ReferralCreated:
+		category = notification.CategorySubmissionReceipt
+	case event.ReferralStatusChanged:
+		category = notification.CategoryStatusChanged
+	default:
+		return nil
+	}
+
+	if s.Notifier == nil {
+		return nil
+	}
+
+	var request repository.ReferralEvent
+	if err := json.Unmarshal(e.Payload, &request); err != nil {
+		return fmt.Errorf("cannot decode %s event: %w", e.Name, err)
+	}
+
+	err := s.Notifier.Notify(notification.Notification{
+		UserID:   request.AuthorID,
+		Category: category,
+		Data: map[string]string{
+			"id":       request.ID,
+			"name":     request.CandidateName,
+			"surname":  request.CandidateSurname,
+			"position": request.Position,
+			"status":   request.Status,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cannot send %s notification to user %s: %w", category, request.AuthorID, err)
+	}
+
+	return nil
+}
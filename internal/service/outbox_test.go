@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/notification"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var outboxColumns = []string{"id", "event", "payload", "attempts", "created", "consumers"}
+
+func TestReferralService_RelayEvents(t *testing.T) {
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	retryAt := now.Add(event.RetryDelay(1))
+	referral := `{"id":"1","candidateName":"Billie","candidateSurname":"Jean","position":"","status":"accepted","authorId":"5","assigneeId":null,"updated":""}`
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	all := []string{consumerHandler, consumerLive, consumerExternal}
+	internal := []string{consumerHandler, consumerLive}
+
+	mock.ExpectQuery("WITH due AS (.+) FROM outbox (.+) FOR UPDATE SKIP LOCKED (.+) UPDATE outbox").
+		WithArgs(repository.EventPending, now, eventBatchSize, now.Add(eventClaimLease)).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).
+			AddRow("1", event.ReferralStatusChanged, referral, 0, now, "{}").
+			AddRow("2", event.ReferralAssigned, referral, 0, now, "{}").
+			AddRow("3", event.BonusStatusChanged, `{}`, maxEventAttempts-1, now, "{handler,live}").
+			AddRow("4", event.ReferralStatusChanged, referral, 1, now, "{handler,live}"))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").
+		WithArgs("1", event.ReferralStatusChanged, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox").
+		WithArgs(repository.EventPublished, 1, nil, "", pq.Array(all), repository.EventPublished, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").
+		WithArgs("2", event.ReferralAssigned, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox").
+		WithArgs(repository.EventPending, 1, &retryAt, "unavailable", pq.Array(internal), repository.EventPublished, "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox").
+		WithArgs(repository.EventFailed, maxEventAttempts, nil, "unavailable", pq.Array(internal), repository.EventPublished, "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox").
+		WithArgs(repository.EventPublished, 2, nil, "", pq.Array(all), repository.EventPublished, "4").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var notifications []notification.Notification
+	external := &event.MemoryPublisher{}
+
+	s := NewReferralService(repository.NewRepository(db), nil)
+	s.Webhooks = senderFunc(nil)
+	s.Notifier = notifierFunc(func(n notification.Notification) error {
+		notifications = append(notifications, n)
+		return nil
+	})
+	s.Publisher = event.Publishers{external, event.PublisherFunc(func(e event.Event) error {
+		if e.ID == "1" || e.ID == "4" {
+			return nil
+		}
+
+		return errors.New("unavailable")
+	})}
+
+	live := s.Events.Subscribe(nil)
+	defer live.Close()
+
+	published, err := s.RelayEvents(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Len(t, external.Events(), 4)
+	assert.Len(t, live.C, 2)
+	assert.Equal(t, []notification.Notification{{
+		UserID:   "5",
+		Category: notification.CategoryStatusChanged,
+		Data: map[string]string{
+			"id":       "1",
+			"name":     "Billie",
+			"surname":  "Jean",
+			"position": "",
+			"status":   "accepted",
+		},
+	}}, notifications)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
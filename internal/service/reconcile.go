@@ -0,0 +1,212 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+)
+
+const (
+	// reconcileBatchSize presents number of keys or files which are checked by a single query.
+	reconcileBatchSize = 100
+
+	// orphanGracePeriod presents age of object after which it's considered orphaned if nothing references it,
+	// younger objects may belong to files which are being added.
+	orphanGracePeriod = time.Hour
+)
+
+// MissingFile presents a file of request which is missing in object storage.
+type MissingFile struct {
+	FileID    string `json:"fileId"`
+	RequestID string `json:"requestId"`
+	Key       string `json:"key"`
+}
+
+// FileReport presents result of reconciliation of object storage with database.
+type FileReport struct {
+	ExpiredUploads  int           `json:"expiredUploads"`
+	OrphanedObjects []string      `json:"orphanedObjects"`
+	DeletedObjects  int           `json:"deletedObjects"`
+	MissingFiles    []MissingFile `json:"missingFiles"`
+}
+
+// ErrNoKeyPrefix is returned when orphaned objects are to be deleted but the key layout has no prefix,
+// objects of the whole store would be considered then, including ones the application doesn't own.
+var ErrNoKeyPrefix = fmt.Errorf("%w: orphaned files can't be deleted without storage key prefix", ErrInvalidParameter)
+
+// ReconcileFiles reconciles object storage with database. Files of uploads which have expired without
+// being used are deleted. Objects under the key layout which aren't referenced by any file, request
+// or upload are reported as orphaned and deleted if deleteOrphans is set, deletion requires the key
+// layout to have a prefix. Files and CVs of requests which are missing in object storage are reported.
+func (s *ReferralService) ReconcileFiles(now time.Time, deleteOrphans bool) (FileReport, error) {
+	var report FileReport
+	var err error
+
+	if deleteOrphans && s.Keys.Root() == "" {
+		return report, ErrNoKeyPrefix
+	}
+
+	report.ExpiredUploads, err = s.deleteExpiredUploads()
+	if err != nil {
+		return report, err
+	}
+
+	prefixes := []string{s.Keys.Root()}
+	if prefixes[0] != "" {
+		prefixes = append(prefixes, quarantinePrefix+prefixes[0])
+	}
+
+	for _, prefix := range prefixes {
+		if err := s.findOrphanedObjects(prefix, now, deleteOrphans, &report); err != nil {
+			return report, err
+		}
+	}
+
+	report.MissingFiles, err = s.findMissingFiles()
+	if err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// deleteExpiredUploads deletes files of uploads which have expired without being used and the uploads.
+// Expired used uploads are deleted too, their files are kept by requests.
+func (s *ReferralService) deleteExpiredUploads() (int, error) {
+	deleted := 0
+
+	for {
+		uploads, err := s.repo.GetExpiredUploads(reconcileBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+
+		for _, u := range uploads {
+			if err := s.storage.Delete(u.Key); err != nil {
+				return deleted, fmt.Errorf("cannot delete file of expired upload %s: %w", u.ID, err)
+			}
+
+			if err := s.repo.DeleteUpload(u.ID); err != nil {
+				return deleted, err
+			}
+
+			deleted++
+		}
+
+		if len(uploads) < reconcileBatchSize {
+			break
+		}
+	}
+
+	if _, err := s.repo.DeleteUsedUploads(); err != nil {
+		return deleted, err
+	}
+
+	return deleted, nil
+}
+
+// findOrphanedObjects adds objects with the key prefix which aren't referenced to the report.
+func (s *ReferralService) findOrphanedObjects(prefix string, now time.Time, deleteOrphans bool, report *FileReport) error {
+	keys := make([]string, 0, reconcileBatchSize)
+
+	check := func() error {
+		referenced, err := s.repo.GetReferencedKeys(keys)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if referenced[key] {
+				continue
+			}
+
+			report.OrphanedObjects = append(report.OrphanedObjects, key)
+
+			if deleteOrphans {
+				if err := s.storage.Delete(key); err != nil {
+					return fmt.Errorf("cannot delete orphaned file %s: %w", key, err)
+				}
+
+				report.DeletedObjects++
+			}
+		}
+
+		keys = keys[:0]
+
+		return nil
+	}
+
+	err := s.storage.List(prefix, func(info storage.FileInfo) error {
+		if now.Sub(info.Modified) < orphanGracePeriod {
+			return nil
+		}
+
+		keys = append(keys, info.Key)
+		if len(keys) < reconcileBatchSize {
+			return nil
+		}
+
+		return check()
+	})
+	if err != nil {
+		return fmt.Errorf("cannot list files of object storage: %w", err)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	return check()
+}
+
+// findMissingFiles returns files of requests and CVs referenced only by requests which are missing
+// in object storage.
+func (s *ReferralService) findMissingFiles() ([]MissingFile, error) {
+	var missing []MissingFile
+
+	pages := []struct {
+		get    func(afterID string, limit int) ([]repository.StoredFile, error)
+		cursor func(repository.StoredFile) string
+	}{
+		{
+			get:    s.repo.GetStoredFiles,
+			cursor: func(f repository.StoredFile) string { return f.ID },
+		},
+		{
+			get:    s.repo.GetRequestCVs,
+			cursor: func(f repository.StoredFile) string { return f.RequestID },
+		},
+	}
+
+	for _, page := range pages {
+		afterID := "0"
+		for {
+			files, err := page.get(afterID, reconcileBatchSize)
+			if err != nil {
+				return missing, err
+			}
+
+			for _, f := range files {
+				_, err := s.storage.Stat(f.Key)
+				if errors.Is(err, storage.ErrNotFound) {
+					missing = append(missing, MissingFile{FileID: f.ID, RequestID: f.RequestID, Key: f.Key})
+					continue
+				}
+				if err != nil {
+					return missing, fmt.Errorf("cannot get info of file %s: %w", f.Key, err)
+				}
+			}
+
+			if len(files) < reconcileBatchSize {
+				break
+			}
+
+			afterID = page.cursor(files[len(files)-1])
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferralService_ReconcileFiles(t *testing.T) {
+	testTable := []struct {
+		testName       string
+		deleteOrphans  bool
+		expectedStored []string
+	}{
+		{
+			testName:       "Report orphaned files",
+			expectedStored: []string{"known.pdf", "legacy.pdf", "new.pdf", "orphan.pdf"},
+		},
+		{
+			testName:       "Delete orphaned files",
+			deleteOrphans:  true,
+			expectedStored: []string{"known.pdf", "legacy.pdf", "new.pdf"},
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			now := time.Now()
+
+			dir := t.TempDir()
+
+			files, err := storage.NewLocalStore(dir, "http://localhost:8000", "secret")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, name := range []string{"expired.pdf", "known.pdf", "legacy.pdf", "new.pdf", "orphan.pdf"} {
+				if err := files.Upload("cvs/"+name, strings.NewReader("content")); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if name != "new.pdf" {
+					old := now.Add(-2 * orphanGracePeriod)
+					if err := os.Chtimes(filepath.Join(dir, "cvs", name), old, old); err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+				}
+			}
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT (.+) FROM uploads WHERE used IS NULL AND expires <= CURRENT_TIMESTAMP").
+				WithArgs(reconcileBatchSize).
+				WillReturnRows(sqlmock.NewRows(uploadColumns).
+					AddRow("u1", "1", "cvs/expired.pdf", "cv.pdf", "application/pdf", 7, "", now.Add(-time.Hour)))
+			mock.ExpectExec("DELETE FROM uploads WHERE id = (.+) AND used IS NULL").
+				WithArgs("u1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("DELETE FROM uploads WHERE used IS NOT NULL").
+				WillReturnResult(sqlmock.NewResult(0, 2))
+			mock.ExpectQuery("SELECT file_key FROM files (.+) UNION").
+				WithArgs(sqlmock.AnyArg()).
+				WillReturnRows(sqlmock.NewRows([]string{"file_key"}).AddRow("cvs/known.pdf").AddRow("cvs/legacy.pdf"))
+			mock.ExpectQuery("SELECT (.+) FROM files WHERE id > (.+) ORDER BY id").
+				WithArgs("0", reconcileBatchSize).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "file_key"}).
+					AddRow("1", "1", "cvs/known.pdf").
+					AddRow("2", "1", "cvs/missing.pdf"))
+			mock.ExpectQuery("SELECT (.+) FROM requests r WHERE r.id > (.+) AND NOT EXISTS (.+) ORDER BY r.id").
+				WithArgs("0", reconcileBatchSize).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "cv_file_id"}).
+					AddRow("2", "cvs/legacy.pdf").
+					AddRow("3", "cvs/lost.pdf"))
+
+			s := NewReferralService(repository.NewRepository(db), files)
+			s.Keys = "cvs"
+
+			report, err := s.ReconcileFiles(now, tc.deleteOrphans)
+			assert.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Equal(t, 1, report.ExpiredUploads)
+			assert.Equal(t, []string{"cvs/orphan.pdf"}, report.OrphanedObjects)
+			assert.Equal(t, []MissingFile{
+				{FileID: "2", RequestID: "1", Key: "cvs/missing.pdf"},
+				{RequestID: "3", Key: "cvs/lost.pdf"},
+			}, report.MissingFiles)
+
+			var stored []string
+			entries, _ := os.ReadDir(filepath.Join(dir, "cvs"))
+			for _, entry := range entries {
+				stored = append(stored, entry.Name())
+			}
+			assert.Equal(t, tc.expectedStored, stored)
+		})
+	}
+}
+
+func TestReferralService_ReconcileFiles_NoKeyPrefix(t *testing.T) {
+	// Layout cv{year}/ has no fixed directory, keys of cvs-backup/ would be deleted with prefix cv.
+	for _, layout := range []storage.KeyLayout{"", "cv{year}/"} {
+		t.Run(string(layout), func(t *testing.T) {
+			dir := t.TempDir()
+
+			files, err := storage.NewLocalStore(dir, "http://localhost:8000", "secret")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := files.Upload("cvs-backup/other.pdf", strings.NewReader("content")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			s := NewReferralService(repository.NewRepository(db), files)
+			s.Keys = layout
+
+			_, err = s.ReconcileFiles(time.Now().Add(2*orphanGracePeriod), true)
+			assert.ErrorIs(t, err, ErrNoKeyPrefix)
+			assert.ErrorIs(t, err, ErrInvalidParameter)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			entries, _ := os.ReadDir(filepath.Join(dir, "cvs-backup"))
+			assert.Len(t, entries, 1)
+		})
+	}
+}
@@ -4,28 +4,80 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log"
+	"mime"
 	"mime/multipart"
+	"net/mail"
+	"path"
+	"regexp"
+	"strings"
+	"time"
 
 	mycontext "github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/notification"
 	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/scan"
 	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/cyberdr0id/referral/internal/webhook"
+	"github.com/cyberdr0id/referral/pkg/filetype"
 	"github.com/pborman/uuid"
 )
 
 // ErrInvalidParameter presetns an error when user input invalid parameter.
 var ErrInvalidParameter = errors.New("invalid parameter")
 
+const (
+	maxPositionLength   = 64
+	maxDepartmentLength = 64
+
+	cvURLExpiry = 10 * time.Minute
+
+	// MaxBulkUpdateSize presents maximum number of requests which are updated at once.
+	MaxBulkUpdateSize = 500
+
+	defaultContentType = "application/octet-stream"
+)
+
+var nameSurnameExp = regexp.MustCompile("^(^[A-Za-zА-Яа-я]{2,16})?$")
+
 // ReferralService presents access to referral service via repository.
 type ReferralService struct {
 	repo    *repository.Repository
-	storage *storage.Storage
+	storage storage.FileStore
+
+	// Keys presents layout of keys of uploaded CVs, they're kept at the root of the store by default.
+	Keys storage.KeyLayout
+
+	// SLA presents limits of time requests may stay in a status, no limits are set by default.
+	SLA SLA
+
+	// Notifier delivers notifications to users, notifications aren't sent if it's nil.
+	Notifier notification.Notifier
+
+	// Webhooks sends events to subscribed endpoints, queued deliveries aren't sent if it's nil.
+	Webhooks webhook.Sender
+
+	// Publisher publishes events from the outbox to external consumers, it's optional.
+	Publisher event.Publisher
+
+	// Scanner scans uploaded CVs for malware, requests are submitted without scanning if it's nil.
+	Scanner scan.Scanner
+
+	// Events publishes events from the outbox to subscribers of live updates.
+	Events *event.Broker
+
+	// Logger logs errors which don't fail operations, e.g. notification delivery errors.
+	Logger *log.Logger
 }
 
 // NewReferralService creates a new instance of ReferralService.
-func NewReferralService(repo *repository.Repository, storage *storage.Storage) *ReferralService {
+func NewReferralService(repo *repository.Repository, storage storage.FileStore) *ReferralService {
 	return &ReferralService{
 		repo:    repo,
 		storage: storage,
+		Events:  event.NewBroker(),
 	}
 }
 
@@ -34,25 +86,114 @@ type SubmitCandidateRequest struct {
 	File             multipart.File
 	CandidateName    string
 	CandidateSurname string
+	Position         string
+	Department       string
 	Filetype         string
+
+	// Filename presents original name of CV file.
+	Filename string
+
+	// ContentType presents MIME type of CV detected by its content.
+	ContentType string
+
+	// UploadID presents upload slot of CV uploaded directly to object storage instead of File.
+	UploadID string
+}
+
+// ValidateCandidate validates candidate data before request creating.
+func ValidateCandidate(name, surname, position, department string) error {
+	if len(name) == 0 || len(surname) == 0 {
+		return fmt.Errorf("%w: wrong length", ErrInvalidParameter)
+	}
+
+	if !nameSurnameExp.MatchString(name) {
+		return fmt.Errorf("%w: name has invalid format", ErrInvalidParameter)
+	}
+
+	if !nameSurnameExp.MatchString(surname) {
+		return fmt.Errorf("%w: surname has invalid format", ErrInvalidParameter)
+	}
+
+	if len(position) > maxPositionLength {
+		return fmt.Errorf("%w: position must be at most %d symbols", ErrInvalidParameter, maxPositionLength)
+	}
+
+	if len(department) > maxDepartmentLength {
+		return fmt.Errorf("%w: department must be at most %d symbols", ErrInvalidParameter, maxDepartmentLength)
+	}
+
+	return nil
+}
+
+// DetectCVType detects type of CV by its content. Only PDF, DOC, DOCX, RTF, ODT and TXT documents
+// are allowed, and the type must match extension of the file name.
+func DetectCVType(file io.ReadSeeker, filename string) (filetype.Type, error) {
+	detected, err := filetype.Detect(file)
+	if errors.Is(err, filetype.ErrUnsupported) {
+		return filetype.Type{}, fmt.Errorf("%w: %s", ErrUnsupportedCV, err)
+	}
+	if err != nil {
+		return filetype.Type{}, fmt.Errorf("cannot detect CV type: %w", err)
+	}
+
+	claimed, ok := filetype.ByExtension(path.Ext(filename))
+	if !ok || claimed != detected {
+		return filetype.Type{}, fmt.Errorf("%w: content of %s is %s document", ErrUnsupportedCV, filename, detected.Extension)
+	}
+
+	return detected, nil
 }
 
-// AddCandidate creates request with candidate.
+// AddCandidate creates request with candidate after checking it against eligibility rules.
 func (s *ReferralService) AddCandidate(ctx context.Context, request SubmitCandidateRequest) (string, error) {
 	userID, ok := mycontext.GetUserID(ctx)
 	if !ok {
 		return "", fmt.Errorf("cannot get user id from context")
 	}
 
-	fileID := uuid.NewRandom().String()
-	filename := fileID + "." + request.Filetype
+	eligibility, err := s.CheckEligibility(userID, request.CandidateName, request.CandidateSurname, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	cv := repository.File{
+		Filename:    path.Base(request.Filename),
+		ContentType: request.ContentType,
+		Key:         s.Keys.Key(uuid.NewRandom().String()+"."+request.Filetype, time.Now()),
+	}
+
+	cv.Size, cv.SHA256, err = digest(request.File)
+	if err != nil {
+		return "", err
+	}
 
-	err := s.storage.UploadFile(request.File, filename)
+	cv.UploadID, err = s.storeFile(userID, cv, request.File)
 	if err != nil {
-		return "", fmt.Errorf("cannot load file to object storage: %w", err)
+		return "", err
 	}
 
-	id, err := s.repo.AddCandidate(userID, request.CandidateName, request.CandidateSurname, filename)
+	id, err := s.addCandidate(userID, request, cv, eligibility)
+	if err != nil {
+		s.deleteObject(cv.Key)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// addCandidate creates request with uploaded CV. Request waits for scanning before recruiters can see it.
+// Duplicate candidate rule is checked again while request is added.
+func (s *ReferralService) addCandidate(userID string, request SubmitCandidateRequest, cv repository.File, eligibility Eligibility) (string, error) {
+	status := repository.StatusSubmitted
+	if s.Scanner != nil {
+		status = repository.StatusPendingScan
+	}
+
+	id, err := s.repo.AddCandidate(userID, request.CandidateName, request.CandidateSurname, request.Position, request.Department,
+		cv, status, eligibility.BonusEligible, eligibility.duplicateCheck())
+	if errors.Is(err, repository.ErrDuplicateCandidate) {
+		return "", &EligibilityError{Code: RuleDuplicateCandidate, Message: duplicateViolation(eligibility.duplicate.Value)}
+	}
 	if err != nil {
 		return "", fmt.Errorf("cannot add candidate to database: %w", err)
 	}
@@ -60,14 +201,38 @@ func (s *ReferralService) AddCandidate(ctx context.Context, request SubmitCandid
 	return id, nil
 }
 
-// GetRequests returns user requests.
-func (s *ReferralService) GetRequests(userID, status string, pageNumber, pageSize int) ([]repository.UserRequests, error) {
-	requests, err := s.repo.GetRequests(userID, status, pageNumber, pageSize)
+// GetRequests returns a page of requests which match the filter and total number of matched requests,
+// requests which exceed SLA of their status are flagged as overdue. Total isn't counted and is zero
+// with keyset pagination unless CountTotal is set.
+func (s *ReferralService) GetRequests(filter repository.RequestFilter) ([]repository.UserRequests, int, error) {
+	requests, err := s.repo.GetRequests(filter)
 	if err != nil {
-		return nil, fmt.Errorf("cannot get user requests: %w", err)
+		return nil, 0, fmt.Errorf("cannot get user requests: %w", err)
+	}
+
+	total := 0
+	if !filter.Keyset || filter.CountTotal {
+		total, err = s.repo.CountRequests(filter)
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot count user requests: %w", err)
+		}
+	}
+
+	now := time.Now()
+	for i := range requests {
+		requests[i].Overdue = s.SLA.isOverdue(requests[i].Status, requests[i].StatusChanged, now)
+	}
+
+	return requests, total, nil
+}
+
+// ExportRequests passes every request which matches the filter to fn.
+func (s *ReferralService) ExportRequests(filter repository.RequestFilter, fn func(repository.UserRequests) error) error {
+	if err := s.repo.ExportRequests(filter, fn); err != nil {
+		return fmt.Errorf("cannot export requests: %w", err)
 	}
 
-	return requests, nil
+	return nil
 }
 
 // DownloadFile downloads file from object storage.
@@ -79,8 +244,11 @@ func (s *ReferralService) DownloadFile(ctx context.Context, candidateID string,
 	if err != nil {
 		return "", fmt.Errorf("cannot get file id from object storage: %w", err)
 	}
+	if fileID == "" {
+		return "", ErrNoFile
+	}
 
-	url, err := s.storage.GetFileURL(fileID)
+	url, err := s.storage.SignedURL(fileID, cvURLExpiry)
 	if err != nil {
 		return "", fmt.Errorf("cannot download file from object storage: %w", err)
 	}
@@ -88,6 +256,63 @@ func (s *ReferralService) DownloadFile(ctx context.Context, candidateID string,
 	return url, nil
 }
 
+// CVFile presents CV opened to be streamed to user.
+type CVFile struct {
+	io.ReadSeekCloser
+
+	// Name presents file name for user built from name of the candidate.
+	Name        string
+	ContentType string
+}
+
+// OpenCV opens CV of the candidate, only requests of the user are searched if user id is set.
+func (s *ReferralService) OpenCV(candidateID, userID string) (CVFile, error) {
+	cv, err := s.repo.GetCV(candidateID, userID)
+	if errors.Is(err, repository.ErrNoFile) {
+		return CVFile{}, ErrNoFile
+	}
+	if err != nil {
+		return CVFile{}, fmt.Errorf("cannot get cv: %w", err)
+	}
+	if cv.FileID == "" {
+		return CVFile{}, ErrNoFile
+	}
+
+	file, err := s.storage.Open(cv.FileID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return CVFile{}, ErrNoFile
+	}
+	if err != nil {
+		return CVFile{}, fmt.Errorf("cannot open file from object storage: %w", err)
+	}
+
+	ext := strings.ToLower(path.Ext(cv.FileID))
+
+	// Content type is detected on upload, CVs uploaded before that have it guessed by extension.
+	contentType := cv.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(ext)
+	}
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	return CVFile{
+		ReadSeekCloser: file,
+		Name:           fmt.Sprintf("%s_%s_CV%s", cv.CandidateName, cv.CandidateSurname, ext),
+		ContentType:    contentType,
+	}, nil
+}
+
+// RecordCVDownload adds download of CV to the audit trail.
+func (s *ReferralService) RecordCVDownload(download repository.CVDownload) error {
+	if err := s.repo.AddCVDownload(download); err != nil {
+		return fmt.Errorf("cannot record cv download: %w", err)
+	}
+
+	return nil
+}
+
 // UpdateRequest updates request's status.
 func (s *ReferralService) UpdateRequest(id, status string) error {
 	err := s.repo.UpdateRequest(id, status)
@@ -100,3 +325,150 @@ func (s *ReferralService) UpdateRequest(id, status string) error {
 
 	return nil
 }
+
+// BulkUpdateRequest presents a type for status update of several requests.
+// Requests are selected either by ids or by filter.
+type BulkUpdateRequest struct {
+	IDs    []string
+	Filter *repository.RequestFilter
+	Status string
+	Atomic bool
+}
+
+// UpdateResult presents a result of status update of a single request.
+type UpdateResult struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// UpdateRequests updates status of several requests. In atomic mode either all requests
+// are updated or none of them, otherwise every request is updated independently.
+func (s *ReferralService) UpdateRequests(request BulkUpdateRequest) ([]UpdateResult, error) {
+	ids := request.IDs
+
+	if request.Filter != nil {
+		var err error
+
+		ids, err = s.repo.GetRequestIDs(*request.Filter, MaxBulkUpdateSize+1)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get requests by filter: %w", err)
+		}
+	}
+
+	if len(ids) > MaxBulkUpdateSize {
+		return nil, fmt.Errorf("%w: at most %d requests can be updated at once", ErrInvalidParameter, MaxBulkUpdateSize)
+	}
+
+	results := make([]UpdateResult, 0, len(ids))
+
+	if !request.Atomic {
+		for _, id := range ids {
+			result := UpdateResult{ID: id}
+			if err := s.UpdateRequest(id, request.Status); err != nil {
+				result.Error = err.Error()
+			}
+
+			results = append(results, result)
+		}
+
+		return results, nil
+	}
+
+	missing, err := s.repo.UpdateRequests(ids, request.Status)
+	if errors.Is(err, repository.ErrNoResult) {
+		for _, id := range missing {
+			results = append(results, UpdateResult{ID: id, Error: ErrNoResult.Error()})
+		}
+
+		return results, ErrNoResult
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot update user requests: %w", err)
+	}
+
+	for _, id := range ids {
+		results = append(results, UpdateResult{ID: id})
+	}
+
+	return results, nil
+}
+
+// GetStats returns statistics of requests created in [from, to).
+func (s *ReferralService) GetStats(from, to time.Time) (repository.Stats, error) {
+	stats, err := s.repo.GetStats(from, to)
+	if err != nil {
+		return repository.Stats{}, fmt.Errorf("cannot get requests statistics: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetUserStats returns statistics of user requests.
+func (s *ReferralService) GetUserStats(userID string) (repository.UserStats, error) {
+	stats, err := s.repo.GetUserStats(userID)
+	if err != nil {
+		return repository.UserStats{}, fmt.Errorf("cannot get user requests statistics: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetLeaderboard returns referrers ranked by number of requests accepted in [from, to).
+func (s *ReferralService) GetLeaderboard(from, to time.Time, limit int) ([]repository.LeaderboardEntry, error) {
+	entries, err := s.repo.GetLeaderboard(from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get leaderboard: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetPreferences returns user settings.
+func (s *ReferralService) GetPreferences(userID string) (repository.Preferences, error) {
+	preferences, err := s.repo.GetPreferences(userID)
+	if errors.Is(err, repository.ErrNoUser) {
+		return repository.Preferences{}, ErrNoUser
+	}
+	if err != nil {
+		return repository.Preferences{}, fmt.Errorf("cannot get user preferences: %w", err)
+	}
+
+	return preferences, nil
+}
+
+// ValidatePreferences validates user settings and normalizes email and notification categories.
+func ValidatePreferences(preferences *repository.Preferences) error {
+	preferences.Email = strings.TrimSpace(preferences.Email)
+	if preferences.Email != "" {
+		address, err := mail.ParseAddress(preferences.Email)
+		if err != nil || address.Address != preferences.Email {
+			return fmt.Errorf("%w: email has bad format", ErrInvalidParameter)
+		}
+	}
+
+	for i, category := range preferences.MutedNotifications {
+		preferences.MutedNotifications[i] = strings.ToLower(strings.TrimSpace(category))
+		if !notification.IsCategory(preferences.MutedNotifications[i]) {
+			return fmt.Errorf("%w: notification category %s", ErrInvalidParameter, category)
+		}
+	}
+
+	return nil
+}
+
+// UpdatePreferences updates user settings.
+func (s *ReferralService) UpdatePreferences(userID string, preferences repository.Preferences) error {
+	if err := ValidatePreferences(&preferences); err != nil {
+		return err
+	}
+
+	err := s.repo.UpdatePreferences(userID, preferences)
+	if errors.Is(err, repository.ErrNoUser) {
+		return ErrNoUser
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update user preferences: %w", err)
+	}
+
+	return nil
+}
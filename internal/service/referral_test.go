@@ -0,0 +1,100 @@
+package service
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferralService_OpenCV(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := files.Upload("cv/1.PDF", strings.NewReader("content")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testTable := []struct {
+		testName            string
+		fileID              string
+		expectedName        string
+		expectedContentType string
+		expectedError       error
+	}{
+		{
+			testName:            "Success",
+			fileID:              "cv/1.PDF",
+			expectedName:        "Billie_Jean_CV.pdf",
+			expectedContentType: "application/pdf",
+		},
+		{
+			testName:      "Failure: file is missing in storage",
+			fileID:        "cv/2.pdf",
+			expectedError: ErrNoFile,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT (.+) FROM requests").
+				WithArgs("1", "5").
+				WillReturnRows(sqlmock.NewRows([]string{"cv_file_id", "cv_content_type", "candidate_name", "candidate_surname"}).
+					AddRow(tc.fileID, "", "Billie", "Jean"))
+
+			s := NewReferralService(repository.NewRepository(db), files)
+
+			file, err := s.OpenCV("1", "5")
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			defer file.Close()
+
+			assert.Equal(t, tc.expectedName, file.Name)
+			assert.Equal(t, tc.expectedContentType, file.ContentType)
+
+			content, err := io.ReadAll(file)
+			assert.NoError(t, err)
+			assert.Equal(t, "content", string(content))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReferralService_UpdateRequests_FilterLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id"})
+	for i := 1; i <= MaxBulkUpdateSize+1; i++ {
+		rows.AddRow(strconv.Itoa(i))
+	}
+	mock.ExpectQuery("SELECT id FROM requests (.+) LIMIT 501").WillReturnRows(rows)
+
+	s := NewReferralService(repository.NewRepository(db), nil)
+
+	_, err = s.UpdateRequests(BulkUpdateRequest{
+		Filter: &repository.RequestFilter{Statuses: []string{repository.StatusSubmitted}},
+		Status: repository.StatusAccepted,
+	})
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
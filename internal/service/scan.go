@@ -0,0 +1,204 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/scan"
+)
+
+const (
+	scanBatchSize   = 20
+	maxScanAttempts = 5
+
+	// quarantinePrefix presents key prefix of infected CVs, they're never served to users.
+	quarantinePrefix = "quarantine/"
+)
+
+// ScanCVs scans CVs of requests pending scan which are due at the time. Requests with clean CVs are submitted,
+// infected CVs are moved to quarantine. If scanner can't give a verdict about a CV, the request is retried with
+// exponential backoff and its CV is quarantined once it runs out of attempts. Scanning stops only if scanner is
+// unavailable, requests stay pending till the next run then. Number of scanned CVs is returned.
+func (s *ReferralService) ScanCVs(now time.Time) (int, error) {
+	if s.Scanner == nil {
+		return 0, nil
+	}
+
+	pending, err := s.repo.GetPendingScans(now, scanBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get requests pending scan: %w", err)
+	}
+
+	scanned := 0
+	for _, p := range pending {
+		result, err := s.scanObject(p.FileID)
+		if errors.Is(err, scan.ErrUnavailable) {
+			return scanned, fmt.Errorf("cannot scan CV of request %s: %w", p.RequestID, err)
+		}
+		if err != nil {
+			if err := s.failScan(p, now, err); err != nil {
+				return scanned, err
+			}
+
+			continue
+		}
+
+		if result.Infected {
+			err = s.quarantineCV(p, result.Signature)
+		} else {
+			err = s.repo.AcceptScannedCV(p.RequestID)
+		}
+		if err != nil && !errors.Is(err, repository.ErrNoResult) {
+			return scanned, err
+		}
+
+		scanned++
+	}
+
+	return scanned, nil
+}
+
+// failScan records failed attempt to scan CV of the request. CV which can't be scanned in all attempts is
+// quarantined, so it's never served unscanned.
+func (s *ReferralService) failScan(p repository.PendingScan, now time.Time, scanErr error) error {
+	if s.Logger != nil {
+		s.Logger.Println(fmt.Errorf("cannot scan CV of request %s: %w", p.RequestID, scanErr))
+	}
+
+	attempts := p.Attempts + 1
+	if err := s.repo.SaveScanAttempt(p.RequestID, attempts, now.Add(event.RetryDelay(attempts)), scanErr.Error()); err != nil {
+		return err
+	}
+
+	if attempts < maxScanAttempts {
+		return nil
+	}
+
+	// Quarantine is retried with the scan at the next attempt, so it doesn't stop scanning of other CVs.
+	if err := s.quarantineCV(p, ""); err != nil && !errors.Is(err, repository.ErrNoResult) && s.Logger != nil {
+		s.Logger.Println(err)
+	}
+
+	return nil
+}
+
+// ScanFiles scans files attached to requests which are pending scan and due at the time. Clean files are served
+// to users and a clean CV version becomes the current CV, infected files are moved to quarantine. Failed scans
+// are retried the same way as scans of CVs of new requests. Number of scanned files is returned.
+func (s *ReferralService) ScanFiles(now time.Time) (int, error) {
+	if s.Scanner == nil {
+		return 0, nil
+	}
+
+	pending, err := s.repo.GetPendingFileScans(now, scanBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get files pending scan: %w", err)
+	}
+
+	scanned := 0
+	for _, p := range pending {
+		result, err := s.scanObject(p.Key)
+		if errors.Is(err, scan.ErrUnavailable) {
+			return scanned, fmt.Errorf("cannot scan file %s: %w", p.FileID, err)
+		}
+		if err != nil {
+			if err := s.failFileScan(p, now, err); err != nil {
+				return scanned, err
+			}
+
+			continue
+		}
+
+		if result.Infected {
+			err = s.quarantineFile(p, result.Signature)
+		} else {
+			err = s.repo.AcceptScannedFile(p.FileID)
+		}
+		if err != nil && !errors.Is(err, repository.ErrNoResult) {
+			return scanned, err
+		}
+
+		scanned++
+	}
+
+	return scanned, nil
+}
+
+// failFileScan records failed attempt to scan the file, file which can't be scanned in all attempts is quarantined.
+func (s *ReferralService) failFileScan(p repository.PendingFileScan, now time.Time, scanErr error) error {
+	if s.Logger != nil {
+		s.Logger.Println(fmt.Errorf("cannot scan file %s: %w", p.FileID, scanErr))
+	}
+
+	attempts := p.Attempts + 1
+	if err := s.repo.SaveFileScanAttempt(p.FileID, attempts, now.Add(event.RetryDelay(attempts)), scanErr.Error()); err != nil {
+		return err
+	}
+
+	if attempts < maxScanAttempts {
+		return nil
+	}
+
+	if err := s.quarantineFile(p, ""); err != nil && !errors.Is(err, repository.ErrNoResult) && s.Logger != nil {
+		s.Logger.Println(err)
+	}
+
+	return nil
+}
+
+func (s *ReferralService) scanObject(key string) (scan.Result, error) {
+	file, err := s.storage.Open(key)
+	if err != nil {
+		return scan.Result{}, fmt.Errorf("cannot open file from object storage: %w", err)
+	}
+	defer file.Close()
+
+	return s.Scanner.Scan(file)
+}
+
+// quarantineCV moves infected CV under the quarantine prefix and marks the request as quarantined.
+func (s *ReferralService) quarantineCV(p repository.PendingScan, signature string) error {
+	return s.quarantine(p.FileID, func(quarantined string) error {
+		if err := s.repo.QuarantineCV(p.RequestID, quarantined, signature); err != nil {
+			return fmt.Errorf("cannot quarantine CV of request %s: %w", p.RequestID, err)
+		}
+
+		return nil
+	})
+}
+
+// quarantineFile moves infected file under the quarantine prefix and marks it as infected.
+func (s *ReferralService) quarantineFile(p repository.PendingFileScan, signature string) error {
+	return s.quarantine(p.Key, func(quarantined string) error {
+		return s.repo.QuarantineFile(p.FileID, quarantined, signature)
+	})
+}
+
+// quarantine copies the object under the quarantine prefix and saves the key of the copy.
+// Original is deleted only after the copy is saved, the copy is deleted if it can't be saved.
+func (s *ReferralService) quarantine(key string, save func(quarantined string) error) error {
+	file, err := s.storage.Open(key)
+	if err != nil {
+		return fmt.Errorf("cannot open file from object storage: %w", err)
+	}
+	defer file.Close()
+
+	quarantined := quarantinePrefix + key
+	if err := s.storage.Upload(quarantined, file); err != nil {
+		return fmt.Errorf("cannot move file %s to quarantine: %w", key, err)
+	}
+
+	if err := save(quarantined); err != nil {
+		s.deleteObject(quarantined)
+		return err
+	}
+
+	if err := s.storage.Delete(key); err != nil && s.Logger != nil {
+		s.Logger.Println(fmt.Errorf("cannot delete infected file %s: %w", key, err))
+	}
+
+	return nil
+}
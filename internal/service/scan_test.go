@@ -0,0 +1,345 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/scan"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+type scannerFunc func(r io.Reader) (scan.Result, error)
+
+func (f scannerFunc) Scan(r io.Reader) (scan.Result, error) {
+	return f(r)
+}
+
+var pendingScanColumns = []string{"id", "cv_file_id", "scan_attempts"}
+
+func TestReferralService_ScanCVs(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, content := range map[string]string{"1.pdf": "clean", "2.pdf": "infected", "3.pdf": "clean"} {
+		if err := files.Upload(key, strings.NewReader(content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM requests").
+		WithArgs(repository.StatusPendingScan, now, scanBatchSize).
+		WillReturnRows(sqlmock.NewRows(pendingScanColumns).
+			AddRow("1", "1.pdf", 0).
+			AddRow("2", "2.pdf", 0).
+			AddRow("3", "3.pdf", 0))
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE files").
+		WithArgs("", repository.FileScanClean, "1", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE requests r SET status").
+		WithArgs(repository.StatusSubmitted, "", "", "1", repository.StatusPendingScan).
+		WillReturnRows(sqlmock.NewRows([]string{"held_status"}).AddRow(""))
+	mock.ExpectExec("SELECT (.+) FROM recruiters, requests (.+) FOR UPDATE OF recruiters").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE requests SET assignee_id").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox").
+		WithArgs(event.ReferralCreated, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE files").
+		WithArgs("quarantine/2.pdf", repository.FileScanInfected, "2", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE requests r SET status").
+		WithArgs(repository.StatusQuarantined, "quarantine/2.pdf", "Eicar-Test-Signature", "2", repository.StatusPendingScan).
+		WillReturnRows(sqlmock.NewRows([]string{"held_status"}).AddRow(""))
+	mock.ExpectExec("INSERT INTO outbox").
+		WithArgs(event.ReferralStatusChanged, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Request 3 has been scanned by another run meanwhile.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE files").
+		WithArgs("", repository.FileScanClean, "3", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("UPDATE requests r SET status").
+		WithArgs(repository.StatusSubmitted, "", "", "3", repository.StatusPendingScan).
+		WillReturnRows(sqlmock.NewRows([]string{"held_status"}))
+	mock.ExpectRollback()
+
+	s := NewReferralService(repository.NewRepository(db), files)
+	s.Scanner = scannerFunc(func(r io.Reader) (scan.Result, error) {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return scan.Result{}, err
+		}
+
+		if string(content) == "infected" {
+			return scan.Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+		}
+
+		return scan.Result{}, nil
+	})
+
+	scanned, err := s.ScanCVs(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, scanned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = files.Stat("2.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	quarantined, err := files.Open("quarantine/2.pdf")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(quarantined)
+	assert.NoError(t, err)
+	assert.NoError(t, quarantined.Close())
+	assert.Equal(t, "infected", string(content))
+}
+
+func TestReferralService_ScanCVs_ScannerUnavailable(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := files.Upload("1.pdf", strings.NewReader("clean")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM requests").
+		WithArgs(repository.StatusPendingScan, now, scanBatchSize).
+		WillReturnRows(sqlmock.NewRows(pendingScanColumns).AddRow("1", "1.pdf", 0))
+
+	s := NewReferralService(repository.NewRepository(db), files)
+	s.Scanner = scannerFunc(func(io.Reader) (scan.Result, error) {
+		return scan.Result{}, scan.ErrUnavailable
+	})
+
+	scanned, err := s.ScanCVs(now)
+	assert.ErrorIs(t, err, scan.ErrUnavailable)
+	assert.Equal(t, 0, scanned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReferralService_ScanCVs_Retry(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, content := range map[string]string{"1.pdf": "broken", "2.pdf": "broken", "3.pdf": "clean"} {
+		if err := files.Upload(key, strings.NewReader(content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM requests").
+		WithArgs(repository.StatusPendingScan, now, scanBatchSize).
+		WillReturnRows(sqlmock.NewRows(pendingScanColumns).
+			AddRow("1", "1.pdf", 0).
+			AddRow("2", "2.pdf", maxScanAttempts-1).
+			AddRow("3", "3.pdf", 0))
+
+	mock.ExpectExec("UPDATE requests SET scan_attempts").
+		WithArgs(1, now.Add(event.RetryDelay(1)), "cannot scan file: clamd error: broken", "1", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("UPDATE requests SET scan_attempts").
+		WithArgs(maxScanAttempts, now.Add(event.RetryDelay(maxScanAttempts)), "cannot scan file: clamd error: broken", "2", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE files").
+		WithArgs("quarantine/2.pdf", repository.FileScanInfected, "2", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE requests r SET status").
+		WithArgs(repository.StatusQuarantined, "quarantine/2.pdf", "", "2", repository.StatusPendingScan).
+		WillReturnRows(sqlmock.NewRows([]string{"held_status"}).AddRow(""))
+	mock.ExpectExec("INSERT INTO outbox").
+		WithArgs(event.ReferralStatusChanged, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE files").
+		WithArgs("", repository.FileScanClean, "3", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE requests r SET status").
+		WithArgs(repository.StatusSubmitted, "", "", "3", repository.StatusPendingScan).
+		WillReturnRows(sqlmock.NewRows([]string{"held_status"}).AddRow(""))
+	mock.ExpectExec("SELECT (.+) FROM recruiters, requests (.+) FOR UPDATE OF recruiters").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE requests SET assignee_id").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox").
+		WithArgs(event.ReferralCreated, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	s := NewReferralService(repository.NewRepository(db), files)
+	s.Scanner = scannerFunc(func(r io.Reader) (scan.Result, error) {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return scan.Result{}, err
+		}
+
+		if string(content) == "broken" {
+			return scan.Result{}, fmt.Errorf("%w: clamd error: broken", scan.ErrScan)
+		}
+
+		return scan.Result{}, nil
+	})
+
+	scanned, err := s.ScanCVs(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, scanned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = files.Stat("quarantine/2.pdf")
+	assert.NoError(t, err)
+}
+
+func TestReferralService_ScanFiles(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for key, content := range map[string]string{"cv.pdf": "clean", "portfolio.pdf": "infected", "letter.pdf": "broken"} {
+		if err := files.Upload(key, strings.NewReader(content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM files").
+		WithArgs(repository.FileScanPending, now, scanBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "file_key", "scan_attempts"}).
+			AddRow("7", "cv.pdf", 0).
+			AddRow("8", "portfolio.pdf", 0).
+			AddRow("9", "letter.pdf", 1))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE files SET scan_status = (.+) RETURNING").
+		WithArgs(repository.FileScanClean, "7", repository.FileScanPending).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "request_id", "kind", "file_key", "content_type"}).
+			AddRow("7", "1", repository.FileKindCV, "cv.pdf", "application/pdf"))
+	mock.ExpectExec("UPDATE requests SET cv_file_id (.+) NOT EXISTS").
+		WithArgs("cv.pdf", "application/pdf", "1", "7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectExec("UPDATE files SET file_key").
+		WithArgs("quarantine/portfolio.pdf", repository.FileScanInfected, "Eicar-Test-Signature", "8", repository.FileScanPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	mock.ExpectExec("UPDATE files SET scan_attempts").
+		WithArgs(2, now.Add(event.RetryDelay(2)), "cannot scan file: clamd error: broken", "9", repository.FileScanPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s := NewReferralService(repository.NewRepository(db), files)
+	s.Scanner = scannerFunc(func(r io.Reader) (scan.Result, error) {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return scan.Result{}, err
+		}
+
+		switch string(content) {
+		case "infected":
+			return scan.Result{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+		case "broken":
+			return scan.Result{}, fmt.Errorf("%w: clamd error: broken", scan.ErrScan)
+		default:
+			return scan.Result{}, nil
+		}
+	})
+
+	scanned, err := s.ScanFiles(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, scanned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = files.Stat("portfolio.pdf")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = files.Stat("quarantine/portfolio.pdf")
+	assert.NoError(t, err)
+}
+
+func TestReferralService_ScanCVs_Imported(t *testing.T) {
+	files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := files.Upload("1.pdf", strings.NewReader("clean")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM requests").
+		WithArgs(repository.StatusPendingScan, now, scanBatchSize).
+		WillReturnRows(sqlmock.NewRows(pendingScanColumns).AddRow("1", "1.pdf", 0))
+
+	// Imported request gets its imported status and is neither assigned nor announced.
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE files").
+		WithArgs("", repository.FileScanClean, "1", repository.StatusPendingScan).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("UPDATE requests r SET status").
+		WithArgs(repository.StatusSubmitted, "", "", "1", repository.StatusPendingScan).
+		WillReturnRows(sqlmock.NewRows([]string{"held_status"}).AddRow(repository.StatusAccepted))
+	mock.ExpectCommit()
+
+	s := NewReferralService(repository.NewRepository(db), files)
+	s.Scanner = scannerFunc(func(r io.Reader) (scan.Result, error) {
+		return scan.Result{}, nil
+	})
+
+	scanned, err := s.ScanCVs(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, scanned)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
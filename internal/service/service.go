@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
 
+	"github.com/cyberdr0id/referral/internal/event"
 	"github.com/cyberdr0id/referral/internal/repository"
 	myjwt "github.com/cyberdr0id/referral/pkg/jwt"
 )
@@ -20,6 +23,24 @@ var (
 
 	// ErrUserAlreadyExists handles an error when user tries to sign up with existing data.
 	ErrUserAlreadyExists = errors.New("user already exists")
+
+	// ErrUnsupportedCV presents an error when CV isn't a document of allowed type or doesn't match its extension.
+	ErrUnsupportedCV = errors.New("unsupported CV file type")
+
+	// ErrUnscannedFile presents an error when file is pending malware scan or is infected, so it isn't served.
+	ErrUnscannedFile = errors.New("file is pending malware scan or is infected")
+
+	// ErrNoUpload presents an error when upload slot doesn't exist, has expired or has been used.
+	ErrNoUpload = errors.New("there is no upload with input id")
+
+	// ErrUploadIncomplete presents an error when file hasn't been uploaded by upload slot yet.
+	ErrUploadIncomplete = errors.New("file hasn't been uploaded")
+
+	// ErrUploadMismatch presents an error when uploaded file doesn't match its upload slot.
+	ErrUploadMismatch = errors.New("uploaded file doesn't match the upload")
+
+	// ErrCurrentCV presents an error when the current CV of request is deleted, a new version must be uploaded instead.
+	ErrCurrentCV = errors.New("current CV cannot be deleted")
 )
 
 // Auth presents interface for authorization and registration actions.
@@ -31,8 +52,49 @@ type Auth interface {
 
 // Referral presents a type of CV interaction.
 type Referral interface {
-	GetRequests(userID, status string, pageNumber, pageSize int) ([]repository.UserRequests, error)
+	GetRequests(filter repository.RequestFilter) ([]repository.UserRequests, int, error)
+	ExportRequests(filter repository.RequestFilter, fn func(repository.UserRequests) error) error
 	AddCandidate(ctx context.Context, request SubmitCandidateRequest) (string, error)
+	CreateUpload(userID, filename string, size int64, sha256 string) (UploadSlot, error)
+	AddUploadedCandidate(ctx context.Context, request SubmitCandidateRequest) (string, error)
 	DownloadFile(ctx context.Context, id string, userID string) (string, error)
+	OpenCV(candidateID, userID string) (CVFile, error)
+	RecordCVDownload(download repository.CVDownload) error
+	GetFiles(requestID, authorID string) ([]repository.File, error)
+	GetFile(fileID, authorID string) (repository.File, error)
+	AttachFile(request AttachFileRequest) (repository.File, error)
+	DeleteFile(fileID, authorID string) error
+	FileLink(f repository.File) (string, error)
+	OpenFile(f repository.File) (CVFile, error)
 	UpdateRequest(id, status string) error
+	UpdateRequests(request BulkUpdateRequest) ([]UpdateResult, error)
+	ImportRequests(r io.Reader, openCV CVOpener, dryRun bool) (ImportReport, error)
+	GetStats(from, to time.Time) (repository.Stats, error)
+	GetUserStats(userID string) (repository.UserStats, error)
+	GetLeaderboard(from, to time.Time, limit int) ([]repository.LeaderboardEntry, error)
+	GetPreferences(userID string) (repository.Preferences, error)
+	UpdatePreferences(userID string, preferences repository.Preferences) error
+	GetBonusRules() ([]repository.BonusRule, error)
+	SaveBonusRule(rule repository.BonusRule) error
+	DeleteBonusRule(position string) error
+	GetBonuses(filter repository.BonusFilter) ([]repository.Bonus, error)
+	ExportBonuses(filter repository.BonusFilter, fn func(repository.Bonus) error) error
+	UpdateBonus(id, status string, now time.Time) error
+	GetEligibilityRules() ([]repository.EligibilityRule, error)
+	SaveEligibilityRule(rule repository.EligibilityRule) error
+	UpdateEmployee(employee repository.Employee) error
+	GetAssignmentRules() ([]repository.AssignmentRule, error)
+	SaveAssignmentRule(rule repository.AssignmentRule) error
+	GetRecruiters() ([]repository.Recruiter, error)
+	SetRecruiterPositions(userID string, positions []string) error
+	AssignRequest(id, assigneeID string) error
+	GetOverdueRequests(now time.Time) ([]OverdueRequest, error)
+	GetWebhooks() ([]repository.Webhook, error)
+	AddWebhook(w repository.Webhook) (repository.Webhook, error)
+	UpdateWebhook(w repository.Webhook) error
+	DeleteWebhook(id string) error
+	GetDeliveries(filter repository.DeliveryFilter) ([]repository.Delivery, error)
+	Redeliver(id string, now time.Time) error
+	ReplayEvents(afterID, authorID string) ([]event.Event, bool, error)
+	SubscribeEvents(authorID string) *event.Subscription
 }
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/notification"
+	"github.com/cyberdr0id/referral/internal/repository"
+)
+
+// SLA presents service level agreement of requests processing.
+type SLA struct {
+	// Limits presents maximum time a request may stay in a status, statuses without limit never become overdue.
+	Limits map[string]time.Duration
+
+	// RemindEvery presents minimum time between reminders about the same request.
+	RemindEvery time.Duration
+}
+
+// OverdueRequest presents a request which exceeds SLA of its status.
+type OverdueRequest struct {
+	repository.OverdueRequest
+	OverdueHours float64 `json:"overdueHours"`
+}
+
+// isOverdue checks if request with the status, which it got at the time, exceeds SLA.
+func (s SLA) isOverdue(status, changed string, now time.Time) bool {
+	limit, ok := s.Limits[status]
+	if !ok {
+		return false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, changed)
+	if err != nil {
+		return false
+	}
+
+	return now.Sub(t) > limit
+}
+
+// GetOverdueRequests returns all requests which exceed SLA of their status, the most overdue go first.
+func (s *ReferralService) GetOverdueRequests(now time.Time) ([]OverdueRequest, error) {
+	requests, err := s.repo.GetOverdueRequests(s.SLA.Limits, now, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get overdue requests: %w", err)
+	}
+
+	overdue := make([]OverdueRequest, 0, len(requests))
+	for _, request := range requests {
+		overdue = append(overdue, OverdueRequest{
+			OverdueRequest: request,
+			OverdueHours:   now.Sub(request.StatusChanged.Add(s.SLA.Limits[request.Status])).Hours(),
+		})
+	}
+
+	return overdue, nil
+}
+
+// SendSLAReminders notifies assignees about their overdue requests, all admins are notified
+// about overdue requests without assignee. Reminder about the same request is sent
+// not more often than SLA allows. Number of requests reminded about is returned.
+func (s *ReferralService) SendSLAReminders(now time.Time) (int, error) {
+	if s.Notifier == nil || len(s.SLA.Limits) == 0 {
+		return 0, nil
+	}
+
+	requests, err := s.repo.GetOverdueRequests(s.SLA.Limits, now, now.Add(-s.SLA.RemindEvery))
+	if err != nil {
+		return 0, fmt.Errorf("cannot get overdue requests: %w", err)
+	}
+
+	var admins []string
+	adminsLoaded := false
+	reminded := 0
+
+	for _, request := range requests {
+		var recipients []string
+
+		if request.AssigneeID != nil {
+			recipients = []string{*request.AssigneeID}
+		} else {
+			if !adminsLoaded {
+				admins, err = s.repo.GetAdminIDs()
+				if err != nil {
+					return reminded, fmt.Errorf("cannot get admins: %w", err)
+				}
+
+				adminsLoaded = true
+			}
+
+			recipients = admins
+		}
+
+		for _, userID := range recipients {
+			err := s.Notifier.Notify(notification.Notification{
+				UserID:   userID,
+				Category: notification.CategorySLAReminder,
+				Data: map[string]string{
+					"id":       request.ID,
+					"name":     request.Name,
+					"surname":  request.Surname,
+					"position": request.Position,
+					"status":   request.Status,
+					"since":    request.StatusChanged.Format(time.RFC1123),
+				},
+			})
+			if err != nil {
+				return reminded, fmt.Errorf("cannot send reminder about request %s: %w", request.ID, err)
+			}
+		}
+
+		// Requests are marked one by one, so reminders which are already sent aren't repeated after a failure.
+		if err := s.repo.MarkReminded([]string{request.ID}, now); err != nil {
+			return reminded, err
+		}
+
+		reminded++
+	}
+
+	return reminded, nil
+}
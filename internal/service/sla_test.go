@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/notification"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+type notifierFunc func(n notification.Notification) error
+
+func (f notifierFunc) Notify(n notification.Notification) error {
+	return f(n)
+}
+
+var overdueColumns = []string{"id", "candidate_name", "candidate_surname", "position", "status", "author_id", "assignee_id", "status_changed"}
+
+func TestSLA_isOverdue(t *testing.T) {
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	sla := SLA{Limits: map[string]time.Duration{repository.StatusSubmitted: 72 * time.Hour}}
+
+	testTable := []struct {
+		testName string
+		status   string
+		updated  string
+		expected bool
+	}{
+		{testName: "Overdue", status: repository.StatusSubmitted, updated: "2022-06-06T00:00:00Z", expected: true},
+		{testName: "Within SLA", status: repository.StatusSubmitted, updated: "2022-06-08T00:00:00Z"},
+		{testName: "Status without SLA", status: repository.StatusAccepted, updated: "2022-01-01T00:00:00Z"},
+		{testName: "Invalid time", status: repository.StatusSubmitted, updated: "yesterday"},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expected, sla.isOverdue(tc.status, tc.updated, now))
+		})
+	}
+}
+
+func TestReferralService_SendSLAReminders(t *testing.T) {
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	updated := now.AddDate(0, 0, -5)
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM requests WHERE (.+)status_changed < (.+) ORDER BY status_changed, id").
+		WithArgs(repository.StatusSubmitted, now.Add(-72*time.Hour), now.Add(-24*time.Hour)).
+		WillReturnRows(sqlmock.NewRows(overdueColumns).
+			AddRow("1", "Billie", "Jean", "developer", repository.StatusSubmitted, "5", "7", updated).
+			AddRow("2", "Igor", "Nikolaev", "", repository.StatusSubmitted, "5", nil, updated).
+			AddRow("3", "Ken", "Thompson", "", repository.StatusSubmitted, "5", nil, updated))
+	mock.ExpectExec("UPDATE requests").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM users").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("8").AddRow("9"))
+	mock.ExpectExec("UPDATE requests").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE requests").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var recipients []string
+
+	s := NewReferralService(repository.NewRepository(db), nil)
+	s.SLA = SLA{Limits: map[string]time.Duration{repository.StatusSubmitted: 72 * time.Hour}, RemindEvery: 24 * time.Hour}
+	s.Notifier = notifierFunc(func(n notification.Notification) error {
+		assert.Equal(t, notification.CategorySLAReminder, n.Category)
+		recipients = append(recipients, n.UserID)
+		return nil
+	})
+
+	reminded, err := s.SendSLAReminders(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reminded)
+	assert.Equal(t, []string{"7", "8", "9", "8", "9"}, recipients)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
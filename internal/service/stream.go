@@ -0,0 +1,62 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/repository"
+)
+
+const maxReplayedEvents = 1000
+
+// streamedEvents presents events which are streamed to clients. Only creation and status changes of requests
+// are streamed, comment events aren't since requests have no comments yet.
+var streamedEvents = []string{event.ReferralCreated, event.ReferralStatusChanged}
+
+// ReplayEvents returns at most maxReplayedEvents streamed events recorded after the event with id and whether
+// there are more of them, so the replay is truncated. If author isn't empty, only events about requests
+// of the author are returned.
+func (s *ReferralService) ReplayEvents(afterID, authorID string) ([]event.Event, bool, error) {
+	events, err := s.repo.GetEventsAfter(afterID, streamedEvents, authorID, maxReplayedEvents+1)
+	if err != nil {
+		return nil, false, fmt.Errorf("cannot replay events: %w", err)
+	}
+
+	if len(events) > maxReplayedEvents {
+		return events[:maxReplayedEvents], true, nil
+	}
+
+	return events, false, nil
+}
+
+// SubscribeEvents subscribes to streamed events as they are published. If author isn't empty,
+// only events about requests of the author are received.
+func (s *ReferralService) SubscribeEvents(authorID string) *event.Subscription {
+	return s.Events.Subscribe(func(e event.Event) bool {
+		if !isStreamed(e.Name) {
+			return false
+		}
+
+		if authorID == "" {
+			return true
+		}
+
+		var request repository.ReferralEvent
+		if err := json.Unmarshal(e.Payload, &request); err != nil {
+			return false
+		}
+
+		return request.AuthorID == authorID
+	})
+}
+
+func isStreamed(name string) bool {
+	for _, streamed := range streamedEvents {
+		if streamed == name {
+			return true
+		}
+	}
+
+	return false
+}
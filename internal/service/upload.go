@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	mycontext "github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/cyberdr0id/referral/pkg/filetype"
+	"github.com/pborman/uuid"
+)
+
+const (
+	// uploadURLExpiry presents time CV must be uploaded within.
+	uploadURLExpiry = 15 * time.Minute
+
+	// uploadExpiry presents time request must be created within after upload slot is created.
+	uploadExpiry = time.Hour
+
+	// pendingUploadExpiry presents time file uploaded by the application must be added within,
+	// otherwise the upload is considered to be interrupted and the file is deleted.
+	pendingUploadExpiry = 24 * time.Hour
+)
+
+// UploadSlot presents a slot for CV which is uploaded directly to object storage by presigned request.
+type UploadSlot struct {
+	storage.SignedUpload
+
+	ID      string    `json:"id"`
+	Expires time.Time `json:"expires"`
+}
+
+// CreateUpload creates a slot for CV of the size and SHA-256 checksum in hex which is uploaded directly
+// to object storage. Type of CV is determined by extension of the file name, uploaded CV must have exactly
+// this type and size, object storage accepts only content with the checksum.
+func (s *ReferralService) CreateUpload(userID, filename string, size int64, sha256 string) (UploadSlot, error) {
+	cvType, ok := filetype.ByExtension(path.Ext(filename))
+	if !ok {
+		return UploadSlot{}, fmt.Errorf("%w: %s", ErrUnsupportedCV, filename)
+	}
+
+	now := time.Now()
+	upload := repository.Upload{
+		ID:          uuid.NewRandom().String(),
+		UserID:      userID,
+		Key:         s.Keys.Key(uuid.NewRandom().String()+"."+cvType.Extension, now),
+		Filename:    path.Base(filename),
+		ContentType: cvType.MIME,
+		Size:        size,
+		SHA256:      strings.ToLower(sha256),
+		Expires:     now.Add(uploadExpiry),
+	}
+
+	signed, err := s.storage.SignedUploadURL(upload.Key, upload.ContentType, upload.Size, upload.SHA256, uploadURLExpiry)
+	if errors.Is(err, storage.ErrInvalidChecksum) {
+		return UploadSlot{}, fmt.Errorf("%w: %s", ErrInvalidParameter, err)
+	}
+	if err != nil {
+		return UploadSlot{}, fmt.Errorf("cannot sign upload: %w", err)
+	}
+
+	if err := s.repo.AddUpload(upload); err != nil {
+		return UploadSlot{}, fmt.Errorf("cannot create upload: %w", err)
+	}
+
+	return UploadSlot{SignedUpload: signed, ID: upload.ID, Expires: upload.Expires}, nil
+}
+
+// AddUploadedCandidate creates request with CV uploaded by the upload slot. Uploaded CV is copied to a key
+// the slot can't upload to, the copy must have the size of the slot and its content must be a document
+// of the slot type. The slot is used in the same transaction the request is created in, so it can't be
+// used again, and the uploaded object is deleted afterwards.
+func (s *ReferralService) AddUploadedCandidate(ctx context.Context, request SubmitCandidateRequest) (string, error) {
+	userID, ok := mycontext.GetUserID(ctx)
+	if !ok {
+		return "", fmt.Errorf("cannot get user id from context")
+	}
+
+	upload, err := s.repo.GetUpload(request.UploadID, userID)
+	if errors.Is(err, repository.ErrNoUpload) {
+		return "", ErrNoUpload
+	}
+	if err != nil {
+		return "", fmt.Errorf("cannot get upload: %w", err)
+	}
+
+	cv, err := s.verifyUpload(upload)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := s.addVerifiedCandidate(userID, request, cv)
+	if err != nil {
+		s.deleteObject(cv.Key)
+	}
+	if errors.Is(err, repository.ErrNoUpload) {
+		return "", ErrNoUpload
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.deleteObject(upload.Key)
+
+	return id, nil
+}
+
+func (s *ReferralService) addVerifiedCandidate(userID string, request SubmitCandidateRequest, cv repository.File) (string, error) {
+	eligibility, err := s.CheckEligibility(userID, request.CandidateName, request.CandidateSurname, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	return s.addCandidate(userID, request, cv, eligibility)
+}
+
+// storeFile uploads file to object storage. The upload is tracked as pending until the file is added
+// to database by the returned upload id, so files of interrupted requests are found and deleted.
+func (s *ReferralService) storeFile(userID string, f repository.File, file io.ReadSeeker) (string, error) {
+	upload := repository.Upload{
+		ID:          uuid.NewRandom().String(),
+		UserID:      userID,
+		Key:         f.Key,
+		Filename:    f.Filename,
+		ContentType: f.ContentType,
+		Size:        f.Size,
+		SHA256:      f.SHA256,
+		Expires:     time.Now().Add(pendingUploadExpiry),
+	}
+
+	if err := s.repo.AddUpload(upload); err != nil {
+		return "", fmt.Errorf("cannot create upload: %w", err)
+	}
+
+	if err := s.storage.Upload(f.Key, file); err != nil {
+		return "", fmt.Errorf("cannot load file to object storage: %w", err)
+	}
+
+	return upload.ID, nil
+}
+
+// verifyUpload copies file uploaded by the slot to a key owned by the application and checks that
+// the copy matches the slot. Only size and leading bytes of the copy are read, its checksum is
+// enforced by object storage on upload. The copy is deleted if it doesn't match.
+func (s *ReferralService) verifyUpload(upload repository.Upload) (repository.File, error) {
+	cv := repository.File{
+		Filename:    upload.Filename,
+		ContentType: upload.ContentType,
+		Size:        upload.Size,
+		SHA256:      upload.SHA256,
+		Key:         s.Keys.Key(uuid.NewRandom().String()+path.Ext(upload.Key), time.Now()),
+		UploadID:    upload.ID,
+	}
+
+	err := s.storage.Copy(upload.Key, cv.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return repository.File{}, ErrUploadIncomplete
+	}
+	if err != nil {
+		return repository.File{}, fmt.Errorf("cannot copy uploaded file: %w", err)
+	}
+
+	if err := s.checkUpload(upload, cv.Key); err != nil {
+		s.deleteObject(cv.Key)
+		return repository.File{}, err
+	}
+
+	return cv, nil
+}
+
+// checkUpload checks that stored file has the size and type of the upload slot.
+func (s *ReferralService) checkUpload(upload repository.Upload, key string) error {
+	info, err := s.storage.Stat(key)
+	if err != nil {
+		return fmt.Errorf("cannot get uploaded file info: %w", err)
+	}
+	if info.Size != upload.Size {
+		return fmt.Errorf("%w: size is %d bytes instead of %d", ErrUploadMismatch, info.Size, upload.Size)
+	}
+
+	file, err := s.storage.Open(key)
+	if err != nil {
+		return fmt.Errorf("cannot open file from object storage: %w", err)
+	}
+	defer file.Close()
+
+	cvType, err := DetectCVType(file, upload.Filename)
+	if errors.Is(err, ErrUnsupportedCV) {
+		return fmt.Errorf("%w: %s", ErrUploadMismatch, err)
+	}
+	if err != nil {
+		return err
+	}
+	if cvType.MIME != upload.ContentType {
+		return fmt.Errorf("%w: content is %s document", ErrUploadMismatch, cvType.Extension)
+	}
+
+	return nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	mycontext "github.com/cyberdr0id/referral/internal/context"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var uploadColumns = []string{"id", "user_id", "file_key", "filename", "content_type", "size", "sha256", "expires"}
+
+// pdfSHA256 presents SHA-256 checksum of "%PDF-1.7\n" in hex.
+const pdfSHA256 = "0716f9264c9fe19f5d7455276107f3ddcc1d3497f63d60689a73558ae8a1bf5e"
+
+// memoryFile presents in-memory file of multipart form.
+type memoryFile struct {
+	*strings.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+func TestReferralService_CreateUpload(t *testing.T) {
+	testTable := []struct {
+		testName      string
+		filename      string
+		sha256        string
+		expectedType  string
+		expectedError error
+	}{
+		{
+			testName:     "PDF",
+			filename:     "cv.pdf",
+			sha256:       strings.ToUpper(pdfSHA256),
+			expectedType: "application/pdf",
+		},
+		{
+			testName:      "Unsupported type",
+			filename:      "cv.exe",
+			sha256:        pdfSHA256,
+			expectedError: ErrUnsupportedCV,
+		},
+		{
+			testName:      "Invalid checksum",
+			filename:      "cv.pdf",
+			sha256:        "checksum",
+			expectedError: ErrInvalidParameter,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			files, err := storage.NewLocalStore(t.TempDir(), "http://localhost:8000", "secret")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			if tc.expectedError == nil {
+				mock.ExpectExec("INSERT INTO uploads").
+					WithArgs(sqlmock.AnyArg(), "1", sqlmock.AnyArg(), tc.filename, tc.expectedType, int64(100), pdfSHA256, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			s := NewReferralService(repository.NewRepository(db), files)
+
+			slot, err := s.CreateUpload("1", tc.filename, 100, tc.sha256)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, slot.ID)
+			assert.Equal(t, "PUT", slot.Method)
+			assert.Equal(t, tc.expectedType, slot.Headers["Content-Type"])
+			assert.True(t, strings.HasPrefix(slot.URL, "http://localhost:8000"+storage.LocalPathPrefix))
+		})
+	}
+}
+
+func TestReferralService_AddCandidate_DeletesFileOnFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	files, err := storage.NewLocalStore(dir, "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").
+		WillReturnRows(sqlmock.NewRows([]string{"code", "enabled", "action", "value"}))
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name", "hired"}).AddRow("", "", nil))
+	mock.ExpectExec("INSERT INTO uploads").
+		WithArgs(sqlmock.AnyArg(), "1", sqlmock.AnyArg(), "cv.pdf", "application/pdf", int64(len("%PDF-1.7\n")), pdfSHA256, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin().WillReturnError(errors.New("connection lost"))
+
+	s := NewReferralService(repository.NewRepository(db), files)
+
+	_, err = s.AddCandidate(mycontext.Set(context.Background(), "1"), SubmitCandidateRequest{
+		File:             memoryFile{strings.NewReader("%PDF-1.7\n")},
+		CandidateName:    "Billie",
+		CandidateSurname: "Jean",
+		Position:         "developer",
+		Department:       "engineering",
+		Filetype:         "pdf",
+		ContentType:      "application/pdf",
+		Filename:         "cv.pdf",
+	})
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	stored, _ := os.ReadDir(dir)
+	assert.Empty(t, stored)
+}
+
+func TestReferralService_AddCandidate_DuplicateInTransaction(t *testing.T) {
+	dir := t.TempDir()
+
+	files, err := storage.NewLocalStore(dir, "http://localhost:8000", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	// The candidate is referred concurrently after eligibility check, the check in transaction rejects it.
+	mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").
+		WillReturnRows(sqlmock.NewRows([]string{"code", "enabled", "action", "value"}).
+			AddRow(RuleDuplicateCandidate, true, repository.EligibilityReject, 183))
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name", "hired"}).AddRow("", "", nil))
+	mock.ExpectQuery("FROM requests").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec("INSERT INTO uploads").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT pg_advisory_xact_lock").WithArgs("Billie", "Jean").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM requests").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	s := NewReferralService(repository.NewRepository(db), files)
+
+	_, err = s.AddCandidate(mycontext.Set(context.Background(), "1"), SubmitCandidateRequest{
+		File:             memoryFile{strings.NewReader("%PDF-1.7\n")},
+		CandidateName:    "Billie",
+		CandidateSurname: "Jean",
+		Position:         "developer",
+		Department:       "engineering",
+		Filetype:         "pdf",
+		ContentType:      "application/pdf",
+		Filename:         "cv.pdf",
+	})
+
+	var eligibilityErr *EligibilityError
+	assert.True(t, errors.As(err, &eligibilityErr))
+	assert.Equal(t, RuleDuplicateCandidate, eligibilityErr.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	stored, _ := os.ReadDir(dir)
+	assert.Empty(t, stored)
+}
+
+func TestReferralService_AddUploadedCandidate(t *testing.T) {
+	const content = "%PDF-1.7\n"
+
+	testTable := []struct {
+		testName      string
+		uploadFound   bool
+		stored        string
+		size          int64
+		addErr        error
+		expectedError error
+	}{
+		{
+			testName:      "Unknown upload",
+			expectedError: ErrNoUpload,
+		},
+		{
+			testName:      "File isn't uploaded",
+			uploadFound:   true,
+			size:          int64(len(content)),
+			expectedError: ErrUploadIncomplete,
+		},
+		{
+			testName:      "Size mismatch",
+			uploadFound:   true,
+			stored:        content,
+			size:          int64(len(content)) + 1,
+			expectedError: ErrUploadMismatch,
+		},
+		{
+			testName:      "Content isn't PDF",
+			uploadFound:   true,
+			stored:        "plain text",
+			size:          int64(len("plain text")),
+			expectedError: ErrUploadMismatch,
+		},
+		{
+			testName:    "Request isn't created",
+			uploadFound: true,
+			stored:      content,
+			size:        int64(len(content)),
+			addErr:      errors.New("connection lost"),
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			dir := t.TempDir()
+
+			files, err := storage.NewLocalStore(dir, "http://localhost:8000", "secret")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.stored != "" {
+				if err := files.Upload("uploads/cv.pdf", strings.NewReader(tc.stored)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer db.Close()
+
+			rows := sqlmock.NewRows(uploadColumns)
+			if tc.uploadFound {
+				rows.AddRow("u1", "1", "uploads/cv.pdf", "cv.pdf", "application/pdf", tc.size, pdfSHA256, time.Now().Add(time.Hour))
+			}
+			mock.ExpectQuery("SELECT (.+) FROM uploads").WithArgs("u1", "1").WillReturnRows(rows)
+			if tc.addErr != nil {
+				mock.ExpectQuery("SELECT (.+) FROM eligibility_rules").
+					WillReturnRows(sqlmock.NewRows([]string{"code", "enabled", "action", "value"}))
+				mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("1").
+					WillReturnRows(sqlmock.NewRows([]string{"first_name", "last_name", "hired"}).AddRow("", "", nil))
+				mock.ExpectBegin().WillReturnError(tc.addErr)
+			}
+
+			s := NewReferralService(repository.NewRepository(db), files)
+
+			_, err = s.AddUploadedCandidate(mycontext.Set(context.Background(), "1"), SubmitCandidateRequest{
+				CandidateName:    "Billie",
+				CandidateSurname: "Jean",
+				Position:         "developer",
+				Department:       "engineering",
+				UploadID:         "u1",
+			})
+			assert.NoError(t, mock.ExpectationsWereMet())
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.Error(t, err)
+			}
+
+			// Only the uploaded object is left, the copy of failed request is deleted.
+			stored, _ := os.ReadDir(dir)
+			if tc.stored != "" {
+				assert.Len(t, stored, 1)
+				uploads, _ := os.ReadDir(filepath.Join(dir, "uploads"))
+				assert.Len(t, uploads, 1)
+			} else {
+				assert.Empty(t, stored)
+			}
+		})
+	}
+}
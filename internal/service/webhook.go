@@ -0,0 +1,202 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/webhook"
+)
+
+const (
+	minSecretLength   = 16
+	secretSize        = 32
+	deliveryBatchSize = 100
+
+	// deliveryClaimLease bounds time a batch of deliveries may take before other senders may retry them.
+	deliveryClaimLease = 30 * time.Minute
+)
+
+// ErrNoWebhook presents an error when there is no webhook with the id.
+var ErrNoWebhook = errors.New("there is no webhook with the id")
+
+// ValidateWebhook validates webhook and normalizes its url and events. Secret is required
+// only by webhooks without id, empty secret of existing webhook keeps the current one.
+func ValidateWebhook(w *repository.Webhook) error {
+	w.URL = strings.TrimSpace(w.URL)
+
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) url", ErrInvalidParameter)
+	}
+
+	if len(w.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidParameter)
+	}
+
+	events := make([]string, 0, len(w.Events))
+	seen := map[string]bool{}
+	for _, name := range w.Events {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !webhook.IsEvent(name) {
+			return fmt.Errorf("%w: unknown event %s", ErrInvalidParameter, name)
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			events = append(events, name)
+		}
+	}
+	w.Events = events
+
+	if w.Secret != "" && len(w.Secret) < minSecretLength {
+		return fmt.Errorf("%w: secret must be at least %d symbols", ErrInvalidParameter, minSecretLength)
+	}
+
+	return nil
+}
+
+// GetWebhooks returns all webhooks.
+func (s *ReferralService) GetWebhooks() ([]repository.Webhook, error) {
+	webhooks, err := s.repo.GetWebhooks()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// AddWebhook creates webhook, a random secret is generated if it isn't set.
+// Created webhook is the only place its secret is returned.
+func (s *ReferralService) AddWebhook(w repository.Webhook) (repository.Webhook, error) {
+	if err := ValidateWebhook(&w); err != nil {
+		return repository.Webhook{}, err
+	}
+
+	if w.Secret == "" {
+		secret := make([]byte, secretSize)
+		if _, err := rand.Read(secret); err != nil {
+			return repository.Webhook{}, fmt.Errorf("cannot generate secret: %w", err)
+		}
+
+		w.Secret = hex.EncodeToString(secret)
+	}
+
+	id, err := s.repo.AddWebhook(w)
+	if err != nil {
+		return repository.Webhook{}, fmt.Errorf("cannot add webhook: %w", err)
+	}
+	w.ID = id
+
+	return w, nil
+}
+
+// UpdateWebhook updates webhook.
+func (s *ReferralService) UpdateWebhook(w repository.Webhook) error {
+	if err := ValidateWebhook(&w); err != nil {
+		return err
+	}
+
+	err := s.repo.UpdateWebhook(w)
+	if errors.Is(err, repository.ErrNoWebhook) {
+		return ErrNoWebhook
+	}
+	if err != nil {
+		return fmt.Errorf("cannot update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes webhook and its delivery log.
+func (s *ReferralService) DeleteWebhook(id string) error {
+	err := s.repo.DeleteWebhook(id)
+	if errors.Is(err, repository.ErrNoWebhook) {
+		return ErrNoWebhook
+	}
+	if err != nil {
+		return fmt.Errorf("cannot delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest webhook deliveries which match the filter.
+func (s *ReferralService) GetDeliveries(filter repository.DeliveryFilter) ([]repository.Delivery, error) {
+	deliveries, err := s.repo.GetDeliveries(filter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver queues delivery again, it's sent on the next run of delivery.
+func (s *ReferralService) Redeliver(id string, now time.Time) error {
+	err := s.repo.Redeliver(id, now)
+	if errors.Is(err, repository.ErrNoResult) {
+		return ErrNoResult
+	}
+	if err != nil {
+		return fmt.Errorf("cannot redeliver: %w", err)
+	}
+
+	return nil
+}
+
+// DeliverWebhooks sends due deliveries. Failed deliveries are retried with exponential backoff
+// until they run out of attempts. Number of delivered events is returned. Each delivery is signed
+// with the time of its attempt, now is used only to claim deliveries and schedule retries.
+func (s *ReferralService) DeliverWebhooks(now time.Time) (int, error) {
+	if s.Webhooks == nil {
+		return 0, nil
+	}
+
+	deliveries, err := s.repo.ClaimDueDeliveries(now, now.Add(deliveryClaimLease), deliveryBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("cannot get due deliveries: %w", err)
+	}
+
+	delivered := 0
+	for _, d := range deliveries {
+		code, err := s.Webhooks.Send(webhook.Delivery{
+			ID:      d.ID,
+			URL:     d.URL,
+			Secret:  d.Secret,
+			Event:   d.Event,
+			Payload: d.Payload,
+		}, time.Now())
+
+		attempts := d.Attempts + 1
+		status := repository.DeliveryDelivered
+		lastError := ""
+		var nextAttempt *time.Time
+
+		if err != nil {
+			lastError = err.Error()
+			status = repository.DeliveryFailed
+
+			if attempts < webhook.MaxAttempts {
+				next := now.Add(event.RetryDelay(attempts))
+				nextAttempt = &next
+				status = repository.DeliveryPending
+			}
+		}
+
+		if err := s.repo.SaveDeliveryAttempt(d.ID, status, attempts, nextAttempt, code, lastError); err != nil {
+			return delivered, err
+		}
+
+		if status == repository.DeliveryDelivered {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
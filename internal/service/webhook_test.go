@@ -0,0 +1,128 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/cyberdr0id/referral/internal/repository"
+	"github.com/cyberdr0id/referral/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+type senderFunc func(d webhook.Delivery, now time.Time) (int, error)
+
+func (f senderFunc) Send(d webhook.Delivery, now time.Time) (int, error) {
+	return f(d, now)
+}
+
+var dueDeliveryColumns = []string{"id", "event", "payload", "attempts", "url", "secret"}
+
+func TestReferralService_DeliverWebhooks(t *testing.T) {
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	retryAt := now.Add(event.RetryDelay(3))
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("WITH due AS (.+) FOR UPDATE OF webhook_deliveries SKIP LOCKED (.+) UPDATE webhook_deliveries").
+		WithArgs(repository.DeliveryPending, now, deliveryBatchSize, now.Add(deliveryClaimLease)).
+		WillReturnRows(sqlmock.NewRows(dueDeliveryColumns).
+			AddRow("1", event.ReferralCreated, `{}`, 0, "http://ats.local/hook", "secret").
+			AddRow("2", event.ReferralCreated, `{}`, 2, "http://down.local/hook", "secret").
+			AddRow("3", event.ReferralCreated, `{}`, webhook.MaxAttempts-1, "http://down.local/hook", "secret"))
+	mock.ExpectExec("UPDATE webhook_deliveries").
+		WithArgs(repository.DeliveryDelivered, 1, nil, http.StatusOK, "", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE webhook_deliveries").
+		WithArgs(repository.DeliveryPending, 3, &retryAt, http.StatusServiceUnavailable, "unavailable", "2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE webhook_deliveries").
+		WithArgs(repository.DeliveryFailed, webhook.MaxAttempts, nil, http.StatusServiceUnavailable, "unavailable", "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	s := NewReferralService(repository.NewRepository(db), nil)
+	s.Webhooks = senderFunc(func(d webhook.Delivery, signedAt time.Time) (int, error) {
+		assert.True(t, signedAt.After(now))
+
+		if d.URL == "http://down.local/hook" {
+			return http.StatusServiceUnavailable, errors.New("unavailable")
+		}
+
+		return http.StatusOK, nil
+	})
+
+	delivered, err := s.DeliverWebhooks(now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidateWebhook(t *testing.T) {
+	testTable := []struct {
+		testName        string
+		webhook         repository.Webhook
+		expected        repository.Webhook
+		isErrorExpected bool
+	}{
+		{
+			testName: "Success",
+			webhook: repository.Webhook{
+				URL:    " https://ats.example.com/hooks ",
+				Events: []string{"Referral.Created", "referral.created", "bonus.status_changed"},
+			},
+			expected: repository.Webhook{
+				URL:    "https://ats.example.com/hooks",
+				Events: []string{event.ReferralCreated, event.BonusStatusChanged},
+			},
+		},
+		{
+			testName:        "Failure: relative url",
+			webhook:         repository.Webhook{URL: "/hooks", Events: []string{event.ReferralCreated}},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: unsupported scheme",
+			webhook:         repository.Webhook{URL: "ftp://ats.example.com", Events: []string{event.ReferralCreated}},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: no events",
+			webhook:         repository.Webhook{URL: "https://ats.example.com"},
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: unknown event",
+			webhook:         repository.Webhook{URL: "https://ats.example.com", Events: []string{"referral.deleted"}},
+			isErrorExpected: true,
+		},
+		{
+			testName: "Failure: short secret",
+			webhook: repository.Webhook{
+				URL:    "https://ats.example.com",
+				Events: []string{event.ReferralCreated},
+				Secret: "secret",
+			},
+			isErrorExpected: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			err := ValidateWebhook(&tc.webhook)
+			if tc.isErrorExpected {
+				assert.ErrorIs(t, err, ErrInvalidParameter)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, tc.webhook)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+type keyConfig struct {
+	Prefix string `envconfig:"STORAGE_KEY_PREFIX"`
+}
+
+// KeyLayout presents prefix of keys of stored files, e.g. "cv/{year}/{month}/". Placeholders
+// {year}, {month} and {day} are replaced with date of upload. Empty layout keeps files at the root.
+type KeyLayout string
+
+// NewKeyLayout creates KeyLayout from STORAGE_KEY_PREFIX environment variable.
+func NewKeyLayout() (KeyLayout, error) {
+	var cfg keyConfig
+
+	if err := envconfig.Process("storage", &cfg); err != nil {
+		return "", fmt.Errorf("error with config loading: %w", err)
+	}
+
+	layout := KeyLayout(cfg.Prefix)
+	if err := layout.Validate(); err != nil {
+		return "", err
+	}
+
+	return layout, nil
+}
+
+// Validate checks that keys of the layout stay relative and don't leave the prefix.
+func (l KeyLayout) Validate() error {
+	prefix := l.prefix(time.Now())
+	if prefix == "" {
+		return nil
+	}
+
+	if path.IsAbs(prefix) || path.Clean(prefix) != strings.TrimSuffix(prefix, "/") || strings.HasPrefix(prefix, "..") {
+		return fmt.Errorf("%w: key prefix %q must be a relative path", ErrInvalidKey, string(l))
+	}
+
+	return nil
+}
+
+// Key returns key of file with the name uploaded at the time.
+func (l KeyLayout) Key(name string, now time.Time) string {
+	prefix := l.prefix(now)
+	if prefix == "" {
+		return name
+	}
+
+	return strings.TrimSuffix(prefix, "/") + "/" + name
+}
+
+// Root returns the directories of key prefix which are the same for every upload date, all keys of the layout
+// start with it. Root ends at a directory boundary, so it doesn't match keys of sibling directories.
+func (l KeyLayout) Root() string {
+	layout := string(l)
+	if i := strings.Index(layout, "{"); i >= 0 {
+		return layout[:strings.LastIndex(layout[:i], "/")+1]
+	}
+	if layout == "" {
+		return ""
+	}
+
+	return strings.TrimSuffix(layout, "/") + "/"
+}
+
+func (l KeyLayout) prefix(now time.Time) string {
+	return strings.NewReplacer(
+		"{year}", now.Format("2006"),
+		"{month}", now.Format("01"),
+		"{day}", now.Format("02"),
+	).Replace(string(l))
+}
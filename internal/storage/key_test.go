@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyLayout_Key(t *testing.T) {
+	now := time.Date(2022, 3, 7, 10, 0, 0, 0, time.UTC)
+
+	testTable := []struct {
+		testName    string
+		layout      KeyLayout
+		expectedKey string
+	}{
+		{
+			testName:    "Empty layout",
+			layout:      "",
+			expectedKey: "1.pdf",
+		},
+		{
+			testName:    "Static prefix",
+			layout:      "cv",
+			expectedKey: "cv/1.pdf",
+		},
+		{
+			testName:    "Date placeholders",
+			layout:      "cv/{year}/{month}/{day}/",
+			expectedKey: "cv/2022/03/07/1.pdf",
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expectedKey, tc.layout.Key("1.pdf", now))
+		})
+	}
+}
+
+func TestKeyLayout_Root(t *testing.T) {
+	testTable := map[KeyLayout]string{
+		"":                        "",
+		"cv":                      "cv/",
+		"cv/":                     "cv/",
+		"cv/{year}/{month}/":      "cv/",
+		"{year}/cv":               "",
+		"cv{year}/":               "",
+		"cv/uploads-{year}/{day}": "cv/",
+	}
+
+	now := time.Date(2022, 3, 7, 10, 0, 0, 0, time.UTC)
+
+	for layout, expectedRoot := range testTable {
+		assert.Equal(t, expectedRoot, layout.Root(), layout)
+		assert.True(t, strings.HasPrefix(layout.Key("1.pdf", now), expectedRoot), layout)
+	}
+}
+
+func TestKeyLayout_Validate(t *testing.T) {
+	for _, layout := range []KeyLayout{"", "cv", "cv/{year}/"} {
+		assert.NoError(t, layout.Validate(), layout)
+	}
+
+	for _, layout := range []KeyLayout{"/cv", "../cv", "cv/../..", "cv//{year}"} {
+		assert.ErrorIs(t, layout.Validate(), ErrInvalidKey, layout)
+	}
+}
@@ -0,0 +1,349 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LocalPathPrefix presents path prefix of signed URLs served by LocalStore.
+	LocalPathPrefix = "/files/"
+
+	expiresParameter   = "expires"
+	signatureParameter = "signature"
+	sizeParameter      = "size"
+	typeParameter      = "type"
+	checksumParameter  = "sha256"
+
+	// tempPrefix presents name prefix of temporary files which uploaded files are written to.
+	tempPrefix = ".upload-"
+
+	dirMode = 0755
+)
+
+// ErrInvalidSignature presents an error when signed URL is forged or expired.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// LocalStore presents file store backed by a local directory. Files are downloaded and uploaded
+// by URLs signed with HMAC, which are served by the application itself.
+type LocalStore struct {
+	Dir     string
+	BaseURL string
+	Secret  []byte
+}
+
+// NewLocalStore creates LocalStore which keeps files in the directory. Signed URLs
+// start with baseURL, which is the public address of the application.
+func NewLocalStore(dir, baseURL, secret string) (*LocalStore, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("secret is required by local storage")
+	}
+
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return nil, fmt.Errorf("cannot create storage directory: %w", err)
+	}
+
+	return &LocalStore{
+		Dir:     dir,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Secret:  []byte(secret),
+	}, nil
+}
+
+// path returns path of file with the key, keys which point outside the directory are rejected.
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || key != path.Clean(key) || path.IsAbs(key) || strings.HasPrefix(key, "../") || key == ".." || key == "." {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return filepath.Join(s.Dir, filepath.FromSlash(key)), nil
+}
+
+// Upload writes file to the directory. File is written to a temporary file first,
+// so readers never see partially written files.
+func (s *LocalStore) Upload(key string, file io.ReadSeeker) error {
+	return s.write(key, file, "")
+}
+
+// write writes file with the key, file is saved only if it matches SHA-256 checksum in hex unless it's empty.
+func (s *LocalStore) write(key string, file io.Reader, checksum string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), dirMode); err != nil {
+		return fmt.Errorf("cannot create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), file); err != nil {
+		tmp.Close()
+		return fmt.Errorf("cannot write file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("cannot write file: %w", err)
+	}
+
+	if checksum != "" && checksum != hex.EncodeToString(hash.Sum(nil)) {
+		return fmt.Errorf("%w: file doesn't match it", ErrInvalidChecksum)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("cannot save file: %w", err)
+	}
+
+	return nil
+}
+
+// Open opens the file.
+func (s *LocalStore) Open(key string) (io.ReadSeekCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot open file: %w", err)
+	}
+
+	return file, nil
+}
+
+// SignedURL returns URL of the file which is valid until expiry passes.
+func (s *LocalStore) SignedURL(key string, expiry time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+
+	query := url.Values{}
+	query.Set(expiresParameter, expires)
+	query.Set(signatureParameter, s.sign(key, expires))
+
+	return s.BaseURL + LocalPathPrefix + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(), nil
+}
+
+// SignedUploadURL returns URL which the file is uploaded to by PUT request until expiry passes.
+// Size, content type and SHA-256 checksum in hex of the file are covered by the signature.
+func (s *LocalStore) SignedUploadURL(key, contentType string, size int64, checksum string, expiry time.Duration) (SignedUpload, error) {
+	if _, err := s.path(key); err != nil {
+		return SignedUpload{}, err
+	}
+
+	if sum, err := hex.DecodeString(checksum); err != nil || len(sum) != sha256.Size {
+		return SignedUpload{}, fmt.Errorf("%w: %q", ErrInvalidChecksum, checksum)
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	sizeValue := strconv.FormatInt(size, 10)
+
+	query := url.Values{}
+	query.Set(expiresParameter, expires)
+	query.Set(sizeParameter, sizeValue)
+	query.Set(typeParameter, contentType)
+	query.Set(checksumParameter, checksum)
+	query.Set(signatureParameter, s.sign(key, expires, http.MethodPut, sizeValue, contentType, checksum))
+
+	return SignedUpload{
+		URL:    s.BaseURL + LocalPathPrefix + (&url.URL{Path: key}).EscapedPath() + "?" + query.Encode(),
+		Method: http.MethodPut,
+		Headers: map[string]string{
+			"Content-Type": contentType,
+		},
+	}, nil
+}
+
+// Copy copies the file, the copy is written the same way as uploaded files.
+func (s *LocalStore) Copy(src, dst string) error {
+	file, err := s.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return s.write(dst, file, "")
+}
+
+// Delete deletes the file, deletion of missing files succeeds.
+func (s *LocalStore) Delete(key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cannot delete file: %w", err)
+	}
+
+	return nil
+}
+
+// Stat returns metadata of the file.
+func (s *LocalStore) Stat(key string) (FileInfo, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	info, err := os.Stat(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return FileInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("cannot get file info: %w", err)
+	}
+
+	return FileInfo{Key: key, Size: info.Size(), Modified: info.ModTime()}, nil
+}
+
+// List passes metadata of every file with the key prefix to fn, listing stops on the first error of fn.
+// Temporary files of uploads in progress are skipped.
+func (s *LocalStore) List(prefix string, fn func(FileInfo) error) error {
+	return filepath.WalkDir(s.Dir, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("cannot list files: %w", err)
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Dir, name)
+		if err != nil {
+			return fmt.Errorf("cannot list files: %w", err)
+		}
+
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("cannot get file info: %w", err)
+		}
+
+		return fn(FileInfo{Key: key, Size: info.Size(), Modified: info.ModTime()})
+	})
+}
+
+// Verify checks that signature of the file URL is valid and isn't expired.
+func (s *LocalStore) Verify(key, expires, signature string) error {
+	return s.verify(signature, key, expires)
+}
+
+// verify checks that signature of the values is valid, the second value is the expiry time.
+func (s *LocalStore) verify(signature string, values ...string) error {
+	deadline, err := strconv.ParseInt(values[1], 10, 64)
+	if err != nil || time.Now().Unix() > deadline {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(values...))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// ServeHTTP serves files by signed URLs, files are uploaded by PUT requests.
+func (s *LocalStore) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, LocalPathPrefix)
+	query := r.URL.Query()
+
+	if r.Method == http.MethodPut {
+		s.serveUpload(rw, r, key)
+		return
+	}
+
+	if err := s.Verify(key, query.Get(expiresParameter), query.Get(signatureParameter)); err != nil {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	name, err := s.path(key)
+	if err != nil {
+		http.NotFound(rw, r)
+		return
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(rw, r)
+		return
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.ServeContent(rw, r, path.Base(key), info.ModTime(), file)
+}
+
+// serveUpload writes file uploaded by signed URL, the request must have the signed size and content type
+// and the file must match the signed checksum.
+func (s *LocalStore) serveUpload(rw http.ResponseWriter, r *http.Request, key string) {
+	query := r.URL.Query()
+	size, contentType, checksum := query.Get(sizeParameter), query.Get(typeParameter), query.Get(checksumParameter)
+
+	err := s.verify(query.Get(signatureParameter), key, query.Get(expiresParameter), http.MethodPut, size, contentType, checksum)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if strconv.FormatInt(r.ContentLength, 10) != size || r.Header.Get("Content-Type") != contentType {
+		http.Error(rw, "size or content type doesn't match the signed ones", http.StatusForbidden)
+		return
+	}
+
+	if err := s.write(key, http.MaxBytesReader(rw, r.Body, r.ContentLength), checksum); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// sign returns HMAC of the values separated by new lines.
+func (s *LocalStore) sign(values ...string) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	mac.Write([]byte(strings.Join(values, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// contentSHA256 presents SHA-256 checksum of "content" in hex.
+const contentSHA256 = "ed7002b439e9ac845f22357d822bac1444730fbdb6016d3ec9432297b9ec9f73"
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost:8000/", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return store
+}
+
+func TestLocalStore(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	assert.NoError(t, store.Upload("cv/1.pdf", strings.NewReader("content")))
+
+	info, err := store.Stat("cv/1.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, "cv/1.pdf", info.Key)
+	assert.Equal(t, int64(len("content")), info.Size)
+
+	file, err := store.Open("cv/1.pdf")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.Equal(t, "content", string(content))
+
+	assert.NoError(t, store.Delete("cv/1.pdf"))
+	assert.NoError(t, store.Delete("cv/1.pdf"))
+
+	_, err = store.Open("cv/1.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Stat("cv/1.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLocalStore_Copy(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	assert.NoError(t, store.Upload("uploads/1.pdf", strings.NewReader("content")))
+	assert.NoError(t, store.Copy("uploads/1.pdf", "cvs/1.pdf"))
+
+	file, err := store.Open("cvs/1.pdf")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.Equal(t, "content", string(content))
+
+	assert.ErrorIs(t, store.Copy("uploads/2.pdf", "cvs/2.pdf"), ErrNotFound)
+}
+
+func TestLocalStore_List(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	for _, key := range []string{"cv/2022/1.pdf", "cv/2.pdf", "other/3.pdf"} {
+		assert.NoError(t, store.Upload(key, strings.NewReader("content")))
+	}
+	assert.NoError(t, os.WriteFile(filepath.Join(store.Dir, "cv", tempPrefix+"1"), []byte("partial"), 0600))
+
+	var keys []string
+	err := store.List("cv/", func(info FileInfo) error {
+		assert.Equal(t, int64(len("content")), info.Size)
+		keys = append(keys, info.Key)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cv/2.pdf", "cv/2022/1.pdf"}, keys)
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	for _, key := range []string{"", "../secret", "/etc/passwd", "cv/../../secret", "."} {
+		t.Run(key, func(t *testing.T) {
+			assert.ErrorIs(t, store.Upload(key, strings.NewReader("content")), ErrInvalidKey)
+			_, err := store.Open(key)
+			assert.ErrorIs(t, err, ErrInvalidKey)
+		})
+	}
+}
+
+func TestLocalStore_ServeHTTP(t *testing.T) {
+	store := newTestLocalStore(t)
+	assert.NoError(t, store.Upload("1.pdf", strings.NewReader("content")))
+
+	signed := func(key string, expiry time.Duration) string {
+		u, err := store.SignedURL(key, expiry)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(u, "http://localhost:8000/files/"))
+
+		return u
+	}
+
+	forged, _ := url.Parse(signed("1.pdf", time.Minute))
+	forged.Path = "/files/2.pdf"
+
+	testTable := []struct {
+		testName           string
+		url                string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			testName:           "Success",
+			url:                signed("1.pdf", time.Minute),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       "content",
+		},
+		{
+			testName:           "Failure: expired url",
+			url:                signed("1.pdf", -time.Minute),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			testName:           "Failure: url of another file",
+			url:                forged.String(),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			testName:           "Failure: missing file",
+			url:                signed("3.pdf", time.Minute),
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			store.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestLocalStore_ServeUpload(t *testing.T) {
+	store := newTestLocalStore(t)
+
+	upload, err := store.SignedUploadURL("cvs/1.pdf", "application/pdf", 7, contentSHA256, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, upload.Method)
+
+	expired, err := store.SignedUploadURL("cvs/1.pdf", "application/pdf", 7, contentSHA256, -time.Minute)
+	assert.NoError(t, err)
+
+	_, err = store.SignedUploadURL("cvs/1.pdf", "application/pdf", 7, "content", time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidChecksum)
+
+	testTable := []struct {
+		testName           string
+		url                string
+		contentType        string
+		body               string
+		expectedStatusCode int
+	}{
+		{
+			testName:           "Failure: another size",
+			url:                upload.URL,
+			contentType:        "application/pdf",
+			body:               "content of another size",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			testName:           "Failure: another content type",
+			url:                upload.URL,
+			contentType:        "text/html",
+			body:               "content",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			testName:           "Failure: another content",
+			url:                upload.URL,
+			contentType:        "application/pdf",
+			body:               "CONTENT",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			testName:           "Failure: another checksum",
+			url:                strings.Replace(upload.URL, contentSHA256, strings.Repeat("0", 64), 1),
+			contentType:        "application/pdf",
+			body:               "content",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			testName:           "Failure: expired url",
+			url:                expired.URL,
+			contentType:        "application/pdf",
+			body:               "content",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			testName:           "Failure: download url",
+			url:                strings.Split(upload.URL, "&size=")[0],
+			contentType:        "application/pdf",
+			body:               "content",
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			testName:           "Success",
+			url:                upload.URL,
+			contentType:        upload.Headers["Content-Type"],
+			body:               "content",
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tc.url, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+
+			w := httptest.NewRecorder()
+			store.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+		})
+	}
+
+	info, err := store.Stat("cvs/1.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), info.Size)
+}
@@ -0,0 +1,468 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/kelseyhightower/envconfig"
+)
+
+const (
+	// notFoundCode presents error code of HEAD requests for missing objects, they have no body with S3 code.
+	notFoundCode = "NotFound"
+
+	// SSENone disables server-side encryption of uploaded objects, bucket defaults are applied.
+	SSENone = "none"
+)
+
+type s3Config struct {
+	Bucket      string `envconfig:"AWS_BUCKET"`
+	Region      string `envconfig:"AWS_REGION"`
+	AccessKey   string `envconfig:"AWS_ACCESS_KEY"`
+	AccessKeyID string `envconfig:"AWS_ACCESS_KEY_ID"`
+
+	// Profile is the shared config profile used when static keys aren't set.
+	Profile string `envconfig:"AWS_PROFILE"`
+
+	// Endpoint, PathStyle and TLS options allow to use S3-compatible stores, e.g. MinIO or Ceph.
+	Endpoint           string `envconfig:"AWS_ENDPOINT"`
+	PathStyle          bool   `envconfig:"AWS_PATH_STYLE"`
+	CAFile             string `envconfig:"AWS_CA_FILE"`
+	InsecureSkipVerify bool   `envconfig:"AWS_INSECURE_SKIP_VERIFY"`
+
+	// SSE presents server-side encryption of uploaded objects: AES256 (SSE-S3), aws:kms (SSE-KMS) or none.
+	SSE       string `envconfig:"AWS_SSE" default:"AES256"`
+	SSEKMSKey string `envconfig:"AWS_SSE_KMS_KEY_ID"`
+}
+
+// S3Store presents file store backed by AWS S3 bucket or S3-compatible object storage.
+type S3Store struct {
+	cfg *s3Config
+	s3  *s3.S3
+}
+
+// NewS3Store creates S3Store configured by AWS_* environment variables.
+func NewS3Store() (*S3Store, error) {
+	cfg, err := loadS3Config()
+	if err != nil {
+		return nil, fmt.Errorf("error with config loading: %w", err)
+	}
+
+	return newS3Store(cfg)
+}
+
+// newS3Store creates S3Store. Static credentials are used when keys are set, otherwise
+// credentials are resolved by the default chain: environment, shared profile, IAM role.
+func newS3Store(cfg *s3Config) (*S3Store, error) {
+	switch cfg.SSE {
+	case SSENone, s3.ServerSideEncryptionAes256, s3.ServerSideEncryptionAwsKms:
+	default:
+		return nil, fmt.Errorf("unknown server-side encryption %q", cfg.SSE)
+	}
+
+	if cfg.SSEKMSKey != "" && cfg.SSE != s3.ServerSideEncryptionAwsKms {
+		return nil, fmt.Errorf("KMS key requires %s server-side encryption", s3.ServerSideEncryptionAwsKms)
+	}
+
+	awsCfg := aws.NewConfig().
+		WithRegion(cfg.Region).
+		WithS3ForcePathStyle(cfg.PathStyle)
+
+	if cfg.Endpoint != "" {
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint)
+	}
+
+	if cfg.AccessKeyID != "" {
+		awsCfg = awsCfg.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.AccessKey, ""))
+	}
+
+	// Own HTTP client is used for TLS options, otherwise SDK would set CA bundle on the shared default client.
+	if cfg.CAFile != "" || cfg.InsecureSkipVerify {
+		awsCfg = awsCfg.WithHTTPClient(newS3HTTPClient(cfg.InsecureSkipVerify))
+	}
+
+	opts := session.Options{
+		Config:            *awsCfg,
+		Profile:           cfg.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.Open(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open CA file: %w", err)
+		}
+		defer ca.Close()
+
+		opts.CustomCABundle = ca
+	}
+
+	sn, err := session.NewSessionWithOptions(opts)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create session: %w", err)
+	}
+
+	return &S3Store{
+		s3:  s3.New(sn),
+		cfg: cfg,
+	}, nil
+}
+
+// newS3HTTPClient creates HTTP client for the store, certificates of the store aren't verified if insecure is set.
+func newS3HTTPClient(insecure bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecure}
+
+	return &http.Client{Transport: transport}
+}
+
+func loadS3Config() (*s3Config, error) {
+	var c s3Config
+
+	err := envconfig.Process("aws", &c)
+	if err != nil {
+		return nil, fmt.Errorf("error with config loading: %w", err)
+	}
+
+	return &c, nil
+}
+
+// Upload puts file to the bucket as a private object, it's encrypted with configured server-side encryption.
+func (s *S3Store) Upload(key string, file io.ReadSeeker) error {
+	input := &s3.PutObjectInput{
+		Body:   file,
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	}
+
+	if s.cfg.SSE != SSENone {
+		input.ServerSideEncryption = aws.String(s.cfg.SSE)
+	}
+
+	if s.cfg.SSEKMSKey != "" {
+		input.SSEKMSKeyId = aws.String(s.cfg.SSEKMSKey)
+	}
+
+	_, err := s.s3.PutObject(input)
+	if err != nil {
+		return fmt.Errorf("unable put file to object storage: %w", err)
+	}
+
+	return nil
+}
+
+// Open returns content of the object. Object is read by ranged requests, so seeking
+// doesn't download skipped content.
+func (s *S3Store) Open(key string) (io.ReadSeekCloser, error) {
+	object := &s3Object{store: s, key: key}
+
+	size, err := object.open()
+	if err != nil {
+		return nil, err
+	}
+	object.size = size
+
+	return object, nil
+}
+
+// SignedURL returns presigned URL of the object.
+func (s *S3Store) SignedURL(key string, expiry time.Duration) (string, error) {
+	req, _ := s.s3.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+
+	url, err := req.Presign(expiry)
+	if err != nil {
+		return "", fmt.Errorf("unable create request's signed URL: %w", err)
+	}
+
+	return url, nil
+}
+
+// SignedUploadURL returns presigned PUT request of the object, S3 rejects uploads of another size,
+// content type or SHA-256 checksum given in hex. Uploaded object is encrypted the same way as objects
+// uploaded by Upload.
+func (s *S3Store) SignedUploadURL(key, contentType string, size int64, checksum string, expiry time.Duration) (SignedUpload, error) {
+	sum, err := hex.DecodeString(checksum)
+	if err != nil || len(sum) != sha256.Size {
+		return SignedUpload{}, fmt.Errorf("%w: %q", ErrInvalidChecksum, checksum)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:         aws.String(s.cfg.Bucket),
+		Key:            aws.String(key),
+		ContentType:    aws.String(contentType),
+		ContentLength:  aws.Int64(size),
+		ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(sum)),
+	}
+
+	if s.cfg.SSE != SSENone {
+		input.ServerSideEncryption = aws.String(s.cfg.SSE)
+	}
+
+	if s.cfg.SSEKMSKey != "" {
+		input.SSEKMSKeyId = aws.String(s.cfg.SSEKMSKey)
+	}
+
+	req, _ := s.s3.PutObjectRequest(input)
+	// Checksum must stay a signed header, S3 doesn't verify the content against a query parameter.
+	req.NotHoist = true
+
+	url, header, err := req.PresignRequest(expiry)
+	if err != nil {
+		return SignedUpload{}, fmt.Errorf("unable create request's signed URL: %w", err)
+	}
+
+	// Signed headers are returned with names in lower case.
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		headers[http.CanonicalHeaderKey(name)] = strings.Join(values, ",")
+	}
+
+	return SignedUpload{URL: url, Method: http.MethodPut, Headers: headers}, nil
+}
+
+// Copy copies the object within the bucket, the copy is encrypted the same way as objects uploaded by Upload.
+func (s *S3Store) Copy(src, dst string) error {
+	input := &s3.CopyObjectInput{
+		Bucket:     aws.String(s.cfg.Bucket),
+		Key:        aws.String(dst),
+		CopySource: aws.String(url.PathEscape(s.cfg.Bucket + "/" + src)),
+	}
+
+	if s.cfg.SSE != SSENone {
+		input.ServerSideEncryption = aws.String(s.cfg.SSE)
+	}
+
+	if s.cfg.SSEKMSKey != "" {
+		input.SSEKMSKeyId = aws.String(s.cfg.SSEKMSKey)
+	}
+
+	_, err := s.s3.CopyObject(input)
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("unable to copy object with key %s to %s: %w", src, dst, err)
+	}
+
+	return nil
+}
+
+// Delete deletes the object, deletion of missing objects succeeds.
+func (s *S3Store) Delete(key string) error {
+	_, err := s.s3.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to delete object with key %s from storage: %w", key, err)
+	}
+
+	return nil
+}
+
+// Stat returns metadata of the object.
+func (s *S3Store) Stat(key string) (FileInfo, error) {
+	resp, err := s.s3.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(s.cfg.Bucket),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return FileInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("unable to get metadata of object with key %s: %w", key, err)
+	}
+
+	return FileInfo{
+		Key:      key,
+		Size:     aws.Int64Value(resp.ContentLength),
+		Modified: aws.TimeValue(resp.LastModified),
+	}, nil
+}
+
+// List passes metadata of every object with the key prefix to fn, listing stops on the first error of fn.
+func (s *S3Store) List(prefix string, fn func(FileInfo) error) error {
+	var fnErr error
+
+	err := s.s3.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.cfg.Bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			fnErr = fn(FileInfo{
+				Key:      aws.StringValue(object.Key),
+				Size:     aws.Int64Value(object.Size),
+				Modified: aws.TimeValue(object.LastModified),
+			})
+			if fnErr != nil {
+				return false
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("unable to list objects: %w", err)
+	}
+
+	return fnErr
+}
+
+// MakePrivate sets private ACL on every object with the key prefix, objects uploaded as public-read
+// become accessible by signed URLs only. Keys of objects are passed to fn before update, objects
+// aren't updated if dryRun is set. Number of objects is returned.
+func (s *S3Store) MakePrivate(prefix string, dryRun bool, fn func(key string)) (int, error) {
+	count := 0
+	var aclErr error
+
+	err := s.s3.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(s.cfg.Bucket),
+		Prefix: aws.String(prefix),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			key := aws.StringValue(object.Key)
+			if fn != nil {
+				fn(key)
+			}
+
+			if !dryRun {
+				_, aclErr = s.s3.PutObjectAcl(&s3.PutObjectAclInput{
+					Bucket: aws.String(s.cfg.Bucket),
+					Key:    aws.String(key),
+					ACL:    aws.String(s3.ObjectCannedACLPrivate),
+				})
+				if aclErr != nil {
+					aclErr = fmt.Errorf("unable to set ACL of object with key %s: %w", key, aclErr)
+					return false
+				}
+			}
+
+			count++
+		}
+
+		return true
+	})
+	if err != nil {
+		return count, fmt.Errorf("unable to list objects: %w", err)
+	}
+
+	return count, aclErr
+}
+
+// s3Object presents object which is read from the offset by ranged GET requests.
+type s3Object struct {
+	store  *S3Store
+	key    string
+	size   int64
+	offset int64
+
+	// body is content of the object from bodyOffset, it's reopened when offset changes.
+	body       io.ReadCloser
+	bodyOffset int64
+}
+
+// open requests content of the object from the offset, length of the content is returned.
+func (o *s3Object) open() (int64, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(o.store.cfg.Bucket),
+		Key:    aws.String(o.key),
+	}
+
+	if o.offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", o.offset))
+	}
+
+	resp, err := o.store.s3.GetObject(input)
+	if isNotFound(err) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to get object with key %s from storage: %w", o.key, err)
+	}
+
+	o.body = resp.Body
+	o.bodyOffset = o.offset
+
+	return aws.Int64Value(resp.ContentLength), nil
+}
+
+func (o *s3Object) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.body != nil && o.bodyOffset != o.offset {
+		o.body.Close()
+		o.body = nil
+	}
+
+	if o.body == nil {
+		if _, err := o.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	o.bodyOffset += int64(n)
+
+	return n, err
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += o.offset
+	case io.SeekEnd:
+		offset += o.size
+	default:
+		return 0, fmt.Errorf("invalid whence %d", whence)
+	}
+
+	if offset < 0 {
+		return 0, fmt.Errorf("negative offset %d", offset)
+	}
+
+	o.offset = offset
+
+	return offset, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+
+	err := o.body.Close()
+	o.body = nil
+
+	return err
+}
+
+func isNotFound(err error) bool {
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return false
+	}
+
+	return awsErr.Code() == s3.ErrCodeNoSuchKey || awsErr.Code() == notFoundCode
+}
@@ -0,0 +1,506 @@
+package storage
+
+import (
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testBucket = "cv"
+
+// fakeS3 is an in-memory stand-in of S3-compatible store with path-style addressing.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	headers map[string]http.Header
+	acls    map[string]string
+	paths   []string
+	ranges  []string
+}
+
+func (f *fakeS3) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.paths = append(f.paths, r.URL.Path)
+
+	if r.Header.Get("Authorization") == "" && r.URL.Query().Get("X-Amz-Signature") == "" {
+		rw.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	content, ok := f.objects[key]
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		f.list(rw, key, r.URL.Query().Get("prefix"))
+		return
+	case r.Method == http.MethodPut && r.URL.Query().Has("acl"):
+		f.acls[key] = r.Header.Get("X-Amz-Acl")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+			f.copy(rw, key, source)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+		f.headers[key] = r.Header.Clone()
+		f.acls[key] = r.Header.Get("X-Amz-Acl")
+	case http.MethodGet, http.MethodHead:
+		if !ok {
+			rw.Header().Set("Content-Type", "application/xml")
+			rw.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprint(rw, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+
+		status := http.StatusOK
+		if from := strings.TrimPrefix(r.Header.Get("Range"), "bytes="); from != "" {
+			f.ranges = append(f.ranges, r.Header.Get("Range"))
+			offset, _ := strconv.Atoi(strings.TrimSuffix(from, "-"))
+			content = content[offset:]
+			status = http.StatusPartialContent
+		}
+
+		rw.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		rw.Header().Set("Last-Modified", time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat))
+		rw.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			rw.Write(content)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		rw.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// copy copies object of the source to the key.
+func (f *fakeS3) copy(rw http.ResponseWriter, key, source string) {
+	source, _ = url.PathUnescape(source)
+
+	content, ok := f.objects[source]
+	if !ok {
+		rw.Header().Set("Content-Type", "application/xml")
+		rw.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(rw, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+		return
+	}
+
+	f.objects[key] = content
+	f.headers[key] = http.Header{}
+
+	rw.Header().Set("Content-Type", "application/xml")
+	fmt.Fprint(rw, `<?xml version="1.0" encoding="UTF-8"?><CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+}
+
+// list responds with objects of the bucket whose keys start with the prefix.
+func (f *fakeS3) list(rw http.ResponseWriter, bucket, prefix string) {
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		if strings.HasPrefix(key, bucket+"/"+prefix) {
+			keys = append(keys, strings.TrimPrefix(key, bucket+"/"))
+		}
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintf(rw, `<?xml version="1.0" encoding="UTF-8"?><ListBucketResult><Name>%s</Name><KeyCount>%d</KeyCount><IsTruncated>false</IsTruncated>`, bucket, len(keys))
+	for _, key := range keys {
+		fmt.Fprintf(rw, "<Contents><Key>%s</Key><Size>%d</Size></Contents>", key, len(f.objects[bucket+"/"+key]))
+	}
+	fmt.Fprint(rw, "</ListBucketResult>")
+}
+
+func newFakeS3(t *testing.T, tls bool) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{objects: map[string][]byte{}, headers: map[string]http.Header{}, acls: map[string]string{}}
+
+	var server *httptest.Server
+	if tls {
+		server = httptest.NewTLSServer(fake)
+	} else {
+		server = httptest.NewServer(fake)
+	}
+	t.Cleanup(server.Close)
+
+	return fake, server
+}
+
+func testS3Config(endpoint string) *s3Config {
+	return &s3Config{
+		Bucket:      testBucket,
+		Region:      "us-east-1",
+		AccessKeyID: "minio",
+		AccessKey:   "minio-secret",
+		Endpoint:    endpoint,
+		PathStyle:   true,
+		SSE:         SSENone,
+	}
+}
+
+func TestS3Store_Endpoint(t *testing.T) {
+	fake, server := newFakeS3(t, false)
+
+	store, err := newS3Store(testS3Config(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert.NoError(t, store.Upload("1.pdf", strings.NewReader("content")))
+	assert.Equal(t, []byte("content"), fake.objects[testBucket+"/1.pdf"])
+	assert.Equal(t, []string{"/" + testBucket + "/1.pdf"}, fake.paths)
+
+	info, err := store.Stat("1.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("content")), info.Size)
+
+	file, err := store.Open("1.pdf")
+	assert.NoError(t, err)
+	content, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+	assert.Equal(t, "content", string(content))
+
+	url, err := store.SignedURL("1.pdf", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, server.URL+"/"+testBucket+"/1.pdf?"))
+
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	content, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, "content", string(content))
+
+	assert.NoError(t, store.Delete("1.pdf"))
+
+	_, err = store.Open("1.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Stat("1.pdf")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestS3Store_TLS(t *testing.T) {
+	_, server := newFakeS3(t, true)
+
+	// CA bundle of the environment must not affect certificate verification.
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testTable := []struct {
+		testName      string
+		configure     func(cfg *s3Config)
+		expectedError bool
+	}{
+		{
+			testName:  "Success: CA file",
+			configure: func(cfg *s3Config) { cfg.CAFile = caFile },
+		},
+		{
+			testName:  "Success: verification is skipped",
+			configure: func(cfg *s3Config) { cfg.InsecureSkipVerify = true },
+		},
+		{
+			testName:      "Failure: unknown certificate authority",
+			configure:     func(cfg *s3Config) {},
+			expectedError: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			cfg := testS3Config(server.URL)
+			tc.configure(cfg)
+
+			store, err := newS3Store(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			store.s3.Config.MaxRetries = new(int)
+
+			err = store.Upload("1.pdf", strings.NewReader("content"))
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestS3Store_CredentialChain(t *testing.T) {
+	_, server := newFakeS3(t, false)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "env-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+
+	cfg := testS3Config(server.URL)
+	cfg.AccessKeyID, cfg.AccessKey = "", ""
+
+	store, err := newS3Store(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	creds, err := store.s3.Config.Credentials.Get()
+	assert.NoError(t, err)
+	assert.Equal(t, "env-key", creds.AccessKeyID)
+	assert.NoError(t, store.Upload("1.pdf", strings.NewReader("content")))
+}
+
+func TestNewS3Store_InvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, file := range []string{caFile, filepath.Join(t.TempDir(), "missing.pem")} {
+		cfg := testS3Config("http://localhost")
+		cfg.CAFile = file
+
+		_, err := newS3Store(cfg)
+		assert.Error(t, err)
+	}
+}
+
+func TestS3Store_Upload(t *testing.T) {
+	testTable := []struct {
+		testName       string
+		sse            string
+		kmsKey         string
+		expectedSSE    string
+		expectedKMSKey string
+	}{
+		{
+			testName: "No encryption",
+			sse:      SSENone,
+		},
+		{
+			testName:    "SSE-S3",
+			sse:         "AES256",
+			expectedSSE: "AES256",
+		},
+		{
+			testName:       "SSE-KMS",
+			sse:            "aws:kms",
+			kmsKey:         "key",
+			expectedSSE:    "aws:kms",
+			expectedKMSKey: "key",
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			fake, server := newFakeS3(t, false)
+
+			cfg := testS3Config(server.URL)
+			cfg.SSE, cfg.SSEKMSKey = tc.sse, tc.kmsKey
+
+			store, err := newS3Store(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			assert.NoError(t, store.Upload("1.pdf", strings.NewReader("content")))
+
+			headers := fake.headers[testBucket+"/1.pdf"]
+			assert.Empty(t, fake.acls[testBucket+"/1.pdf"])
+			assert.Equal(t, tc.expectedSSE, headers.Get("X-Amz-Server-Side-Encryption"))
+			assert.Equal(t, tc.expectedKMSKey, headers.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+		})
+	}
+}
+
+func TestS3Store_SignedUploadURL(t *testing.T) {
+	fake, server := newFakeS3(t, false)
+
+	cfg := testS3Config(server.URL)
+	cfg.SSE = "AES256"
+
+	store, err := newS3Store(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = store.SignedUploadURL("cvs/1.pdf", "application/pdf", 7, "content", time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidChecksum)
+
+	upload, err := store.SignedUploadURL("cvs/1.pdf", "application/pdf", 7, contentSHA256, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, upload.Method)
+
+	u, err := url.Parse(upload.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "content-length;content-type;host;x-amz-checksum-sha256;x-amz-server-side-encryption", u.Query().Get("X-Amz-SignedHeaders"))
+	assert.Equal(t, "application/pdf", upload.Headers["Content-Type"])
+	assert.Equal(t, "AES256", upload.Headers["X-Amz-Server-Side-Encryption"])
+	assert.Equal(t, "7XACtDnprIRfIjV9giusFERzD722AW0+yUMil7nsn3M=", upload.Headers["X-Amz-Checksum-Sha256"])
+
+	req, err := http.NewRequest(upload.Method, upload.URL, strings.NewReader("content"))
+	assert.NoError(t, err)
+	for name, value := range upload.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "content", string(fake.objects[testBucket+"/cvs/1.pdf"]))
+	assert.Equal(t, "AES256", fake.headers[testBucket+"/cvs/1.pdf"].Get("X-Amz-Server-Side-Encryption"))
+}
+
+func TestS3Store_Copy(t *testing.T) {
+	fake, server := newFakeS3(t, false)
+
+	store, err := newS3Store(testS3Config(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert.NoError(t, store.Upload("uploads/1.pdf", strings.NewReader("content")))
+	assert.NoError(t, store.Copy("uploads/1.pdf", "cvs/1.pdf"))
+	assert.Equal(t, "content", string(fake.objects[testBucket+"/cvs/1.pdf"]))
+
+	assert.ErrorIs(t, store.Copy("uploads/2.pdf", "cvs/2.pdf"), ErrNotFound)
+}
+
+func TestNewS3Store_InvalidEncryption(t *testing.T) {
+	cfg := testS3Config("http://localhost")
+	cfg.SSE = "unknown"
+
+	_, err := newS3Store(cfg)
+	assert.Error(t, err)
+
+	cfg.SSE, cfg.SSEKMSKey = "AES256", "key"
+
+	_, err = newS3Store(cfg)
+	assert.Error(t, err)
+}
+
+func TestS3Store_MakePrivate(t *testing.T) {
+	fake, server := newFakeS3(t, false)
+
+	store, err := newS3Store(testS3Config(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"cv/1.pdf", "cv/2.pdf", "other/3.pdf"} {
+		fake.objects[testBucket+"/"+key] = []byte("content")
+		fake.acls[testBucket+"/"+key] = "public-read"
+	}
+
+	var keys []string
+	count, err := store.MakePrivate("cv/", true, func(key string) { keys = append(keys, key) })
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"cv/1.pdf", "cv/2.pdf"}, keys)
+	assert.Equal(t, "public-read", fake.acls[testBucket+"/cv/1.pdf"])
+
+	count, err = store.MakePrivate("cv/", false, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, "private", fake.acls[testBucket+"/cv/1.pdf"])
+	assert.Equal(t, "private", fake.acls[testBucket+"/cv/2.pdf"])
+	assert.Equal(t, "public-read", fake.acls[testBucket+"/other/3.pdf"])
+}
+
+func TestS3Store_List(t *testing.T) {
+	fake, server := newFakeS3(t, false)
+
+	store, err := newS3Store(testS3Config(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"cv/1.pdf", "cv/2.pdf", "other/3.pdf"} {
+		fake.objects[testBucket+"/"+key] = []byte("content")
+	}
+
+	var files []FileInfo
+	err = store.List("cv/", func(info FileInfo) error {
+		files = append(files, info)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []FileInfo{{Key: "cv/1.pdf", Size: 7}, {Key: "cv/2.pdf", Size: 7}}, files)
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = store.List("", func(FileInfo) error {
+		calls++
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestS3Store_OpenSeek(t *testing.T) {
+	fake, server := newFakeS3(t, false)
+
+	store, err := newS3Store(testS3Config(server.URL))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fake.objects[testBucket+"/1.pdf"] = []byte("content")
+
+	file, err := store.Open("1.pdf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer file.Close()
+
+	size, err := file.Seek(0, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("content")), size)
+
+	_, err = file.Seek(3, io.SeekStart)
+	assert.NoError(t, err)
+
+	part := make([]byte, 2)
+	_, err = io.ReadFull(file, part)
+	assert.NoError(t, err)
+	assert.Equal(t, "te", string(part))
+
+	rest, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "nt", string(rest))
+	assert.Equal(t, []string{"bytes=3-"}, fake.ranges)
+
+	_, err = file.Seek(0, io.SeekStart)
+	assert.NoError(t, err)
+
+	all, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(all))
+
+	_, err = file.Seek(-1, io.SeekStart)
+	assert.Error(t, err)
+}
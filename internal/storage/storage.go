@@ -1,101 +1,83 @@
-// Package storage implements functions and types for work with AWS S3 object storage.
+// Package storage implements file stores for CVs: AWS S3 object storage and local file system.
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/kelseyhightower/envconfig"
 )
 
 const (
-	urlExpireTime = 10 * time.Minute
+	// DriverS3 presents file store backed by AWS S3.
+	DriverS3 = "s3"
+
+	// DriverLocal presents file store backed by a local directory.
+	DriverLocal = "local"
 )
 
-type storageConfig struct {
-	Bucket      string `envconfig:"AWS_BUCKET"`
-	Region      string `envconfig:"AWS_REGION"`
-	AccessKey   string `envconfig:"AWS_ACCESS_KEY"`
-	AccessKeyID string `envconfig:"AWS_ACCESS_KEY_ID"`
-}
+var (
+	// ErrNotFound presents an error when there is no file with the key.
+	ErrNotFound = errors.New("file not found")
 
-type Storage struct {
-	cfg *storageConfig
-	s3  *s3.S3
-}
+	// ErrInvalidKey presents an error when file key can't be used by the store.
+	ErrInvalidKey = errors.New("invalid file key")
 
-func NewStorage() (*Storage, error) {
-	cfg, err := loadConfig()
-	if err != nil {
-		return nil, fmt.Errorf("error with config loading: %w", err)
-	}
-
-	sn, err := session.NewSession(&aws.Config{
-		Region:      aws.String(cfg.Region),
-		Credentials: credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.AccessKey, ""),
-	})
-	if err != nil {
-		return &Storage{}, fmt.Errorf("unable to create session: %w", err)
-	}
+	// ErrInvalidChecksum presents an error when SHA-256 checksum of a file isn't valid hex or doesn't match the file.
+	ErrInvalidChecksum = errors.New("invalid SHA-256 checksum")
+)
 
-	return &Storage{
-		s3:  s3.New(sn),
-		cfg: cfg,
-	}, nil
+type storeConfig struct {
+	Driver      string `envconfig:"STORAGE_DRIVER" default:"s3"`
+	LocalDir    string `envconfig:"STORAGE_LOCAL_DIR" default:"files"`
+	LocalURL    string `envconfig:"STORAGE_LOCAL_URL"`
+	LocalSecret string `envconfig:"STORAGE_LOCAL_SECRET"`
 }
 
-func loadConfig() (*storageConfig, error) {
-	var c storageConfig
-
-	err := envconfig.Process("aws", &c)
-	if err != nil {
-		return nil, fmt.Errorf("error with config loading: %w", err)
-	}
-
-	return &c, nil
+// FileInfo presents metadata of a stored file.
+type FileInfo struct {
+	Key      string
+	Size     int64
+	Modified time.Time
 }
 
-func (s *Storage) UploadFile(file io.ReadSeeker, fileName string) error {
-	_, err := s.s3.PutObject(&s3.PutObjectInput{
-		Body:   file,
-		Bucket: aws.String(s.cfg.Bucket),
-		Key:    aws.String(fileName),
-		ACL:    aws.String(s3.BucketCannedACLPublicRead),
-	})
-	if err != nil {
-		return fmt.Errorf("unable put file to object storage: %w", err)
-	}
-
-	return nil
+// SignedUpload presents a presigned request which uploads a file directly to the store.
+// The file must be sent with all the headers, they are covered by the signature.
+type SignedUpload struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
 }
 
-func (s *Storage) DownloadFile(fileID string) (io.ReadCloser, error) {
-	resp, err := s.s3.GetObject(&s3.GetObjectInput{
-		Bucket: aws.String(s.cfg.Bucket),
-		Key:    aws.String(fileID),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("unable to get object with key %s from storage: %w", fileID, err)
-	}
-
-	return resp.Body, nil
+// FileStore presents a store of files addressed by keys. Opened files can be read
+// from any offset, so ranges of them are served without reading whole files.
+type FileStore interface {
+	Upload(key string, file io.ReadSeeker) error
+	Open(key string) (io.ReadSeekCloser, error)
+	SignedURL(key string, expiry time.Duration) (string, error)
+	SignedUploadURL(key, contentType string, size int64, checksum string, expiry time.Duration) (SignedUpload, error)
+	Copy(src, dst string) error
+	Delete(key string) error
+	Stat(key string) (FileInfo, error)
+	List(prefix string, fn func(FileInfo) error) error
 }
 
-func (s *Storage) GetFileURL(fileID string) (string, error) {
-	req, _ := s.s3.GetObjectRequest(&s3.GetObjectInput{
-		Bucket: aws.String(s.cfg.Bucket),
-		Key:    aws.String(fileID),
-	})
+// NewFileStore creates file store selected by STORAGE_DRIVER environment variable.
+func NewFileStore() (FileStore, error) {
+	var cfg storeConfig
 
-	url, err := req.Presign(urlExpireTime)
-	if err != nil {
-		return "", fmt.Errorf("unable create request's signed URL: %w", err)
+	if err := envconfig.Process("storage", &cfg); err != nil {
+		return nil, fmt.Errorf("error with config loading: %w", err)
 	}
 
-	return url, nil
+	switch cfg.Driver {
+	case DriverS3:
+		return NewS3Store()
+	case DriverLocal:
+		return NewLocalStore(cfg.LocalDir, cfg.LocalURL, cfg.LocalSecret)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
 }
@@ -0,0 +1,161 @@
+// Package webhook delivers referral events to subscribed HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/event"
+)
+
+// Events presents all events webhooks can be subscribed to.
+var Events = []string{
+	event.ReferralCreated,
+	event.ReferralStatusChanged,
+	event.ReferralAssigned,
+	event.BonusStatusChanged,
+}
+
+const (
+	// HeaderEvent presents header with name of the event.
+	HeaderEvent = "X-Referral-Event"
+
+	// HeaderDelivery presents header with id of the delivery, it's the same for every attempt.
+	HeaderDelivery = "X-Referral-Delivery"
+
+	// HeaderTimestamp presents header with unix time of the attempt, it's a part of signed content.
+	HeaderTimestamp = "X-Referral-Timestamp"
+
+	// HeaderSignature presents header with HMAC-SHA256 signature of the attempt.
+	HeaderSignature = "X-Referral-Signature"
+
+	signaturePrefix = "sha256="
+
+	// MaxAttempts presents number of attempts after which delivery is considered failed.
+	MaxAttempts = 8
+
+	maxResponseSize = 1 << 10
+)
+
+// ErrInvalidSignature presents an error when signature doesn't match content of the request.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Payload presents body of webhook requests.
+type Payload struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurredAt"`
+	Data       interface{} `json:"data"`
+}
+
+// NewPayload encodes event with its data.
+func NewPayload(event string, data interface{}, now time.Time) ([]byte, error) {
+	body, err := json.Marshal(Payload{Event: event, OccurredAt: now.UTC(), Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode %s payload: %w", event, err)
+	}
+
+	return body, nil
+}
+
+// IsEvent checks if there is an event with the name.
+func IsEvent(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sign returns signature of body sent at the time, timestamp is signed to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature of webhook request, requests older than tolerance are rejected.
+// Receivers may use it to authenticate deliveries.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrInvalidSignature)
+	}
+
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp is out of tolerance", ErrInvalidSignature)
+	}
+
+	if !hmac.Equal([]byte(header.Get(HeaderSignature)), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Delivery presents a single attempt to deliver an event.
+type Delivery struct {
+	ID      string
+	URL     string
+	Secret  string
+	Event   string
+	Payload []byte
+}
+
+// Sender sends deliveries to endpoints.
+type Sender interface {
+	Send(d Delivery, now time.Time) (int, error)
+}
+
+// Client sends deliveries over HTTP.
+type Client struct {
+	HTTP *http.Client
+}
+
+// NewClient creates a new instance of Client with the request timeout.
+func NewClient(timeout time.Duration) *Client {
+	return &Client{HTTP: &http.Client{Timeout: timeout}}
+}
+
+// Send posts signed payload to the endpoint and returns status code of the response.
+// Responses other than 2xx are errors.
+func (c *Client) Send(d Delivery, now time.Time) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("cannot create request: %w", err)
+	}
+
+	timestamp := now.Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.Secret, timestamp, d.Payload))
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("cannot send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Send(t *testing.T) {
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	payload := []byte(`{"event":"referral.created"}`)
+
+	testTable := []struct {
+		testName        string
+		secret          string
+		responseCode    int
+		expectedCode    int
+		isErrorExpected bool
+	}{
+		{
+			testName:     "Success",
+			secret:       "0123456789abcdef",
+			responseCode: http.StatusNoContent,
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			testName:        "Failure: endpoint rejects signature",
+			secret:          "fedcba9876543210",
+			responseCode:    http.StatusNoContent,
+			expectedCode:    http.StatusUnauthorized,
+			isErrorExpected: true,
+		},
+		{
+			testName:        "Failure: endpoint error",
+			secret:          "0123456789abcdef",
+			responseCode:    http.StatusServiceUnavailable,
+			expectedCode:    http.StatusServiceUnavailable,
+			isErrorExpected: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			endpoint := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, payload, body)
+				assert.Equal(t, event.ReferralCreated, r.Header.Get(HeaderEvent))
+				assert.Equal(t, "42", r.Header.Get(HeaderDelivery))
+
+				if err := Verify("0123456789abcdef", r.Header, body, 5*time.Minute, now); err != nil {
+					rw.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				rw.WriteHeader(tc.responseCode)
+			}))
+			defer endpoint.Close()
+
+			code, err := NewClient(time.Second).Send(Delivery{
+				ID:      "42",
+				URL:     endpoint.URL,
+				Secret:  tc.secret,
+				Event:   event.ReferralCreated,
+				Payload: payload,
+			}, now)
+			if tc.isErrorExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedCode, code)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2022, 6, 10, 0, 0, 0, 0, time.UTC)
+	body := []byte(`{}`)
+
+	header := func(timestamp time.Time, signature string) http.Header {
+		h := http.Header{}
+		h.Set(HeaderTimestamp, strconv.FormatInt(timestamp.Unix(), 10))
+		h.Set(HeaderSignature, signature)
+		return h
+	}
+
+	assert.NoError(t, Verify("secret", header(now, Sign("secret", now.Unix(), body)), body, time.Minute, now))
+	assert.ErrorIs(t, Verify("secret", header(now, Sign("other", now.Unix(), body)), body, time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, Verify("secret", header(now, Sign("secret", now.Unix(), body)), []byte(`{"a":1}`), time.Minute, now), ErrInvalidSignature)
+
+	old := now.Add(-time.Hour)
+	assert.ErrorIs(t, Verify("secret", header(old, Sign("secret", old.Unix(), body)), body, time.Minute, now), ErrInvalidSignature)
+}
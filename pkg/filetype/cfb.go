@@ -0,0 +1,172 @@
+package filetype
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// Layout of compound file binary format (MS-CFB) used by legacy Office documents and installers.
+const (
+	cfbHeaderSize      = 512
+	cfbHeaderDIFAT     = 109
+	cfbEntrySize       = 128
+	cfbMaxRegularSect  = 0xFFFFFFFA
+	cfbStreamEntry     = 2
+	wordDocumentStream = "WordDocument"
+
+	// maxDirectorySectors bounds the walk over directory of a broken or hostile file.
+	maxDirectorySectors = 1024
+)
+
+// compoundFile reads sectors of a compound file.
+type compoundFile struct {
+	r          io.ReaderAt
+	size       int64
+	sectorSize int64
+	header     []byte
+}
+
+// detectCompound distinguishes Word documents from other compound files, e.g. spreadsheets and installers,
+// by the WordDocument stream in the directory.
+func detectCompound(r io.ReaderAt, size int64) (Type, error) {
+	f, err := newCompoundFile(r, size)
+	if err != nil {
+		return Type{}, err
+	}
+
+	ok, err := f.hasStream(wordDocumentStream)
+	if err != nil {
+		return Type{}, err
+	}
+	if !ok {
+		return Type{}, ErrUnsupported
+	}
+
+	return DOC, nil
+}
+
+func newCompoundFile(r io.ReaderAt, size int64) (*compoundFile, error) {
+	header := make([]byte, cfbHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("%w: broken compound file", ErrUnsupported)
+	}
+
+	shift := binary.LittleEndian.Uint16(header[0x1E:])
+	if shift != 9 && shift != 12 {
+		return nil, fmt.Errorf("%w: broken compound file", ErrUnsupported)
+	}
+
+	return &compoundFile{r: r, size: size, sectorSize: 1 << shift, header: header}, nil
+}
+
+// hasStream checks that directory of the file has a stream with the name.
+func (f *compoundFile) hasStream(name string) (bool, error) {
+	sector := binary.LittleEndian.Uint32(f.header[0x30:])
+	entry := make([]byte, cfbEntrySize)
+
+	for i := 0; sector < cfbMaxRegularSect; i++ {
+		if i == maxDirectorySectors {
+			return false, fmt.Errorf("%w: too large compound file directory", ErrUnsupported)
+		}
+
+		for off := int64(0); off < f.sectorSize; off += cfbEntrySize {
+			if err := f.readSector(sector, off, entry); err != nil {
+				return false, err
+			}
+
+			if entry[0x42] == cfbStreamEntry && entryName(entry) == name {
+				return true, nil
+			}
+		}
+
+		next, err := f.next(sector)
+		if err != nil {
+			return false, err
+		}
+		sector = next
+	}
+
+	return false, nil
+}
+
+// next returns the sector which follows the sector in its chain.
+func (f *compoundFile) next(sector uint32) (uint32, error) {
+	perSector := uint32(f.sectorSize / 4)
+
+	fatSector, err := f.fatSector(sector / perSector)
+	if err != nil {
+		return 0, err
+	}
+
+	return f.readUint32(fatSector, int64(sector%perSector)*4)
+}
+
+// fatSector returns location of the n-th sector of the allocation table.
+func (f *compoundFile) fatSector(n uint32) (uint32, error) {
+	if n < cfbHeaderDIFAT {
+		return binary.LittleEndian.Uint32(f.header[0x4C+4*n:]), nil
+	}
+	n -= cfbHeaderDIFAT
+
+	perSector := uint32(f.sectorSize/4) - 1
+	sector := binary.LittleEndian.Uint32(f.header[0x44:])
+	count := binary.LittleEndian.Uint32(f.header[0x48:])
+
+	for i := uint32(0); i < n/perSector; i++ {
+		if i == count {
+			return 0, fmt.Errorf("%w: broken compound file", ErrUnsupported)
+		}
+
+		next, err := f.readUint32(sector, int64(perSector)*4)
+		if err != nil {
+			return 0, err
+		}
+		sector = next
+	}
+
+	return f.readUint32(sector, int64(n%perSector)*4)
+}
+
+func (f *compoundFile) readUint32(sector uint32, off int64) (uint32, error) {
+	buf := make([]byte, 4)
+	if err := f.readSector(sector, off, buf); err != nil {
+		return 0, err
+	}
+
+	return binary.LittleEndian.Uint32(buf), nil
+}
+
+// readSector reads len(p) bytes at the offset within the sector.
+func (f *compoundFile) readSector(sector uint32, off int64, p []byte) error {
+	if sector >= cfbMaxRegularSect {
+		return fmt.Errorf("%w: broken compound file", ErrUnsupported)
+	}
+
+	pos := (int64(sector)+1)*f.sectorSize + off
+	if pos+int64(len(p)) > f.size {
+		return fmt.Errorf("%w: broken compound file", ErrUnsupported)
+	}
+
+	if _, err := f.r.ReadAt(p, pos); err != nil {
+		return fmt.Errorf("cannot read compound file: %w", err)
+	}
+
+	return nil
+}
+
+// entryName decodes UTF-16 name of a directory entry.
+func entryName(entry []byte) string {
+	length := int(binary.LittleEndian.Uint16(entry[0x40:]))
+	if length < 2 || length > 64 {
+		return ""
+	}
+
+	name := make([]uint16, length/2-1)
+	for i := range name {
+		name[i] = binary.LittleEndian.Uint16(entry[2*i:])
+	}
+
+	return string(utf16.Decode(name))
+}
@@ -0,0 +1,180 @@
+// Package filetype implements detection of document types by their content.
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// sniffLength presents number of leading bytes which types are detected by.
+	sniffLength = 512
+
+	odtMimetype = "application/vnd.oasis.opendocument.text"
+)
+
+// ErrUnsupported presents an error when content isn't a document of allowed types.
+var ErrUnsupported = errors.New("unsupported file type")
+
+// Type presents a document type.
+type Type struct {
+	Extension string
+	MIME      string
+}
+
+// Allowed document types.
+var (
+	PDF  = Type{Extension: "pdf", MIME: "application/pdf"}
+	DOC  = Type{Extension: "doc", MIME: "application/msword"}
+	DOCX = Type{Extension: "docx", MIME: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"}
+	RTF  = Type{Extension: "rtf", MIME: "application/rtf"}
+	ODT  = Type{Extension: "odt", MIME: odtMimetype}
+	TXT  = Type{Extension: "txt", MIME: "text/plain"}
+)
+
+var (
+	pdfSignature = []byte("%PDF-")
+	oleSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+	rtfSignature = []byte(`{\rtf`)
+	zipSignature = []byte("PK\x03\x04")
+)
+
+// ByExtension returns allowed type with the file extension, extension is case-insensitive.
+func ByExtension(ext string) (Type, bool) {
+	for _, t := range []Type{PDF, DOC, DOCX, RTF, ODT, TXT} {
+		if strings.EqualFold(strings.TrimPrefix(ext, "."), t.Extension) {
+			return t, true
+		}
+	}
+
+	return Type{}, false
+}
+
+// Detect detects type of document by its content, ErrUnsupported is returned for other content.
+// Reader is rewound to the start afterwards.
+func Detect(r io.ReadSeeker) (Type, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Type{}, fmt.Errorf("cannot get file size: %w", err)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Type{}, fmt.Errorf("cannot rewind file: %w", err)
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return Type{}, fmt.Errorf("cannot read file: %w", err)
+	}
+	head = head[:n]
+
+	t, err := detect(head, &readerAt{r: r}, size)
+	if err != nil {
+		return Type{}, err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Type{}, fmt.Errorf("cannot rewind file: %w", err)
+	}
+
+	return t, nil
+}
+
+func detect(head []byte, r io.ReaderAt, size int64) (Type, error) {
+	switch {
+	case bytes.HasPrefix(head, pdfSignature):
+		return PDF, nil
+	case bytes.HasPrefix(head, oleSignature):
+		return detectCompound(r, size)
+	case bytes.HasPrefix(head, rtfSignature):
+		return RTF, nil
+	case bytes.HasPrefix(head, zipSignature):
+		return detectZip(r, size)
+	case isText(head):
+		return TXT, nil
+	default:
+		return Type{}, ErrUnsupported
+	}
+}
+
+// detectZip distinguishes DOCX and ODT documents from other ZIP archives.
+func detectZip(r io.ReaderAt, size int64) (Type, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return Type{}, fmt.Errorf("%w: broken zip archive", ErrUnsupported)
+	}
+
+	for _, f := range archive.File {
+		switch f.Name {
+		case "word/document.xml":
+			return DOCX, nil
+		case "mimetype":
+			if f.UncompressedSize64 != uint64(len(odtMimetype)) {
+				continue
+			}
+
+			content, err := f.Open()
+			if err != nil {
+				continue
+			}
+
+			mimetype, err := io.ReadAll(content)
+			content.Close()
+			if err == nil && string(mimetype) == odtMimetype {
+				return ODT, nil
+			}
+		}
+	}
+
+	return Type{}, ErrUnsupported
+}
+
+// isText checks that content is UTF-8 text without control characters except whitespaces.
+func isText(head []byte) bool {
+	if len(head) == 0 {
+		return false
+	}
+
+	// Leading bytes may end in the middle of a multibyte character.
+	if len(head) == sniffLength {
+		for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+
+	if !utf8.Valid(head) {
+		return false
+	}
+
+	for _, r := range string(head) {
+		if r < ' ' && r != '\n' && r != '\r' && r != '\t' && r != '\f' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// readerAt reads from io.ReadSeeker at offsets.
+type readerAt struct {
+	r io.ReadSeeker
+}
+
+func (r *readerAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := r.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(r.r, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+
+	return n, err
+}
@@ -0,0 +1,135 @@
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func zipArchive(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := io.WriteString(f, content); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf.String()
+}
+
+// oleFile builds a compound file with 512-byte sectors whose directory has the streams.
+func oleFile(streams ...string) string {
+	const sectorSize = 512
+
+	entries := append([]string{"Root Entry"}, streams...)
+	dirSectors := (len(entries)*cfbEntrySize + sectorSize - 1) / sectorSize
+	buf := make([]byte, (2+dirSectors)*sectorSize)
+
+	copy(buf, oleSignature)
+	binary.LittleEndian.PutUint16(buf[0x1A:], 3)
+	binary.LittleEndian.PutUint16(buf[0x1C:], 0xFFFE)
+	binary.LittleEndian.PutUint16(buf[0x1E:], 9)
+	binary.LittleEndian.PutUint32(buf[0x2C:], 1)
+	binary.LittleEndian.PutUint32(buf[0x30:], 1)
+	binary.LittleEndian.PutUint32(buf[0x44:], 0xFFFFFFFE)
+	for i := 0; i < cfbHeaderDIFAT; i++ {
+		binary.LittleEndian.PutUint32(buf[0x4C+4*i:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(buf[0x4C:], 0)
+
+	fat := buf[sectorSize : 2*sectorSize]
+	for i := 0; i < sectorSize/4; i++ {
+		binary.LittleEndian.PutUint32(fat[4*i:], 0xFFFFFFFF)
+	}
+	binary.LittleEndian.PutUint32(fat, 0xFFFFFFFD)
+	for i := 1; i <= dirSectors; i++ {
+		next := uint32(i + 1)
+		if i == dirSectors {
+			next = 0xFFFFFFFE
+		}
+		binary.LittleEndian.PutUint32(fat[4*i:], next)
+	}
+
+	for i, name := range entries {
+		entry := buf[2*sectorSize+i*cfbEntrySize:]
+		encoded := utf16.Encode([]rune(name))
+		for j, c := range encoded {
+			binary.LittleEndian.PutUint16(entry[2*j:], c)
+		}
+		binary.LittleEndian.PutUint16(entry[0x40:], uint16(2*len(encoded)+2))
+
+		entry[0x42] = cfbStreamEntry
+		if i == 0 {
+			entry[0x42] = 5
+		}
+	}
+
+	return string(buf)
+}
+
+func TestDetect(t *testing.T) {
+	testTable := []struct {
+		testName      string
+		content       string
+		expectedType  Type
+		expectedError error
+	}{
+		{testName: "PDF", content: "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n", expectedType: PDF},
+		{testName: "DOC", content: oleFile("\x01CompObj", "1Table", "WordDocument"), expectedType: DOC},
+		{testName: "DOC with directory of several sectors", content: oleFile("\x05SummaryInformation", "\x05DocumentSummaryInformation", "\x01CompObj", "1Table", "Data", "WordDocument"), expectedType: DOC},
+		{testName: "RTF", content: `{\rtf1\ansi Hello}`, expectedType: RTF},
+		{testName: "DOCX", content: zipArchive(t, map[string]string{"word/document.xml": "<w:document/>"}), expectedType: DOCX},
+		{testName: "ODT", content: zipArchive(t, map[string]string{"mimetype": odtMimetype}), expectedType: ODT},
+		{testName: "TXT", content: "Billie Jean\nDeveloper\tМинск\n", expectedType: TXT},
+		{testName: "TXT cut in the middle of a character", content: strings.Repeat("a", sniffLength-1) + "Ж", expectedType: TXT},
+		{testName: "Other zip archive", content: zipArchive(t, map[string]string{"app.exe": "MZ"}), expectedError: ErrUnsupported},
+		{testName: "XLS", content: oleFile("\x01CompObj", "Workbook"), expectedError: ErrUnsupported},
+		{testName: "MSI", content: oleFile("\x05SummaryInformation", "\u4840\u3f7f\u4164\u422f\u4836"), expectedError: ErrUnsupported},
+		{testName: "Bare compound file header", content: "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1\x00\x00", expectedError: ErrUnsupported},
+		{testName: "Executable", content: "MZ\x90\x00\x03\x00\x00\x00", expectedError: ErrUnsupported},
+		{testName: "Empty file", content: "", expectedError: ErrUnsupported},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			r := strings.NewReader(tc.content)
+
+			detected, err := Detect(r)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedType, detected)
+
+			content, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.content, string(content))
+		})
+	}
+}
+
+func TestByExtension(t *testing.T) {
+	detected, ok := ByExtension(".PDF")
+	assert.True(t, ok)
+	assert.Equal(t, PDF, detected)
+
+	_, ok = ByExtension("exe")
+	assert.False(t, ok)
+}
@@ -0,0 +1,66 @@
+package textract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const docxDocument = "word/document.xml"
+
+// extractDOCX extracts text of the main document part of DOCX file, paragraphs are separated by new lines.
+func extractDOCX(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("cannot open DOCX archive: %w", err)
+	}
+
+	part, err := archive.Open(docxDocument)
+	if err != nil {
+		return "", fmt.Errorf("cannot open DOCX document: %w", err)
+	}
+	defer part.Close()
+
+	var b strings.Builder
+	inText := false
+
+	decoder := xml.NewDecoder(io.LimitReader(part, MaxInputSize))
+	for b.Len() < MaxTextSize {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("cannot parse DOCX document: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				b.WriteByte(' ')
+			case "br", "cr":
+				b.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				b.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+
+	return b.String(), nil
+}
@@ -0,0 +1,308 @@
+package textract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfWordSpacing presents the displacement in TJ arrays, in thousandths of text space unit,
+// beyond which strings are treated as separate words.
+const pdfWordSpacing = -200
+
+var (
+	pdfStream    = []byte("stream")
+	pdfEndStream = []byte("endstream")
+	pdfObject    = []byte(" obj")
+)
+
+// extractPDF extracts text shown by text operators of uncompressed and FlateDecode content streams.
+// The extraction is best-effort: text of fonts with custom encodings isn't decoded and is dropped by normalization.
+// All streams of the document are inflated to MaxInputSize in total.
+func extractPDF(data []byte) string {
+	var b strings.Builder
+
+	inflateBudget := int64(MaxInputSize)
+	dictStart, searched := 0, 0
+
+	for offset := 0; offset < len(data) && b.Len() < MaxTextSize; {
+		i := bytes.Index(data[offset:], pdfStream)
+		if i < 0 {
+			break
+		}
+		start := offset + i
+		offset = start + len(pdfStream)
+
+		if start > 0 && data[start-1] == 'd' {
+			continue
+		}
+
+		// Dictionary starts at the last object header before the stream, the document is searched only once.
+		if j := bytes.LastIndex(data[searched:start], pdfObject); j >= 0 {
+			dictStart = searched + j
+		}
+		searched = start
+		dict := data[dictStart:start]
+
+		if offset < len(data) && data[offset] == '\r' {
+			offset++
+		}
+		if offset < len(data) && data[offset] == '\n' {
+			offset++
+		}
+
+		end := bytes.Index(data[offset:], pdfEndStream)
+		if end < 0 {
+			end = len(data) - offset
+		}
+		content := data[offset : offset+end]
+		offset += end
+
+		content, ok := decodeStream(dict, content, &inflateBudget)
+		if ok {
+			parseContent(content, &b)
+		}
+	}
+
+	return b.String()
+}
+
+// decodeStream decodes a stream which may contain page content, images and embedded fonts are skipped.
+// Compressed streams are inflated within the budget, which is reduced by the inflated size.
+func decodeStream(dict, content []byte, budget *int64) ([]byte, bool) {
+	if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/Length1")) ||
+		bytes.Contains(dict, []byte("/DCTDecode")) {
+		return nil, false
+	}
+
+	if !bytes.Contains(dict, []byte("/Filter")) {
+		return content, true
+	}
+	if !bytes.Contains(dict, []byte("/FlateDecode")) || *budget <= 0 {
+		return nil, false
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, false
+	}
+	defer r.Close()
+
+	// Streams are often truncated or padded, so the data inflated before an error is kept.
+	decoded, _ := io.ReadAll(io.LimitReader(r, *budget))
+	*budget -= int64(len(decoded))
+
+	return decoded, len(decoded) != 0
+}
+
+// parseContent writes strings shown by text operators inside BT/ET blocks of the content stream.
+func parseContent(data []byte, b *strings.Builder) {
+	var (
+		inText  bool
+		inArray bool
+		strs    []string
+		numbers []float64
+	)
+
+	for i := 0; i < len(data); {
+		c := data[i]
+
+		switch {
+		case isPDFSpace(c):
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case c == '(':
+			s, n := pdfLiteral(data[i:])
+			strs = append(strs, s)
+			i += n
+		case c == '<' && i+1 < len(data) && data[i+1] == '<':
+			i += 2
+		case c == '<':
+			s, n := pdfHex(data[i:])
+			strs = append(strs, s)
+			i += n
+		case c == '[':
+			inArray = true
+			i++
+		case c == ']':
+			inArray = false
+			i++
+		case c == '>' || c == '{' || c == '}' || c == ')':
+			i++
+		default:
+			n := pdfToken(data[i:])
+			token := string(data[i : i+n])
+			i += n
+
+			if c == '/' {
+				continue
+			}
+
+			if f, err := strconv.ParseFloat(token, 64); err == nil {
+				if inArray && f < pdfWordSpacing {
+					strs = append(strs, " ")
+				}
+				numbers = append(numbers, f)
+				continue
+			}
+
+			switch token {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				b.WriteByte('\n')
+			case "Tj", "TJ":
+				if inText {
+					b.WriteString(strings.Join(strs, ""))
+				}
+			case "'", "\"":
+				if inText {
+					b.WriteByte('\n')
+					b.WriteString(strings.Join(strs, ""))
+				}
+			case "T*", "Tm":
+				b.WriteByte('\n')
+			case "Td", "TD":
+				if len(numbers) >= 2 && numbers[len(numbers)-1] != 0 {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(' ')
+				}
+			}
+
+			strs, numbers = strs[:0], numbers[:0]
+		}
+	}
+}
+
+// pdfLiteral decodes a literal string which data starts with and returns the number of bytes it takes.
+func pdfLiteral(data []byte) (string, int) {
+	var s []byte
+	depth := 0
+
+	i := 0
+	for ; i < len(data); i++ {
+		c := data[i]
+
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString(s), i + 1
+			}
+		case '\\':
+			i++
+			if i == len(data) {
+				return pdfString(s), i
+			}
+
+			switch e := data[i]; e {
+			case 'n':
+				s = append(s, '\n')
+			case 'r':
+				s = append(s, '\r')
+			case 't':
+				s = append(s, '\t')
+			case 'b', 'f':
+			case '\r':
+				if i+1 < len(data) && data[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e < '0' || e > '7' {
+					s = append(s, e)
+					break
+				}
+
+				n := 0
+				for j := 0; j < 3 && i < len(data) && data[i] >= '0' && data[i] <= '7'; j++ {
+					n = n*8 + int(data[i]-'0')
+					i++
+				}
+				i--
+				s = append(s, byte(n))
+			}
+			continue
+		}
+
+		s = append(s, c)
+	}
+
+	return pdfString(s), i
+}
+
+// pdfHex decodes a hexadecimal string which data starts with and returns the number of bytes it takes.
+func pdfHex(data []byte) (string, int) {
+	end := bytes.IndexByte(data, '>')
+	if end < 0 {
+		end = len(data) - 1
+	}
+
+	digits := make([]byte, 0, end)
+	for _, c := range data[1:end] {
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 != 0 {
+		digits = append(digits, '0')
+	}
+
+	s, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return "", end + 1
+	}
+
+	return pdfString(s), end + 1
+}
+
+// pdfString converts a string in UTF-16BE with byte order mark or PDFDocEncoding, which is treated as Latin-1.
+func pdfString(s []byte) string {
+	if len(s) >= 2 && s[0] == 0xFE && s[1] == 0xFF {
+		units := make([]uint16, 0, len(s)/2)
+		for i := 2; i+1 < len(s); i += 2 {
+			units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+
+		return string(utf16.Decode(units))
+	}
+
+	runes := make([]rune, len(s))
+	for i, c := range s {
+		runes[i] = rune(c)
+	}
+
+	return string(runes)
+}
+
+// pdfToken returns the length of a name, number or operator which data starts with.
+func pdfToken(data []byte) int {
+	i := 1
+	for i < len(data) && !isPDFSpace(data[i]) && !isPDFDelimiter(data[i]) {
+		i++
+	}
+
+	return i
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
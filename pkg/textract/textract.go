@@ -0,0 +1,141 @@
+// Package textract implements extraction of plain text from documents and search of keywords in it.
+package textract
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cyberdr0id/referral/pkg/filetype"
+)
+
+const (
+	// MaxInputSize presents maximum size of document which text is extracted from.
+	MaxInputSize = 32 << 20
+
+	// MaxTextSize presents maximum size of extracted text, the rest is dropped.
+	MaxTextSize = 1 << 20
+)
+
+var (
+	// ErrUnsupported presents an error when text can't be extracted from documents of the type.
+	ErrUnsupported = errors.New("text extraction isn't supported")
+
+	// ErrInvalid presents an error when document is malformed.
+	ErrInvalid = errors.New("invalid document")
+)
+
+// Extract extracts plain text from PDF, DOCX or TXT document. Whitespaces are collapsed and text
+// is cut to MaxTextSize.
+func Extract(t filetype.Type, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxInputSize))
+	if err != nil {
+		return "", fmt.Errorf("cannot read document: %w", err)
+	}
+
+	var text string
+	switch t {
+	case filetype.PDF:
+		text = extractPDF(data)
+	case filetype.DOCX:
+		text, err = extractDOCX(data)
+	case filetype.TXT:
+		text = string(data)
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupported, t.Extension)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	return normalize(text), nil
+}
+
+// normalize drops invalid and control characters, collapses whitespaces within lines and
+// empty lines, and cuts text to MaxTextSize.
+func normalize(text string) string {
+	var b strings.Builder
+	space, newline := false, false
+
+	for _, r := range text {
+		switch {
+		case r == utf8.RuneError:
+			continue
+		case r == '\n' || r == '\r':
+			newline = true
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case !unicode.IsPrint(r):
+			continue
+		}
+
+		if b.Len() != 0 {
+			if newline {
+				b.WriteByte('\n')
+			} else if space {
+				b.WriteByte(' ')
+			}
+		}
+		space, newline = false, false
+
+		if b.Len()+utf8.RuneLen(r) > MaxTextSize {
+			break
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Keyword presents a keyword with its alternative spellings, all of them are matched case-insensitively.
+type Keyword struct {
+	Name    string
+	Aliases []string
+}
+
+// Keywords returns names of keywords which are mentioned in the text as whole words, in vocabulary order.
+func Keywords(text string, vocabulary []Keyword) []string {
+	text = strings.ToLower(text)
+
+	found := make([]string, 0)
+	for _, k := range vocabulary {
+		for _, spelling := range append([]string{k.Name}, k.Aliases...) {
+			if containsWord(text, strings.ToLower(spelling)) {
+				found = append(found, k.Name)
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+// containsWord checks if text contains the word which isn't a part of another word, e.g. "java" isn't found in "javascript".
+func containsWord(text, word string) bool {
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+
+		start := offset + i
+		end := start + len(word)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+
+		offset = start + 1
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '+' || r == '#')
+}
@@ -0,0 +1,181 @@
+package textract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cyberdr0id/referral/pkg/filetype"
+	"github.com/stretchr/testify/assert"
+)
+
+func pdfDocument(t *testing.T, content string, compress bool) string {
+	stream, filter := content, ""
+	if compress {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := io.WriteString(w, content); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		stream, filter = buf.String(), " /Filter /FlateDecode"
+	}
+
+	return fmt.Sprintf("%%PDF-1.4\n1 0 obj\n<< /Type /Font /Subtype /Type1 >>\nendobj\n"+
+		"4 0 obj\n<< /Length %d%s >>\nstream\n%s\nendstream\nendobj\n%%%%EOF\n", len(stream), filter, stream)
+}
+
+func docxFile(t *testing.T, document string) string {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(docxDocument)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := io.WriteString(f, document); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestExtract(t *testing.T) {
+	const pdfContent = `BT /F1 12 Tf 72 712 Td (Billie Jean) Tj 0 -14 Td [(Go) -50 (lang) -300 (\(PostgreSQL\))] TJ ` +
+		`T* <FEFF041C0438043D0441043A> Tj (Caf\351 \\ \101WS) ' ET (outside) Tj`
+
+	testTable := []struct {
+		testName      string
+		fileType      filetype.Type
+		content       string
+		expectedText  string
+		expectedError error
+	}{
+		{
+			testName:     "PDF",
+			fileType:     filetype.PDF,
+			content:      pdfDocument(t, pdfContent, false),
+			expectedText: "Billie Jean\nGolang (PostgreSQL)\nМинск\nCafé \\ AWS",
+		},
+		{
+			testName:     "Compressed PDF",
+			fileType:     filetype.PDF,
+			content:      pdfDocument(t, pdfContent, true),
+			expectedText: "Billie Jean\nGolang (PostgreSQL)\nМинск\nCafé \\ AWS",
+		},
+		{
+			testName:     "PDF without text",
+			fileType:     filetype.PDF,
+			content:      "%PDF-1.4\n%%EOF",
+			expectedText: "",
+		},
+		{
+			testName: "DOCX",
+			fileType: filetype.DOCX,
+			content: docxFile(t, `<?xml version="1.0"?><w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">`+
+				`<w:body><w:p><w:r><w:t>Billie</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">Jean </w:t></w:r></w:p>`+
+				`<w:p><w:r><w:t>Docker &amp; Kubernetes</w:t><w:br/><w:t>Minsk</w:t></w:r></w:p></w:body></w:document>`),
+			expectedText: "Billie Jean\nDocker & Kubernetes\nMinsk",
+		},
+		{
+			testName:      "DOCX without document",
+			fileType:      filetype.DOCX,
+			content:       "PK\x03\x04",
+			expectedError: ErrInvalid,
+		},
+		{
+			testName:     "TXT",
+			fileType:     filetype.TXT,
+			content:      "  Billie   Jean\r\n\r\n\tDeveloper\x07\n",
+			expectedText: "Billie Jean\nDeveloper",
+		},
+		{
+			testName:      "RTF",
+			fileType:      filetype.RTF,
+			content:       `{\rtf1 Hello}`,
+			expectedError: ErrUnsupported,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			text, err := Extract(tc.fileType, strings.NewReader(tc.content))
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedText, text)
+		})
+	}
+}
+
+func TestExtract_Limit(t *testing.T) {
+	text, err := Extract(filetype.TXT, strings.NewReader(strings.Repeat("ab ", MaxTextSize)))
+
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(text), MaxTextSize)
+}
+
+func TestDecodeStream_Budget(t *testing.T) {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	if _, err := io.WriteString(w, strings.Repeat("a", 100)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dict := []byte("<< /Filter /FlateDecode >>")
+	budget := int64(150)
+
+	decoded, ok := decodeStream(dict, buf.Bytes(), &budget)
+	assert.True(t, ok)
+	assert.Len(t, decoded, 100)
+
+	decoded, ok = decodeStream(dict, buf.Bytes(), &budget)
+	assert.True(t, ok)
+	assert.Len(t, decoded, 50)
+
+	_, ok = decodeStream(dict, buf.Bytes(), &budget)
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), budget)
+}
+
+func TestKeywords(t *testing.T) {
+	vocabulary := []Keyword{
+		{Name: "go", Aliases: []string{"golang"}},
+		{Name: "java"},
+		{Name: "javascript", Aliases: []string{"js"}},
+		{Name: "c++"},
+		{Name: "c"},
+		{Name: "postgresql", Aliases: []string{"postgres"}},
+	}
+
+	testTable := []struct {
+		testName         string
+		text             string
+		expectedKeywords []string
+	}{
+		{testName: "Aliases and case", text: "Golang, JavaScript and Postgres", expectedKeywords: []string{"go", "javascript", "postgresql"}},
+		{testName: "Part of another word", text: "JavaScript developer, going to learn C++", expectedKeywords: []string{"javascript", "c++"}},
+		{testName: "Nothing found", text: "Manager", expectedKeywords: []string{}},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.testName, func(t *testing.T) {
+			assert.Equal(t, tc.expectedKeywords, Keywords(tc.text, vocabulary))
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package xlsx implements a minimal streaming writer of single-sheet XLSX workbooks.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// ContentType presents MIME type of XLSX workbook.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+const (
+	sheetHeader = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetFooter = `</sheetData></worksheet>`
+)
+
+// staticParts presents workbook parts which don't depend on written data.
+var staticParts = []struct {
+	name    string
+	content string
+}{
+	{
+		name: "[Content_Types].xml",
+		content: xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`</Types>`,
+	},
+	{
+		name: "_rels/.rels",
+		content: xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`,
+	},
+	{
+		name: "xl/workbook.xml",
+		content: xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+	},
+	{
+		name: "xl/_rels/workbook.xml.rels",
+		content: xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`</Relationships>`,
+	},
+}
+
+// Writer writes rows to a workbook as they come, so whole table is never kept in memory.
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+// NewWriter creates a new instance of Writer which writes workbook to w.
+func NewWriter(w io.Writer) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	for _, part := range staticParts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create workbook part %s: %w", part.name, err)
+		}
+
+		if _, err := io.WriteString(pw, part.content); err != nil {
+			return nil, fmt.Errorf("cannot write workbook part %s: %w", part.name, err)
+		}
+	}
+
+	sw, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("cannot create worksheet: %w", err)
+	}
+
+	sheet := bufio.NewWriter(sw)
+	if _, err := sheet.WriteString(sheetHeader); err != nil {
+		return nil, fmt.Errorf("cannot write worksheet: %w", err)
+	}
+
+	return &Writer{
+		zip:   zw,
+		sheet: sheet,
+	}, nil
+}
+
+// Write writes a single row, all values are written as strings.
+func (w *Writer) Write(record []string) error {
+	if _, err := w.sheet.WriteString("<row>"); err != nil {
+		return fmt.Errorf("cannot write row: %w", err)
+	}
+
+	for _, value := range record {
+		if _, err := w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return fmt.Errorf("cannot write cell: %w", err)
+		}
+
+		if err := xml.EscapeText(w.sheet, []byte(value)); err != nil {
+			return fmt.Errorf("cannot write cell: %w", err)
+		}
+
+		if _, err := w.sheet.WriteString("</t></is></c>"); err != nil {
+			return fmt.Errorf("cannot write cell: %w", err)
+		}
+	}
+
+	if _, err := w.sheet.WriteString("</row>"); err != nil {
+		return fmt.Errorf("cannot write row: %w", err)
+	}
+
+	return nil
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return fmt.Errorf("cannot flush worksheet: %w", err)
+	}
+
+	if err := w.zip.Flush(); err != nil {
+		return fmt.Errorf("cannot flush workbook: %w", err)
+	}
+
+	return nil
+}
+
+// Close finishes the workbook. It doesn't close the underlying writer.
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetFooter); err != nil {
+		return fmt.Errorf("cannot write worksheet: %w", err)
+	}
+
+	if err := w.sheet.Flush(); err != nil {
+		return fmt.Errorf("cannot flush worksheet: %w", err)
+	}
+
+	if err := w.zip.Close(); err != nil {
+		return fmt.Errorf("cannot close workbook: %w", err)
+	}
+
+	return nil
+}
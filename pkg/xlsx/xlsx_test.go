@@ -0,0 +1,62 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert.NoError(t, w.Write([]string{"id", "name"}))
+	assert.NoError(t, w.Write([]string{"1", "Tom & <Jerry>"}))
+	assert.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	var sheet []byte
+
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			sheet, err = io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			rc.Close()
+		}
+	}
+
+	assert.ElementsMatch(t, []string{
+		"[Content_Types].xml",
+		"_rels/.rels",
+		"xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels",
+		"xl/worksheets/sheet1.xml",
+	}, names)
+
+	expectedSheet := sheetHeader +
+		`<row><c t="inlineStr"><is><t xml:space="preserve">id</t></is></c><c t="inlineStr"><is><t xml:space="preserve">name</t></is></c></row>` +
+		`<row><c t="inlineStr"><is><t xml:space="preserve">1</t></is></c><c t="inlineStr"><is><t xml:space="preserve">Tom &amp; &lt;Jerry&gt;</t></is></c></row>` +
+		sheetFooter
+	assert.Equal(t, expectedSheet, string(sheet))
+}
@@ -8,25 +8,347 @@ CREATE TABLE IF NOT EXISTS Users
 	name VARCHAR UNIQUE NOT NULL,
 	password VARCHAR NOT NULL,
 	is_admin BOOLEAN DEFAULT FALSE,
+	first_name VARCHAR NOT NULL DEFAULT '',
+	last_name VARCHAR NOT NULL DEFAULT '',
+	hired DATE,
+	hide_from_leaderboard BOOLEAN NOT NULL DEFAULT FALSE,
+	email VARCHAR NOT NULL DEFAULT '',
+	muted_notifications VARCHAR[] NOT NULL DEFAULT '{}',
 	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS hide_from_leaderboard BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Users existing before eligibility rules have no hire date, so tenure of their referrals isn't checked.
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS first_name VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS last_name VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS hired DATE;
+
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS email VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS muted_notifications VARCHAR[] NOT NULL DEFAULT '{}';
+
 CREATE TABLE IF NOT EXISTS Requests
 (
 	id SERIAL PRIMARY KEY,
 	author_id INTEGER NOT NULL,
 	candidate_name VARCHAR NOT NULL,
 	candidate_surname VARCHAR NOT NULL,
+	position VARCHAR NOT NULL DEFAULT '',
+	department VARCHAR NOT NULL DEFAULT '',
 	cv_file_id VARCHAR NOT NULL,
+	cv_content_type VARCHAR NOT NULL DEFAULT '',
+	cv_signature VARCHAR NOT NULL DEFAULT '',
+	scan_attempts INTEGER NOT NULL DEFAULT 0,
+	scan_next_attempt TIMESTAMP,
+	scan_error VARCHAR NOT NULL DEFAULT '',
+	held_status VARCHAR NOT NULL DEFAULT '',
+	cv_text TEXT NOT NULL DEFAULT '',
+	cv_keywords VARCHAR[] NOT NULL DEFAULT '{}',
+	cv_extracted TIMESTAMP,
+	bonus_eligible BOOLEAN NOT NULL DEFAULT TRUE,
+	assignee_id INTEGER,
+	assigned TIMESTAMP,
+	reminded TIMESTAMP,
 	status VARCHAR CHECK (
 		Status = 'accepted' OR
 		Status = 'rejected' OR
-		Status = 'submitted'
+		Status = 'submitted' OR
+		Status = 'pending_scan' OR
+		Status = 'quarantined'
 	) DEFAULT 'submitted',
 	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	status_changed TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	decided_at TIMESTAMP,
 	CONSTRAINT fkUser
 		FOREIGN KEY(author_id)
+			REFERENCES Users(id),
+	CONSTRAINT fkAssignee
+		FOREIGN KEY(assignee_id)
+			REFERENCES Users(id)
+);
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS position VARCHAR NOT NULL DEFAULT '';
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS department VARCHAR NOT NULL DEFAULT '';
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS bonus_eligible BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS assignee_id INTEGER REFERENCES Users(id);
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS assigned TIMESTAMP;
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS reminded TIMESTAMP;
+
+-- Status change time of requests created before it was tracked is approximated by their last update.
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS status_changed TIMESTAMP;
+UPDATE Requests SET status_changed = updated WHERE status_changed IS NULL;
+ALTER TABLE Requests ALTER COLUMN status_changed SET DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS cv_content_type VARCHAR NOT NULL DEFAULT '';
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS cv_text TEXT NOT NULL DEFAULT '';
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS cv_keywords VARCHAR[] NOT NULL DEFAULT '{}';
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS cv_extracted TIMESTAMP;
+
+-- Decision time of requests decided before decided_at was tracked is approximated by their last update.
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS decided_at TIMESTAMP;
+UPDATE Requests SET decided_at = updated WHERE decided_at IS NULL AND status IN ('accepted', 'rejected');
+
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS scan_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS scan_next_attempt TIMESTAMP;
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS scan_error VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS cv_signature VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE Requests ADD COLUMN IF NOT EXISTS held_status VARCHAR NOT NULL DEFAULT '';
+
+-- Status check of existing tables is replaced to allow statuses of CVs which are scanned for malware.
+ALTER TABLE Requests DROP CONSTRAINT IF EXISTS requests_status_check;
+ALTER TABLE Requests ADD CONSTRAINT requests_status_check
+	CHECK (status IN ('accepted', 'rejected', 'submitted', 'pending_scan', 'quarantined'));
+
+CREATE INDEX IF NOT EXISTS requests_cv_text ON Requests USING GIN (to_tsvector('simple', cv_text));
+CREATE INDEX IF NOT EXISTS requests_cv_keywords ON Requests USING GIN (cv_keywords);
+CREATE INDEX IF NOT EXISTS requests_cv_pending_extraction ON Requests (id) WHERE cv_extracted IS NULL;
+CREATE INDEX IF NOT EXISTS requests_cv_file ON Requests (cv_file_id);
+
+CREATE TABLE IF NOT EXISTS Bonus_Rules
+(
+	position VARCHAR PRIMARY KEY,
+	amount NUMERIC(12, 2) NOT NULL CHECK (amount >= 0),
+	currency VARCHAR(3) NOT NULL,
+	probation_days INTEGER NOT NULL DEFAULT 0 CHECK (probation_days >= 0),
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS Bonuses
+(
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER UNIQUE NOT NULL,
+	user_id INTEGER NOT NULL,
+	amount NUMERIC(12, 2) NOT NULL,
+	currency VARCHAR(3) NOT NULL,
+	status VARCHAR CHECK (
+		Status = 'pending' OR
+		Status = 'approved' OR
+		Status = 'paid' OR
+		Status = 'cancelled'
+	) DEFAULT 'pending',
+	eligible_at TIMESTAMP NOT NULL,
+	approved_at TIMESTAMP,
+	paid_at TIMESTAMP,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT fkRequest
+		FOREIGN KEY(request_id)
+			REFERENCES Requests(id),
+	CONSTRAINT fkBonusUser
+		FOREIGN KEY(user_id)
+			REFERENCES Users(id)
+);
+
+CREATE TABLE IF NOT EXISTS Eligibility_Rules
+(
+	code VARCHAR PRIMARY KEY,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	action VARCHAR NOT NULL CHECK (
+		Action = 'reject' OR
+		Action = 'no_bonus'
+	) DEFAULT 'reject',
+	value INTEGER NOT NULL DEFAULT 0 CHECK (value >= 0),
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO Eligibility_Rules (code, action, value) VALUES
+	('self_referral', 'reject', 0),
+	('current_employee', 'reject', 0),
+	('duplicate_candidate', 'reject', 183),
+	('referrer_tenure', 'no_bonus', 90)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS Recruiters
+(
+	user_id INTEGER NOT NULL,
+	position VARCHAR NOT NULL DEFAULT '',
+	PRIMARY KEY (user_id, position),
+	CONSTRAINT fkRecruiter
+		FOREIGN KEY(user_id)
+			REFERENCES Users(id)
+);
+
+CREATE TABLE IF NOT EXISTS Assignment_Rules
+(
+	position VARCHAR PRIMARY KEY,
+	strategy VARCHAR NOT NULL CHECK (
+		Strategy = 'manual' OR
+		Strategy = 'round_robin' OR
+		Strategy = 'least_loaded'
+	) DEFAULT 'manual',
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS Webhooks
+(
+	id SERIAL PRIMARY KEY,
+	url VARCHAR NOT NULL,
+	events VARCHAR[] NOT NULL DEFAULT '{}',
+	secret VARCHAR NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS Webhook_Deliveries
+(
+	id SERIAL PRIMARY KEY,
+	webhook_id INTEGER NOT NULL,
+	event_id BIGINT NOT NULL,
+	event VARCHAR NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR CHECK (
+		Status = 'pending' OR
+		Status = 'delivered' OR
+		Status = 'failed'
+	) DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	response_code INTEGER,
+	last_error VARCHAR NOT NULL DEFAULT '',
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (webhook_id, event_id),
+	CONSTRAINT fkWebhook
+		FOREIGN KEY(webhook_id)
+			REFERENCES Webhooks(id)
+			ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due ON Webhook_Deliveries (next_attempt) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS Outbox
+(
+	id BIGSERIAL PRIMARY KEY,
+	event VARCHAR NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR CHECK (
+		Status = 'pending' OR
+		Status = 'published' OR
+		Status = 'failed'
+	) DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_error VARCHAR NOT NULL DEFAULT '',
+	consumers VARCHAR[] NOT NULL DEFAULT '{}',
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	published TIMESTAMP
+);
+
+ALTER TABLE Outbox ADD COLUMN IF NOT EXISTS consumers VARCHAR[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS outbox_due ON Outbox (next_attempt) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS Files
+(
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER NOT NULL,
+	kind VARCHAR CHECK (
+		Kind = 'cv' OR
+		Kind = 'cover_letter' OR
+		Kind = 'portfolio' OR
+		Kind = 'other'
+	) NOT NULL,
+	file_key VARCHAR NOT NULL,
+	filename VARCHAR NOT NULL,
+	content_type VARCHAR NOT NULL DEFAULT '',
+	size BIGINT NOT NULL,
+	sha256 VARCHAR NOT NULL,
+	uploaded_by INTEGER NOT NULL,
+	scan_status VARCHAR CHECK (
+		Scan_Status = 'pending' OR
+		Scan_Status = 'clean' OR
+		Scan_Status = 'infected'
+	) NOT NULL DEFAULT 'clean',
+	scan_attempts INTEGER NOT NULL DEFAULT 0,
+	scan_next_attempt TIMESTAMP,
+	scan_error VARCHAR NOT NULL DEFAULT '',
+	signature VARCHAR NOT NULL DEFAULT '',
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT fkRequest
+		FOREIGN KEY(request_id)
+			REFERENCES Requests(id)
+			ON DELETE CASCADE,
+	CONSTRAINT fkUploader
+		FOREIGN KEY(uploaded_by)
+			REFERENCES Users(id)
+);
+
+ALTER TABLE Files ADD COLUMN IF NOT EXISTS scan_status VARCHAR NOT NULL DEFAULT 'clean'
+	CHECK (scan_status IN ('pending', 'clean', 'infected'));
+ALTER TABLE Files ADD COLUMN IF NOT EXISTS scan_attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE Files ADD COLUMN IF NOT EXISTS scan_next_attempt TIMESTAMP;
+ALTER TABLE Files ADD COLUMN IF NOT EXISTS scan_error VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE Files ADD COLUMN IF NOT EXISTS signature VARCHAR NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS files_request ON Files (request_id, created);
+CREATE INDEX IF NOT EXISTS files_key ON Files (file_key);
+CREATE INDEX IF NOT EXISTS files_pending_scan ON Files (id) WHERE scan_status = 'pending';
+
+-- CVs of requests created before files were tracked are recorded as their first CV versions.
+-- Their size and hash are unknown, the insert is skipped for CVs which are already recorded.
+INSERT INTO Files (request_id, kind, file_key, filename, content_type, size, sha256, uploaded_by, created)
+SELECT
+	r.id, 'cv', r.cv_file_id, regexp_replace(r.cv_file_id, '^.*/', ''), r.cv_content_type, 0, '', r.author_id, r.created
+FROM
+	Requests r
+WHERE
+	r.cv_file_id IS NOT NULL AND r.cv_file_id <> '' AND
+	NOT EXISTS (SELECT 1 FROM Files f WHERE f.request_id = r.id AND f.file_key = r.cv_file_id);
+
+CREATE TABLE IF NOT EXISTS Uploads
+(
+	id VARCHAR PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	file_key VARCHAR NOT NULL,
+	filename VARCHAR NOT NULL,
+	content_type VARCHAR NOT NULL,
+	size BIGINT NOT NULL,
+	sha256 VARCHAR NOT NULL DEFAULT '',
+	expires TIMESTAMP NOT NULL,
+	used TIMESTAMP,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT fkUser
+		FOREIGN KEY(user_id)
+			REFERENCES Users(id)
+			ON DELETE CASCADE
+);
+
+ALTER TABLE Uploads ADD COLUMN IF NOT EXISTS sha256 VARCHAR NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS uploads_key ON Uploads (file_key);
+CREATE INDEX IF NOT EXISTS uploads_expires ON Uploads (expires);
+
+CREATE TABLE IF NOT EXISTS CV_Downloads
+(
+	id SERIAL PRIMARY KEY,
+	request_id INTEGER NOT NULL,
+	file_id INTEGER,
+	user_id INTEGER NOT NULL,
+	mode VARCHAR CHECK (
+		Mode = 'link' OR
+		Mode = 'stream'
+	) NOT NULL,
+	byte_range VARCHAR NOT NULL DEFAULT '',
+	remote_addr VARCHAR NOT NULL DEFAULT '',
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT fkRequest
+		FOREIGN KEY(request_id)
+			REFERENCES Requests(id)
+			ON DELETE CASCADE,
+	CONSTRAINT fkFile
+		FOREIGN KEY(file_id)
+			REFERENCES Files(id)
+			ON DELETE SET NULL,
+	CONSTRAINT fkUser
+		FOREIGN KEY(user_id)
 			REFERENCES Users(id)
 );
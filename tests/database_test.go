@@ -2,6 +2,9 @@ package tests
 
 import (
 	"fmt"
+	"time"
+
+	"github.com/cyberdr0id/referral/internal/repository"
 )
 
 const (
@@ -14,6 +17,8 @@ const (
 	defaultPageSize         = 1
 	defaultCandidateName    = "candidate"
 	defaultCandidateSurname = "candidate"
+	defaultPosition         = "developer"
+	defaultDepartment       = "engineering"
 	defaultRequestsLength   = 1
 
 	statusAccepted = "accepted"
@@ -26,7 +31,13 @@ func makeRequest(s *ReferralAPISuite) (id string, requestID string) {
 	}
 	s.NoError(err)
 
-	requestID, err = s.repo.AddCandidate(id, defaultCandidateName, defaultCandidateSurname, defaultFileID)
+	requestID, err = s.repo.AddCandidate(id, defaultCandidateName, defaultCandidateSurname, defaultPosition, defaultDepartment, repository.File{
+		Filename:    defaultFileID,
+		ContentType: "application/pdf",
+		Size:        1,
+		SHA256:      "-",
+		Key:         defaultFileID,
+	}, repository.StatusSubmitted, true, nil)
 	if err != nil {
 		s.FailNow(fmt.Errorf("cannot add candidate: %w", err).Error())
 	}
@@ -73,7 +84,13 @@ func (s *ReferralAPISuite) TestAddCandidate() {
 func (s *ReferralAPISuite) TestGetRequests() {
 	id, _ := makeRequest(s)
 
-	requests, err := s.repo.GetRequests(id, defaultStatus, defaultPageNumber, defaultPageSize)
+	requests, err := s.repo.GetRequests(repository.RequestFilter{
+		AuthorID:   id,
+		Statuses:   []string{defaultStatus},
+		Positions:  []string{defaultPosition},
+		PageNumber: defaultPageNumber,
+		PageSize:   defaultPageSize,
+	})
 	if err != nil {
 		s.FailNow(fmt.Errorf("cannot get requests: %w", err).Error())
 	}
@@ -81,6 +98,14 @@ func (s *ReferralAPISuite) TestGetRequests() {
 
 	s.Equal(defaultRequestsLength, len(requests))
 
+	total, err := s.repo.CountRequests(repository.RequestFilter{AuthorID: id})
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot count requests: %w", err).Error())
+	}
+	s.NoError(err)
+
+	s.Equal(defaultRequestsLength, total)
+
 	s.clearTables()
 }
 
@@ -109,3 +134,72 @@ func (s *ReferralAPISuite) TestGetCVID() {
 
 	s.clearTables()
 }
+
+func (s *ReferralAPISuite) TestGetStats() {
+	_, requestID := makeRequest(s)
+
+	err := s.repo.UpdateRequest(requestID, statusAccepted)
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot update request: %w", err).Error())
+	}
+	s.NoError(err)
+
+	now := time.Now().UTC()
+
+	stats, err := s.repo.GetStats(now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot get stats: %w", err).Error())
+	}
+	s.NoError(err)
+
+	s.Equal(defaultRequestsLength, stats.Total)
+	s.Equal(defaultRequestsLength, stats.ByStatus[statusAccepted])
+	s.Equal(defaultRequestsLength, stats.ByPosition[defaultPosition])
+	s.Equal(float64(1), stats.ConversionRate)
+	s.NotNil(stats.MedianDecisionHours)
+
+	s.clearTables()
+}
+
+func (s *ReferralAPISuite) TestBonusCreation() {
+	userID, requestID := makeRequest(s)
+
+	err := s.repo.SaveBonusRule(repository.BonusRule{Position: defaultPosition, Amount: "500.00", Currency: "USD", ProbationDays: 90})
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot save bonus rule: %w", err).Error())
+	}
+	s.NoError(err)
+
+	err = s.repo.UpdateRequest(requestID, statusAccepted)
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot update request: %w", err).Error())
+	}
+	s.NoError(err)
+
+	bonuses, err := s.repo.GetBonuses(repository.BonusFilter{UserID: userID})
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot get bonuses: %w", err).Error())
+	}
+	s.NoError(err)
+
+	s.Len(bonuses, 1)
+	s.Equal(requestID, bonuses[0].RequestID)
+	s.Equal(repository.BonusPending, bonuses[0].Status)
+	s.Equal("500.00", bonuses[0].Amount)
+
+	err = s.repo.UpdateRequest(requestID, defaultStatus)
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot update request: %w", err).Error())
+	}
+	s.NoError(err)
+
+	bonuses, err = s.repo.GetBonuses(repository.BonusFilter{UserID: userID})
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot get bonuses: %w", err).Error())
+	}
+	s.NoError(err)
+
+	s.Equal(repository.BonusCancelled, bonuses[0].Status)
+
+	s.clearTables()
+}
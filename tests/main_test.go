@@ -47,7 +47,8 @@ func (s *ReferralAPISuite) TearDownTest() {
 
 func (s *ReferralAPISuite) clearTables() {
 	clearUsersQuery := `TRUNCATE TABLE users CASCADE`
-	clearRequestsQuery := `TRUNCATE TABLE requests`
+	clearRequestsQuery := `TRUNCATE TABLE requests CASCADE`
+	clearBonusRulesQuery := `TRUNCATE TABLE bonus_rules`
 
 	_, err := s.db.Exec(clearUsersQuery)
 	if err != nil {
@@ -58,4 +59,9 @@ func (s *ReferralAPISuite) clearTables() {
 	if err != nil {
 		s.FailNow(fmt.Errorf("cannot clear REQUESTS table: %w", err).Error())
 	}
+
+	_, err = s.db.Exec(clearBonusRulesQuery)
+	if err != nil {
+		s.FailNow(fmt.Errorf("cannot clear BONUS_RULES table: %w", err).Error())
+	}
 }